@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/terdia/mvp/pkg/dto"
+)
+
+// deleteCurrentUserHandler deletes the caller's account, see
+// AccountService.Delete.
+func (app *application) deleteCurrentUserHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.DeleteAccountRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	validationErrors, err := app.accountService.Delete(app.contextGetUser(r), input)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Message:   "Account was deleted",
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+// exportCurrentUserHandler hands the caller a ZIP archive of the data kept
+// about them, one JSON file each for the profile, tokens, purchases and ledger.
+func (app *application) exportCurrentUserHandler(rw http.ResponseWriter, r *http.Request) {
+
+	export, err := app.accountService.Export(app.contextGetUser(r))
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	tokens := []dto.ExportToken{}
+	for _, token := range export.Tokens {
+		tokens = append(tokens, dto.ExportToken{
+			ID:         token.ID,
+			Scope:      token.Scope,
+			CreatedAt:  token.CreatedAt,
+			Expiry:     token.Expiry,
+			LastSeenIP: token.LastSeenIP,
+			LastSeenAt: token.LastSeenAt,
+		})
+	}
+
+	purchases := []dto.APIPurchase{}
+	for _, purchase := range export.Purchases {
+		purchases = append(purchases, getAPIPurchase(purchase))
+	}
+
+	entries := []dto.APILedgerEntry{}
+	for _, entry := range export.Ledger {
+		entries = append(entries, getAPILedgerEntry(entry))
+	}
+
+	rw.Header().Set("Cache-Control", "no-store")
+
+	if err = app.writeZip(rw, http.StatusOK, fmt.Sprintf("user-%d-export.zip", export.User.ID), []zipFile{
+		{Name: "profile.json", Content: getAPIUser(export.User)},
+		{Name: "tokens.json", Content: tokens},
+		{Name: "purchases.json", Content: purchases},
+		{Name: "ledger.json", Content: entries},
+	}); err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+func (app *application) listUserHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	filters := data.Filters{
+		Page:         app.readInt(qs, "page", 1, v),
+		PageSize:     app.readInt(qs, "page_size", 20, v),
+		Sort:         app.readString(qs, "sort", "id"),
+		SortSafeList: []string{"id", "username", "created_at", "deposit", "-id", "-username", "-created_at", "-deposit"},
+	}
+
+	filter := data.UserFilter{
+		Query: app.readString(qs, "q", ""),
+		Role:  app.readString(qs, "role", ""),
+	}
+
+	if qs.Has("suspended") {
+		suspended := app.readBool(qs, "suspended", false, v)
+		filter.Suspended = &suspended
+	}
+
+	filters.ValidateFilters(v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	users, metadata, err := app.adminService.ListUsers(filter, filters)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listUserResponse := dto.ListUserResponse{
+		Users: []dto.APIUser{},
+	}
+
+	for _, user := range users {
+		listUserResponse.Users = append(listUserResponse.Users, getAPIUser(user))
+	}
+
+	if len(users) > 0 {
+		listUserResponse.Metadata = &metadata
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listUserResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) adminShowUserHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	user, err := app.adminService.GetUser(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	app.writeAdminUser(rw, r, user, nil, nil)
+}
+
+func (app *application) changeUserRoleHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	var input dto.RoleRequest
+	if err = app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	user, validationErrors, err := app.adminService.ChangeRole(app.contextGetUser(r), id, input)
+	app.writeAdminUser(rw, r, user, validationErrors, err)
+}
+
+func (app *application) suspendUserHandler(rw http.ResponseWriter, r *http.Request) {
+	app.setSuspended(rw, r, true)
+}
+
+func (app *application) unsuspendUserHandler(rw http.ResponseWriter, r *http.Request) {
+	app.setSuspended(rw, r, false)
+}
+
+// setSuspended serves both suspend routes. The reason is optional, so an empty
+// body is accepted.
+func (app *application) setSuspended(rw http.ResponseWriter, r *http.Request, suspend bool) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	var input dto.SuspendRequest
+	if r.ContentLength != 0 {
+		if err = app.readJson(rw, r, &input); err != nil {
+			app.badRequestResponse(rw, r, err)
+			return
+		}
+	}
+
+	var (
+		user             *data.User
+		validationErrors map[string]string
+	)
+
+	if suspend {
+		user, validationErrors, err = app.adminService.Suspend(app.contextGetUser(r), id, input)
+	} else {
+		user, validationErrors, err = app.adminService.Unsuspend(app.contextGetUser(r), id, input)
+	}
+
+	app.writeAdminUser(rw, r, user, validationErrors, err)
+}
+
+func (app *application) adjustDepositHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	var input dto.DepositAdjustmentRequest
+	if err = app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	user, entry, validationErrors, err := app.adminService.AdjustDeposit(app.contextGetUser(r), id, input)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusCreated, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data: dto.DepositAdjustmentResponse{
+			User:  getAPIUser(user),
+			Entry: getAPILedgerEntry(entry),
+		},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) listAuditHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	filters := data.Filters{
+		Page:         app.readInt(qs, "page", 1, v),
+		PageSize:     app.readInt(qs, "page_size", 20, v),
+		Sort:         app.readString(qs, "sort", "-id"),
+		SortSafeList: []string{"id", "-id"},
+	}
+
+	filter := data.AuditFilter{
+		ActorID:      int64(app.readInt(qs, "actor_id", 0, v)),
+		TargetUserID: int64(app.readInt(qs, "user_id", 0, v)),
+		Action:       app.readString(qs, "action", ""),
+	}
+
+	filters.ValidateFilters(v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	entries, metadata, err := app.adminService.ListAudit(filter, filters)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listAuditResponse := dto.ListAuditResponse{
+		Entries: []dto.APIAuditEntry{},
+	}
+
+	for _, entry := range entries {
+		listAuditResponse.Entries = append(listAuditResponse.Entries, dto.APIAuditEntry{
+			ID:           entry.ID,
+			ActorID:      entry.ActorID,
+			Action:       entry.Action,
+			TargetUserID: entry.TargetUserID,
+			Reason:       entry.Reason,
+			Details:      entry.Details,
+			CreatedAt:    entry.CreatedAt,
+		})
+	}
+
+	if len(entries) > 0 {
+		listAuditResponse.Metadata = &metadata
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listAuditResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+// writeAdminUser answers an admin action on a user with the user as it is now.
+func (app *application) writeAdminUser(rw http.ResponseWriter, r *http.Request, user *data.User, validationErrors map[string]string, err error) {
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.UserResponse{User: getAPIUser(user)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+)
+
+func (app *application) listAPIKeyHandler(rw http.ResponseWriter, r *http.Request) {
+
+	keys, err := app.apiKeyService.List(app.machineID(r))
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listAPIKeyResponse := dto.ListAPIKeyResponse{
+		APIKeys: []dto.APIKey{},
+	}
+
+	for _, key := range keys {
+		listAPIKeyResponse.APIKeys = append(listAPIKeyResponse.APIKeys, getAPIKey(key))
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listAPIKeyResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+// createAPIKeyHandler issues a key for the machine. The plaintext key is in
+// this response only, it can not be recovered later.
+func (app *application) createAPIKeyHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.APIKeyRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	machineID := app.machineID(r)
+
+	key, validationErrors, err := app.apiKeyService.Create(machineID, input)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/machines/%d/keys/%d", machineID, key.ID))
+
+	if err = app.writeJson(rw, http.StatusCreated, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.APIKeyResponse{APIKey: getAPIKey(key)},
+	}, headers); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) revokeAPIKeyHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	if err = app.apiKeyService.Revoke(app.machineID(r), id); err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Message:   "api key successfully revoked",
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func getAPIKey(key *data.APIKey) dto.APIKey {
+	return dto.APIKey{
+		ID:         key.ID,
+		MachineID:  key.MachineID,
+		Name:       key.Name,
+		Key:        key.Plaintext,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.Expiry,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
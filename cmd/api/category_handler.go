@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+)
+
+func (app *application) listCategoryHandler(rw http.ResponseWriter, r *http.Request) {
+
+	categories, err := app.categoryService.List()
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listCategoryResponse := dto.ListCategoryResponse{
+		Categories: []dto.APICategory{},
+	}
+
+	for _, category := range categories {
+		listCategoryResponse.Categories = append(listCategoryResponse.Categories, getAPICategory(*category))
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listCategoryResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) showCategoryHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	category, err := app.categoryService.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.CategoryResponse{Category: getAPICategory(*category)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) createCategoryHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.CategoryRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	category, validationErrors, err := app.categoryService.Create(input)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/categories/%d", category.ID))
+
+	if err = app.writeJson(rw, http.StatusCreated, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.CategoryResponse{Category: getAPICategory(*category)},
+	}, headers); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) updateCategoryHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	var input dto.CategoryRequest
+	if err = app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	category, validationErrors, err := app.categoryService.Update(id, input)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.CategoryResponse{Category: getAPICategory(*category)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) deleteCategoryHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	validationErrors, err := app.categoryService.Remove(id)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Message:   "category successfully deleted",
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func getAPICategory(category data.Category) dto.APICategory {
+	return dto.APICategory{
+		ID:        category.ID,
+		Name:      category.Name,
+		Slug:      category.Slug,
+		ParentID:  category.ParentID,
+		CreatedAt: category.CreatedAt,
+	}
+}
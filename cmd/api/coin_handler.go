@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+)
+
+func (app *application) showCoinInventoryHandler(rw http.ResponseWriter, r *http.Request) {
+
+	inventory, err := app.coinService.Inventory(app.machineID(r))
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      getCoinInventoryResponse(inventory),
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) refillCoinsHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.RefillCoinsRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	inventory, validationErrors, err := app.coinService.Refill(app.machineID(r), input.Coin, input.Quantity)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Message:   "Coins refilled",
+		Data:      getCoinInventoryResponse(inventory),
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func getCoinInventoryResponse(inventory data.CoinInventory) dto.CoinInventoryResponse {
+
+	response := dto.CoinInventoryResponse{
+		Coins: []dto.APICoin{},
+	}
+
+	for _, coin := range data.Coins {
+		response.Coins = append(response.Coins, dto.APICoin{
+			Coin:     coin,
+			Quantity: inventory[coin],
+		})
+	}
+
+	return response
+}
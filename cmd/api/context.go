@@ -10,7 +10,8 @@ import (
 type contextKey string
 
 const (
-	userContextKey = contextKey("user")
+	userContextKey  = contextKey("user")
+	tokenContextKey = contextKey("token")
 )
 
 func (app *application) contextSetUser(r *http.Request, user *data.User) *http.Request {
@@ -28,3 +29,17 @@ func (app *application) contextGetUser(r *http.Request) *data.User {
 
 	return user
 }
+
+// contextSetToken keeps the token the user authenticated with, so the session
+// it stands for can be told apart from the user's others.
+func (app *application) contextSetToken(r *http.Request, token string) *http.Request {
+	ctx := context.WithValue(r.Context(), tokenContextKey, token)
+
+	return r.WithContext(ctx)
+}
+
+func (app *application) contextGetToken(r *http.Request) string {
+	token, _ := r.Context().Value(tokenContextKey).(string)
+
+	return token
+}
@@ -69,6 +69,13 @@ func (app *application) invalidAuthenticationTokenResponse(w http.ResponseWriter
 	})
 }
 
+func (app *application) accountSuspendedResponse(w http.ResponseWriter, r *http.Request) {
+	app.errorResponse(w, r, http.StatusForbidden, dto.ResponseObject{
+		StatusMsg: dto.Fail,
+		Message:   "your user account has been suspended",
+	})
+}
+
 func (app *application) authenticationRequiredResponse(w http.ResponseWriter, r *http.Request) {
 	app.errorResponse(w, r, http.StatusUnauthorized, dto.ResponseObject{
 		Message: "you must be authenticated to access this resource",
@@ -80,3 +87,24 @@ func (app *application) notPermittedRResponse(w http.ResponseWriter, r *http.Req
 		Message: "your user account doesn't have the necessary permissions to perform this operation",
 	})
 }
+
+func (app *application) editConflictResponse(w http.ResponseWriter, r *http.Request) {
+	app.errorResponse(w, r, http.StatusConflict, dto.ResponseObject{
+		StatusMsg: dto.Fail,
+		Message:   "unable to update the record due to an edit conflict, please try again",
+	})
+}
+
+func (app *application) idempotencyKeyMismatchResponse(w http.ResponseWriter, r *http.Request) {
+	app.errorResponse(w, r, http.StatusUnprocessableEntity, dto.ResponseObject{
+		StatusMsg: dto.Fail,
+		Message:   "the idempotency key has already been used for a different request",
+	})
+}
+
+func (app *application) idempotencyKeyInProgressResponse(w http.ResponseWriter, r *http.Request) {
+	app.errorResponse(w, r, http.StatusConflict, dto.ResponseObject{
+		StatusMsg: dto.Fail,
+		Message:   "a request with the same idempotency key is still being processed",
+	})
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,9 +12,11 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/terdia/mvp/internal/data"
 	"github.com/terdia/mvp/pkg/dto"
 	"github.com/terdia/mvp/pkg/validator"
 )
@@ -31,6 +36,18 @@ func (app *application) extractIntParamFromContext(r *http.Request, key string)
 	return id, nil
 }
 
+// machineID returns the machine a request is scoped to: the {machine} route
+// parameter, already checked by requireMachine, or the default machine on
+// routes that predate the fleet.
+func (app *application) machineID(r *http.Request) int64 {
+	id, err := strconv.ParseInt(chi.URLParam(r, "machine"), 10, 64)
+	if err != nil {
+		return data.DefaultMachineID
+	}
+
+	return id
+}
+
 func (app *application) readInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
 
 	s := qs.Get(key)
@@ -47,6 +64,22 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 	return i
 }
 
+func (app *application) readBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+
+	s := qs.Get(key)
+	if s == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+
+	return b
+}
+
 func (app *application) readString(qs url.Values, key, defaultValue string) string {
 
 	str := qs.Get(key)
@@ -57,6 +90,96 @@ func (app *application) readString(qs url.Values, key, defaultValue string) stri
 	return str
 }
 
+// readIfMatch returns the version a client expects from an If-Match header, or
+// 0 when the header is absent or "*".
+func (app *application) readIfMatch(r *http.Request) (int32, error) {
+	etag := r.Header.Get("If-Match")
+	if etag == "" || etag == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(etag, "W/"), `"`), 10, 32)
+	if err != nil || version < 1 {
+		return 0, errors.New("invalid If-Match header")
+	}
+
+	return int32(version), nil
+}
+
+// hasPermission reports whether the authenticated user holds code, for handlers
+// that widen what a caller may see rather than gate the whole route.
+func (app *application) hasPermission(r *http.Request, code string) (bool, error) {
+	permissions, err := app.permissions(r)
+	if err != nil {
+		return false, err
+	}
+
+	return permissions.Includes(code), nil
+}
+
+// permissions returns what the authenticated caller may do. A machine holds the
+// scopes of its API key, and only on routes scoped to its own machine.
+func (app *application) permissions(r *http.Request) (data.Permissions, error) {
+	user := app.contextGetUser(r)
+
+	if user.IsMachine() {
+		if chi.URLParam(r, "machine") == "" || app.machineID(r) != user.APIKey.MachineID {
+			return data.Permissions{}, nil
+		}
+
+		return user.APIKey.Scopes, nil
+	}
+
+	return app.userService.GetPermissions(user.ID)
+}
+
+// readDate parses a YYYY-MM-DD query parameter, returning the zero time when it is absent.
+func (app *application) readDate(qs url.Values, key string, v *validator.Validator) time.Time {
+
+	s := qs.Get(key)
+	if s == "" {
+		return time.Time{}
+	}
+
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		v.AddError(key, "must be a date in the format YYYY-MM-DD")
+		return time.Time{}
+	}
+
+	return t
+}
+
+// wantsCSV reports whether the client asked for CSV, either with ?format=csv
+// or through the Accept header.
+func (app *application) wantsCSV(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+func (app *application) writeCSV(rw http.ResponseWriter, status int, filename string, records [][]string) error {
+
+	rw.Header().Set("Content-Type", "text/csv")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	rw.WriteHeader(status)
+
+	w := csv.NewWriter(rw)
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// csvText makes user supplied text safe to open in a spreadsheet, which would
+// run a cell starting with =, +, - or @ as a formula.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
 func (app *application) writeJson(rw http.ResponseWriter, status int, envelop dto.ResponseObject, headers http.Header) error {
 
 	js, err := json.MarshalIndent(envelop, "", "\t")
@@ -77,6 +200,48 @@ func (app *application) writeJson(rw http.ResponseWriter, status int, envelop dt
 	return nil
 }
 
+// zipFile is a file of the archive written by writeZip, Content is encoded as
+// JSON.
+type zipFile struct {
+	Name    string
+	Content interface{}
+}
+
+// writeZip builds the archive in memory first, so a failure can still be
+// answered with an error response.
+func (app *application) writeZip(rw http.ResponseWriter, status int, filename string, files []zipFile) error {
+
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	for _, file := range files {
+		js, err := json.MarshalIndent(file.Content, "", "\t")
+		if err != nil {
+			return err
+		}
+
+		f, err := w.Create(file.Name)
+		if err != nil {
+			return err
+		}
+
+		if _, err = f.Write(append(js, '\n')); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	rw.Header().Set("Content-Type", "application/zip")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	rw.WriteHeader(status)
+	rw.Write(buf.Bytes()) //nolint
+
+	return nil
+}
+
 func (app *application) readJson(rw http.ResponseWriter, r *http.Request, dst interface{}) error {
 
 	maxBytes := 1_048_576
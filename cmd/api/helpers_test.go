@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestCSVText(t *testing.T) {
+
+	testCases := map[string]struct {
+		text string
+		want string
+	}{
+		"Plain":   {text: "Lemonade", want: "Lemonade"},
+		"Empty":   {text: "", want: ""},
+		"Formula": {text: "=HYPERLINK(\"http://evil\")", want: "'=HYPERLINK(\"http://evil\")"},
+		"Plus":    {text: "+1 Cola", want: "'+1 Cola"},
+		"Minus":   {text: "-2 Chips", want: "'-2 Chips"},
+		"At":      {text: "@SUM(A1)", want: "'@SUM(A1)"},
+		"Inside":  {text: "Cola=Good", want: "Cola=Good"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := csvText(tc.text); got != tc.want {
+				t.Errorf("want %q; got %q", tc.want, got)
+			}
+		})
+	}
+}
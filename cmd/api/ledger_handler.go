@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+func (app *application) listLedgerHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	filters := data.Filters{
+		Page:         app.readInt(qs, "page", 1, v),
+		PageSize:     app.readInt(qs, "page_size", 20, v),
+		Sort:         app.readString(qs, "sort", "-id"),
+		SortSafeList: []string{"id", "-id"},
+	}
+
+	filters.ValidateFilters(v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	entries, metadata, err := app.ledgerService.ListForUser(app.contextGetUser(r).ID, filters)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listLedgerResponse := dto.ListLedgerResponse{
+		Entries: []dto.APILedgerEntry{},
+	}
+
+	for _, entry := range entries {
+		listLedgerResponse.Entries = append(listLedgerResponse.Entries, getAPILedgerEntry(entry))
+	}
+
+	if len(entries) > 0 {
+		listLedgerResponse.Metadata = &metadata
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listLedgerResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func getAPILedgerEntry(entry *data.LedgerEntry) dto.APILedgerEntry {
+	return dto.APILedgerEntry{
+		ID:           entry.ID,
+		MachineID:    entry.MachineID,
+		Kind:         entry.Kind,
+		Amount:       entry.Amount,
+		BalanceAfter: entry.BalanceAfter,
+		CreatedAt:    entry.CreatedAt,
+	}
+}
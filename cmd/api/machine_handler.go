@@ -0,0 +1,234 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+)
+
+func (app *application) listMachineHandler(rw http.ResponseWriter, r *http.Request) {
+
+	machines, err := app.machineService.List()
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listMachineResponse := dto.ListMachineResponse{
+		Machines: []dto.APIMachine{},
+	}
+
+	for _, machine := range machines {
+		listMachineResponse.Machines = append(listMachineResponse.Machines, getAPIMachine(machine))
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listMachineResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) showMachineHandler(rw http.ResponseWriter, r *http.Request) {
+
+	machine, err := app.machineService.Get(app.machineID(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.MachineResponse{Machine: getAPIMachine(machine)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) createMachineHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.MachineRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	machine, validationErrors, err := app.machineService.Create(input)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/machines/%d", machine.ID))
+
+	if err = app.writeJson(rw, http.StatusCreated, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.MachineResponse{Machine: getAPIMachine(machine)},
+	}, headers); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) updateMachineHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.MachineRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	machine, validationErrors, err := app.machineService.Update(app.machineID(r), input)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.MachineResponse{Machine: getAPIMachine(machine)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) listMachineStockHandler(rw http.ResponseWriter, r *http.Request) {
+
+	stock, err := app.machineService.Stock(app.machineID(r))
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	app.writeMachineStock(rw, r, stock)
+}
+
+// listProductStockHandler shows sellers how one of their products is spread
+// across the machines.
+func (app *application) listProductStockHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	stock, err := app.machineService.StockForProduct(app.contextGetUser(r), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	app.writeMachineStock(rw, r, stock)
+}
+
+func (app *application) restockMachineHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	var input dto.RestockRequest
+	if err = app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	stock, validationErrors, err := app.machineService.Restock(app.contextGetUser(r), app.machineID(r), id, input.Quantity)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		case errors.Is(err, data.ErrNoPermission):
+			app.notPermittedRResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.MachineStockResponse{Stock: getAPIMachineStock(stock)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) writeMachineStock(rw http.ResponseWriter, r *http.Request, stock []*data.MachineStock) {
+
+	listMachineStockResponse := dto.ListMachineStockResponse{
+		Stock: []dto.APIMachineStock{},
+	}
+
+	for _, s := range stock {
+		listMachineStockResponse.Stock = append(listMachineStockResponse.Stock, getAPIMachineStock(s))
+	}
+
+	if err := app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listMachineStockResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func getAPIMachine(machine *data.Machine) dto.APIMachine {
+	return dto.APIMachine{
+		ID:        machine.ID,
+		Name:      machine.Name,
+		Location:  machine.Location,
+		CreatedAt: machine.CreatedAt,
+	}
+}
+
+func getAPIMachineStock(stock *data.MachineStock) dto.APIMachineStock {
+	return dto.APIMachineStock{
+		MachineID:   stock.MachineID,
+		MachineName: stock.MachineName,
+		ProductID:   stock.ProductID,
+		ProductName: stock.ProductName,
+		Cost:        stock.ProductCost,
+		Quantity:    stock.Quantity,
+	}
+}
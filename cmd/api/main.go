@@ -1,18 +1,44 @@
 package main
 
 import (
+	"crypto/rand"
 	"os"
 	"sync"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/rs/zerolog"
 
+	"github.com/terdia/mvp/internal/repository/repositoryapikey"
+	"github.com/terdia/mvp/internal/repository/repositoryaudit"
+	"github.com/terdia/mvp/internal/repository/repositorycategory"
+	"github.com/terdia/mvp/internal/repository/repositorycoin"
+	"github.com/terdia/mvp/internal/repository/repositoryidempotency"
+	"github.com/terdia/mvp/internal/repository/repositoryledger"
+	"github.com/terdia/mvp/internal/repository/repositorymachine"
 	"github.com/terdia/mvp/internal/repository/repositorypermission"
 	"github.com/terdia/mvp/internal/repository/repositoryproduct"
+	"github.com/terdia/mvp/internal/repository/repositorypurchase"
+	"github.com/terdia/mvp/internal/repository/repositoryrefund"
+	"github.com/terdia/mvp/internal/repository/repositoryreport"
+	"github.com/terdia/mvp/internal/repository/repositoryrole"
+	"github.com/terdia/mvp/internal/repository/repositoryslot"
 	"github.com/terdia/mvp/internal/repository/repositorytoken"
+	"github.com/terdia/mvp/internal/repository/repositorytx"
 	"github.com/terdia/mvp/internal/repository/repositoryuser"
+	"github.com/terdia/mvp/internal/service/accountservice"
+	"github.com/terdia/mvp/internal/service/adminservice"
 	"github.com/terdia/mvp/internal/service/auth"
+	"github.com/terdia/mvp/internal/service/categoryservice"
+	"github.com/terdia/mvp/internal/service/coinservice"
+	"github.com/terdia/mvp/internal/service/idempotency"
+	"github.com/terdia/mvp/internal/service/ledger"
+	"github.com/terdia/mvp/internal/service/machineservice"
+	"github.com/terdia/mvp/internal/service/permissionservice"
 	"github.com/terdia/mvp/internal/service/productservice"
+	"github.com/terdia/mvp/internal/service/purchaseservice"
+	"github.com/terdia/mvp/internal/service/refundservice"
+	"github.com/terdia/mvp/internal/service/reportservice"
+	"github.com/terdia/mvp/internal/service/slotservice"
 	"github.com/terdia/mvp/internal/service/transaction"
 	"github.com/terdia/mvp/internal/service/userservice"
 )
@@ -33,16 +59,65 @@ func main() {
 	defer postgresDb.Close() //nolint
 	logger.Printf("database connection pool established")
 
-	tokenService := auth.NewTokenService(repositorytoken.NewTokenRepository(postgresDb))
+	authCache := auth.NewCache(cfg.AuthCache.Size, cfg.AuthCache.TTL)
+
+	tokenService := auth.NewTokenService(
+		repositorytoken.NewTokenRepository(postgresDb),
+		cfg.Tokens.AccessTTL,
+		cfg.Tokens.RefreshTTL,
+		authCache,
+	)
 
 	newUserService := userservice.NewUserService(
 		repositoryuser.NewUserRepository(postgresDb),
 		tokenService,
 		repositorypermission.NewPermissionRepository(postgresDb),
+		authCache,
 	)
 
+	cursorSecret := []byte(cfg.CursorSecret)
+	if len(cursorSecret) == 0 {
+		logger.Warn().Msg("CURSOR_SECRET is not set, using a random secret")
+
+		cursorSecret = make([]byte, 32)
+		if _, err = rand.Read(cursorSecret); err != nil {
+			logger.Fatal().Err(err).Msg("Failed to generate cursor secret")
+		}
+	}
+
 	newProductService := productservice.NewProductService(
 		repositoryproduct.NewProductRepository(postgresDb),
+		cursorSecret,
+	)
+
+	ledgerService := ledger.NewLedgerService(repositoryledger.NewLedgerRepository(postgresDb))
+	coinService := coinservice.NewCoinService(repositorycoin.NewCoinRepository(postgresDb))
+	transactor := repositorytx.NewTransactor(postgresDb)
+
+	adminService := adminservice.NewAdminService(
+		repositoryuser.NewUserRepository(postgresDb),
+		repositoryaudit.NewAuditRepository(postgresDb),
+		tokenService,
+		ledgerService,
+		transactor,
+		authCache,
+	)
+
+	permissionService := permissionservice.NewPermissionService(
+		repositorypermission.NewPermissionRepository(postgresDb),
+		repositoryrole.NewRoleRepository(postgresDb),
+		repositoryuser.NewUserRepository(postgresDb),
+		transactor,
+		authCache,
+	)
+
+	accountService := accountservice.NewAccountService(
+		repositoryuser.NewUserRepository(postgresDb),
+		repositorypurchase.NewPurchaseRepository(postgresDb),
+		repositoryledger.NewLedgerRepository(postgresDb),
+		tokenService,
+		transactor,
+		authCache,
 	)
 
 	app := &application{
@@ -50,8 +125,26 @@ func main() {
 		config:             &cfg,
 		logger:             &logger,
 		userService:        newUserService,
+		tokenService:       tokenService,
 		productService:     newProductService,
-		transactionService: transaction.NewTransactionService(newUserService, newProductService),
+		categoryService:    categoryservice.NewCategoryService(repositorycategory.NewCategoryRepository(postgresDb)),
+		ledgerService:      ledgerService,
+		coinService:        coinService,
+		purchaseService:    purchaseservice.NewPurchaseService(repositorypurchase.NewPurchaseRepository(postgresDb)),
+		refundService:      refundservice.NewRefundService(repositoryrefund.NewRefundRepository(postgresDb)),
+		reportService:      reportservice.NewReportService(repositoryreport.NewReportRepository(postgresDb)),
+		slotService:        slotservice.NewSlotService(repositoryslot.NewSlotRepository(postgresDb), transactor),
+		machineService:     machineservice.NewMachineService(repositorymachine.NewMachineRepository(postgresDb), transactor),
+		apiKeyService:      auth.NewAPIKeyService(repositoryapikey.NewAPIKeyRepository(postgresDb)),
+		adminService:       adminService,
+		permissionService:  permissionService,
+		accountService:     accountService,
+		idempotencyService: idempotency.NewIdempotencyService(repositoryidempotency.NewIdempotencyRepository(postgresDb)),
+		transactionService: transaction.NewTransactionService(
+			transactor,
+			ledgerService,
+			coinService,
+		),
 	}
 
 	err = app.serve()
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/tomasen/realip"
 
 	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
 	"github.com/terdia/mvp/pkg/validator"
 )
 
@@ -62,15 +67,28 @@ func (app *application) authenticate(next http.HandlerFunc) http.HandlerFunc {
 
 		token := parts[1]
 
-		v := validator.New()
-		v.Check(token != "", "token", "must be provided")
-		v.Check(len(token) == 26, "token", "must be 26 bytes long")
-		if !v.Valid() {
-			app.invalidAuthenticationTokenResponse(rw, r)
-			return
+		var (
+			user *data.User
+			err  error
+		)
+
+		if strings.HasPrefix(token, data.APIKeyPrefix) {
+			user, err = app.apiKeyService.Authenticate(token)
+		} else {
+			v := validator.New()
+			v.Check(token != "", "token", "must be provided")
+			v.Check(len(token) == 26, "token", "must be 26 bytes long")
+			if !v.Valid() {
+				app.invalidAuthenticationTokenResponse(rw, r)
+				return
+			}
+
+			user, err = app.userService.GetUserByToken(token, data.TokenScopeAuthentication, realip.FromRequest(r))
+			if err == nil {
+				r = app.contextSetToken(r, token)
+			}
 		}
 
-		user, err := app.userService.GetUserByToken(token, data.TokenScopeAuthentication)
 		if err != nil {
 			switch {
 			case errors.Is(err, data.ErrRecordNotFound):
@@ -88,7 +106,9 @@ func (app *application) authenticate(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func (app *application) requireAuthenticatedUser(next http.HandlerFunc) http.HandlerFunc {
+// requireAuthenticated lets any authenticated principal through, a user
+// account or a machine holding an API key.
+func (app *application) requireAuthenticated(next http.HandlerFunc) http.HandlerFunc {
 
 	fn := func(rw http.ResponseWriter, r *http.Request) {
 		user := app.contextGetUser(r)
@@ -104,12 +124,29 @@ func (app *application) requireAuthenticatedUser(next http.HandlerFunc) http.Han
 	return app.authenticate(fn)
 }
 
-func (app *application) requirePermission(code string, next http.HandlerFunc) http.HandlerFunc {
+// requireAuthenticatedUser only lets user accounts through, machines are
+// refused.
+func (app *application) requireAuthenticatedUser(next http.HandlerFunc) http.HandlerFunc {
 
 	fn := func(rw http.ResponseWriter, r *http.Request) {
-		user := app.contextGetUser(r)
+		if app.contextGetUser(r).IsMachine() {
+			app.notPermittedRResponse(rw, r)
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	}
+
+	return app.requireAuthenticated(fn)
+}
+
+// requirePermission lets the request through when the caller holds code.
+// Grants, revocations and role changes clear the cached permissions, so they
+// apply from the next request on.
+func (app *application) requirePermission(code string, next http.HandlerFunc) http.HandlerFunc {
 
-		permissions, err := app.userService.GetPermissions(user.ID)
+	fn := func(rw http.ResponseWriter, r *http.Request) {
+		permissions, err := app.permissions(r)
 		if err != nil {
 			app.serverErrorResponse(rw, r, err)
 			return
@@ -123,7 +160,174 @@ func (app *application) requirePermission(code string, next http.HandlerFunc) ht
 		next.ServeHTTP(rw, r)
 	}
 
-	return app.requireAuthenticatedUser(fn)
+	return app.requireAuthenticated(fn)
+}
+
+// requireReadPermission gates the catalogue, the products, categories, machines
+// and slots, on products:read when REQUIRE_READ_PERMISSION is set; otherwise
+// the catalogue stays public, though callers are still authenticated so
+// handlers can tell sellers and admins apart.
+func (app *application) requireReadPermission(next http.HandlerFunc) http.HandlerFunc {
+	if !app.config.RequireReadPermission {
+		return app.authenticate(next)
+	}
+
+	return app.requirePermission(data.PermissionProductsRead, next)
+}
+
+// idempotent honours an Idempotency-Key header on money changing routes. The
+// first response for a key is stored and replayed for retries of the same
+// request; reusing the key for a different request is rejected.
+// It must run after authentication since keys are scoped to the user.
+func (app *application) idempotent(next http.HandlerFunc) http.HandlerFunc {
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		v := validator.New()
+		v.Check(len(key) <= 255, "idempotency_key", "must not be more than 255 bytes long")
+		if !v.Valid() {
+			app.failedValidationResponse(rw, r, v.Errors)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, 1_048_576))
+		if err != nil {
+			app.badRequestResponse(rw, r, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		fingerprint := sha256.New()
+		fingerprint.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n")) //nolint
+		fingerprint.Write(body)                                               //nolint
+
+		record, replay, err := app.idempotencyService.Begin(app.contextGetUser(r).ID, key, fingerprint.Sum(nil))
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrIdempotencyKeyMismatch):
+				app.idempotencyKeyMismatchResponse(rw, r)
+			case errors.Is(err, data.ErrIdempotencyKeyInProgress):
+				app.idempotencyKeyInProgressResponse(rw, r)
+			default:
+				app.serverErrorResponse(rw, r, err)
+			}
+			return
+		}
+
+		if replay {
+			var envelope dto.ResponseObject
+			if err = json.Unmarshal(record.Response, &envelope); err != nil {
+				app.serverErrorResponse(rw, r, err)
+				return
+			}
+
+			headers := make(http.Header)
+			for name, values := range record.Headers {
+				for _, value := range values {
+					headers.Add(name, value)
+				}
+			}
+			headers.Set("Idempotent-Replayed", "true")
+
+			if err = app.writeJson(rw, record.StatusCode, envelope, headers); err != nil {
+				app.serverErrorResponse(rw, r, err)
+			}
+			return
+		}
+
+		// the key is given up unless the response was stored: after server
+		// errors, after a panic unwinding to recoverPanic and when storing
+		// failed, so the client can retry with the same key.
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+
+			if err := app.idempotencyService.Release(record); err != nil {
+				app.logErrorWithHttpRequestContext(r, err)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: rw, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if recorder.statusCode >= http.StatusInternalServerError {
+			return
+		}
+
+		record.StatusCode = recorder.statusCode
+		record.Response = recorder.body.Bytes()
+		record.Headers = make(map[string][]string)
+		for _, name := range data.IdempotentHeaders {
+			if values := rw.Header().Values(name); len(values) > 0 {
+				record.Headers[name] = values
+			}
+		}
+		if err = app.idempotencyService.Complete(record); err != nil {
+			app.logErrorWithHttpRequestContext(r, err)
+			return
+		}
+
+		completed = true
+	}
+}
+
+// responseRecorder passes the response through while keeping a copy of the
+// status code and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(statusCode int) {
+	rec.statusCode = statusCode
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b) //nolint
+	return rec.ResponseWriter.Write(b)
+}
+
+// deprecated flags a legacy route and points clients at its replacement.
+func (app *application) deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Deprecation", "true")
+		rw.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+
+		next.ServeHTTP(rw, r)
+	}
+}
+
+// requireMachine answers 404 on routes scoped to a machine that does not exist.
+func (app *application) requireMachine(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		id, err := app.extractIntParamFromContext(r, "machine")
+		if err != nil || id < 1 {
+			app.notFoundResponse(rw, r)
+			return
+		}
+
+		if _, err = app.machineService.Get(id); err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(rw, r)
+			default:
+				app.serverErrorResponse(rw, r, err)
+			}
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
 }
 
 func (app *application) enableCors(next http.Handler) http.Handler {
@@ -142,7 +346,7 @@ func (app *application) enableCors(next http.Handler) http.Handler {
 					// handle prefight
 					if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
 						rw.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
-						rw.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+						rw.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Idempotency-Key, If-Match")
 
 						rw.WriteHeader(http.StatusOK)
 						return
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/service/idempotency"
+	repo "github.com/terdia/mvp/mocks/repository"
+)
+
+func TestIdempotentReleasesKey(t *testing.T) {
+
+	testCases := map[string]struct {
+		handler  http.HandlerFunc
+		complete error
+	}{
+		"Panic": {
+			handler: func(rw http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			},
+		},
+		"CompleteFails": {
+			handler: func(rw http.ResponseWriter, r *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			},
+			complete: errors.New("connection reset"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			ctrl := gomock.NewController(t)
+			keys := repo.NewMockIdempotencyRepository(ctrl)
+			keys.EXPECT().Insert(gomock.Any()).Return(nil)
+			if tc.complete != nil {
+				keys.EXPECT().Update(gomock.Any()).Return(tc.complete)
+			}
+			keys.EXPECT().Delete(int64(2), "retry-me").Return(nil)
+
+			app := createTestApplication(t, false)
+			app.idempotencyService = idempotency.NewIdempotencyService(keys)
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/deposits", nil)
+			r.Header.Set("Idempotency-Key", "retry-me")
+			r = app.contextSetUser(r, &data.User{ID: 2})
+
+			// act
+			app.recoverPanic(app.idempotent(tc.handler)).ServeHTTP(httptest.NewRecorder(), r)
+		})
+	}
+}
+
+func TestIdempotentReplaysHeaders(t *testing.T) {
+
+	// arrange
+	ctrl := gomock.NewController(t)
+	keys := repo.NewMockIdempotencyRepository(ctrl)
+
+	fingerprint := sha256.New()
+	fingerprint.Write([]byte(http.MethodPost + " /v1/deposits\n")) //nolint
+
+	keys.EXPECT().Insert(gomock.Any()).Return(data.ErrDuplicateIdempotencyKey)
+	keys.EXPECT().Get(int64(2), "retry-me").Return(&data.IdempotencyKey{
+		UserID:      2,
+		Key:         "retry-me",
+		Fingerprint: fingerprint.Sum(nil),
+		StatusCode:  http.StatusCreated,
+		Response:    []byte(`{"status":"success"}`),
+		Headers:     map[string][]string{"Location": {"/v1/purchases/7"}},
+		CreatedAt:   time.Now(),
+	}, nil)
+
+	app := createTestApplication(t, false)
+	app.idempotencyService = idempotency.NewIdempotencyService(keys)
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/deposits", nil)
+	r.Header.Set("Idempotency-Key", "retry-me")
+	r = app.contextSetUser(r, &data.User{ID: 2})
+
+	rec := httptest.NewRecorder()
+
+	// act
+	app.idempotent(func(rw http.ResponseWriter, r *http.Request) {
+		t.Error("the handler must not run for a replay")
+	}).ServeHTTP(rec, r)
+
+	// assert
+	if rec.Code != http.StatusCreated {
+		t.Errorf("want %d; got %d", http.StatusCreated, rec.Code)
+	}
+
+	if location := rec.Header().Get("Location"); location != "/v1/purchases/7" {
+		t.Errorf("want %q; got %q", "/v1/purchases/7", location)
+	}
+}
+
+func TestReadPermissionCoversCatalogue(t *testing.T) {
+
+	app := createTestApplication(t, false)
+	app.config.RequireReadPermission = true
+	ts := newTestServer(t, app.routes())
+
+	for _, path := range []string{"/v1/products", "/v1/categories", "/v1/machines", "/v1/slots"} {
+		t.Run(path, func(t *testing.T) {
+			rs := ts.get(t, path)
+
+			if rs.StatusCode != http.StatusUnauthorized {
+				t.Errorf("want %d; got %d", http.StatusUnauthorized, rs.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+)
+
+func (app *application) listPermissionHandler(rw http.ResponseWriter, r *http.Request) {
+
+	permissions, err := app.permissionService.List()
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listPermissionResponse := dto.ListPermissionResponse{
+		Permissions: []dto.APIPermission{},
+	}
+
+	for _, permission := range permissions {
+		listPermissionResponse.Permissions = append(listPermissionResponse.Permissions, getAPIPermission(permission))
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listPermissionResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) createPermissionHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.PermissionRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	permission, validationErrors, err := app.permissionService.Create(app.contextGetUser(r), input)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusCreated, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.PermissionResponse{Permission: getAPIPermission(permission)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) deletePermissionHandler(rw http.ResponseWriter, r *http.Request) {
+
+	validationErrors, err := app.permissionService.Remove(app.contextGetUser(r), chi.URLParam(r, "code"))
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Message:   "permission successfully deleted",
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) listRoleHandler(rw http.ResponseWriter, r *http.Request) {
+
+	roles, err := app.permissionService.ListRoles()
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listRoleResponse := dto.ListRoleResponse{
+		Roles: []dto.APIRole{},
+	}
+
+	for _, role := range roles {
+		listRoleResponse.Roles = append(listRoleResponse.Roles, getAPIRole(role))
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listRoleResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) showRoleHandler(rw http.ResponseWriter, r *http.Request) {
+
+	role, err := app.permissionService.GetRole(chi.URLParam(r, "name"))
+	app.writeRole(rw, r, http.StatusOK, role, nil, err)
+}
+
+func (app *application) createRoleHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.CreateRoleRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	role, validationErrors, err := app.permissionService.CreateRole(app.contextGetUser(r), input)
+	app.writeRole(rw, r, http.StatusCreated, role, validationErrors, err)
+}
+
+func (app *application) setRolePermissionsHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.RolePermissionsRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	role, validationErrors, err := app.permissionService.SetRolePermissions(app.contextGetUser(r), chi.URLParam(r, "name"), input)
+	app.writeRole(rw, r, http.StatusOK, role, validationErrors, err)
+}
+
+func (app *application) deleteRoleHandler(rw http.ResponseWriter, r *http.Request) {
+
+	validationErrors, err := app.permissionService.RemoveRole(app.contextGetUser(r), chi.URLParam(r, "name"))
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Message:   "role successfully deleted",
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) showUserPermissionsHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	permissions, err := app.permissionService.GetForUser(id)
+	app.writeUserPermissions(rw, r, permissions, nil, err)
+}
+
+func (app *application) grantPermissionHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	permissions, validationErrors, err := app.permissionService.Grant(app.contextGetUser(r), id, chi.URLParam(r, "code"))
+	app.writeUserPermissions(rw, r, permissions, validationErrors, err)
+}
+
+func (app *application) revokePermissionHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	permissions, validationErrors, err := app.permissionService.Revoke(app.contextGetUser(r), id, chi.URLParam(r, "code"))
+	app.writeUserPermissions(rw, r, permissions, validationErrors, err)
+}
+
+func (app *application) writeRole(
+	rw http.ResponseWriter,
+	r *http.Request,
+	status int,
+	role *data.Role,
+	validationErrors map[string]string,
+	err error,
+) {
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	var headers http.Header
+	if status == http.StatusCreated {
+		headers = make(http.Header)
+		headers.Set("Location", fmt.Sprintf("/v1/admin/roles/%s", role.Name))
+	}
+
+	if err = app.writeJson(rw, status, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.RoleResponse{Role: getAPIRole(role)},
+	}, headers); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) writeUserPermissions(
+	rw http.ResponseWriter,
+	r *http.Request,
+	permissions *data.UserPermissions,
+	validationErrors map[string]string,
+	err error,
+) {
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data: dto.UserPermissionsResponse{Permissions: dto.APIUserPermissions{
+			UserID:          permissions.UserID,
+			Role:            permissions.Role.Name,
+			RolePermissions: permissions.Role.Permissions,
+			Granted:         permissions.Granted,
+			Permissions:     permissions.All(),
+		}},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func getAPIPermission(permission *data.Permission) dto.APIPermission {
+	return dto.APIPermission{
+		ID:          permission.ID,
+		Code:        permission.Code,
+		Description: permission.Description,
+		Builtin:     data.BuiltinPermissions.Includes(permission.Code),
+		CreatedAt:   permission.CreatedAt,
+	}
+}
+
+func getAPIRole(role *data.Role) dto.APIRole {
+	return dto.APIRole{
+		ID:          role.ID,
+		Name:        role.Name,
+		Permissions: role.Permissions,
+		Builtin:     data.IsBuiltinRole(role.Name),
+		CreatedAt:   role.CreatedAt,
+	}
+}
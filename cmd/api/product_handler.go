@@ -47,6 +47,7 @@ func (app *application) createProductHandler(w http.ResponseWriter, r *http.Requ
 
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/products/%d", product.ID))
+	headers.Set("ETag", productETag(product))
 
 	if err = app.writeJson(w, http.StatusCreated, result, headers); err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -61,7 +62,28 @@ func (app *application) showProductHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	product, err := app.productService.GetOne(id)
+	v := validator.New()
+	includeArchived := app.readBool(r.URL.Query(), "include_archived", false, v)
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	var product *data.Product
+	if includeArchived {
+		product, err = app.productService.GetOneIncludingArchived(id)
+	} else {
+		product, err = app.productService.GetOne(id)
+	}
+
+	// archived products of other sellers are hidden as if they did not exist.
+	if err == nil && product.IsArchived() {
+		var allowed bool
+		if allowed, err = app.canSeeArchived(r, product.Seller.ID); err == nil && !allowed {
+			err = data.ErrRecordNotFound
+		}
+	}
+
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -79,7 +101,10 @@ func (app *application) showProductHandler(w http.ResponseWriter, r *http.Reques
 		},
 	}
 
-	if err = app.writeJson(w, http.StatusOK, result, nil); err != nil {
+	headers := make(http.Header)
+	headers.Set("ETag", productETag(product))
+
+	if err = app.writeJson(w, http.StatusOK, result, headers); err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
@@ -91,39 +116,86 @@ func (app *application) listProductHandler(rw http.ResponseWriter, r *http.Reque
 	qs := r.URL.Query()
 
 	filters := data.Filters{
-		Page:         app.readInt(qs, "page", 1, v),
-		PageSize:     app.readInt(qs, "page_size", 10, v),
-		Sort:         app.readString(qs, "sort", "id"),
-		SortSafeList: []string{"id", "name", "-id", "-name"},
+		Page:     app.readInt(qs, "page", 1, v),
+		PageSize: app.readInt(qs, "page_size", 10, v),
+		Sort:     app.readString(qs, "sort", "id"),
+		SortSafeList: []string{
+			"id", "name", "cost", "amount_available", "created_at",
+			"-id", "-name", "-cost", "-amount_available", "-created_at",
+		},
+		SortColumns: map[string]string{"amount_available": "quantity"},
 	}
 
-	filters.ValidateFilters(v)
-	if !v.Valid() {
+	listRequest := dto.ListProductRequest{
+		Name:            app.readString(qs, "name", ""),
+		IncludeArchived: app.readBool(qs, "include_archived", false, v),
+		MinCost:         app.readInt(qs, "min_cost", 0, v),
+		MaxCost:         app.readInt(qs, "max_cost", 0, v),
+		SellerID:        int64(app.readInt(qs, "seller_id", 0, v)),
+		InStock:         app.readBool(qs, "in_stock", false, v),
+		CreatedAfter:    app.readDate(qs, "created_after", v),
+		CreatedBefore:   app.readDate(qs, "created_before", v),
+		Category:        app.readString(qs, "category", ""),
+		Tag:             app.readString(qs, "tag", ""),
+		Filters:         filters,
+	}
+
+	// a cursor parameter, even an empty one, switches to keyset pagination.
+	_, cursorMode := qs["cursor"]
+	if cursorMode {
+		v.Check(qs.Get("page") == "", "page", "can not be combined with cursor")
+	}
+
+	if listRequest.Validate(v); !v.Valid() {
 		app.failedValidationResponse(rw, r, v.Errors)
 		return
 	}
 
-	listRequest := dto.ListProductRequest{
-		Name:    app.readString(qs, "name", ""),
-		Filters: filters,
-	}
+	// archived products stay hidden from callers who may not see them.
+	if listRequest.IncludeArchived {
+		allowed, err := app.canSeeArchived(r, listRequest.SellerID)
+		if err != nil {
+			app.serverErrorResponse(rw, r, err)
+			return
+		}
 
-	products, metadata, err := app.productService.List(listRequest)
-	if err != nil {
-		app.serverErrorResponse(rw, r, err)
-		return
+		listRequest.IncludeArchived = allowed
 	}
 
 	listProductResponse := dto.ListProductResponse{
 		Products: []dto.APIProduct{},
 	}
 
-	for _, product := range products {
-		listProductResponse.Products = append(listProductResponse.Products, getProductResponse(product))
+	var products []*data.Product
+	var err error
+
+	if cursorMode {
+		var cursorMetadata data.CursorMetadata
+		var validationErrors map[string]string
+
+		products, cursorMetadata, validationErrors, err = app.productService.ListByCursor(listRequest, qs.Get("cursor"))
+		if validationErrors != nil {
+			app.failedValidationResponse(rw, r, validationErrors)
+			return
+		}
+
+		listProductResponse.CursorMetadata = &cursorMetadata
+	} else {
+		var metadata data.Metadata
+
+		products, metadata, err = app.productService.List(listRequest)
+		if len(products) > 0 {
+			listProductResponse.Metadata = &metadata
+		}
 	}
 
-	if len(products) > 0 {
-		listProductResponse.Metadata = &metadata
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	for _, product := range products {
+		listProductResponse.Products = append(listProductResponse.Products, getProductResponse(product))
 	}
 
 	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
@@ -143,6 +215,12 @@ func (app *application) updateProductHandler(rw http.ResponseWriter, r *http.Req
 		return
 	}
 
+	version, err := app.readIfMatch(r)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
 	var input struct {
 		Name     string `json:"name"`
 		Cost     int    `json:"cost"`
@@ -160,14 +238,54 @@ func (app *application) updateProductHandler(rw http.ResponseWriter, r *http.Req
 		Seller:          *app.contextGetUser(r),
 		CreatedAt:       time.Time{},
 		AmountAvailable: input.Quantity,
+		Version:         version,
 	})
 
+	app.writeUpdatedProduct(rw, r, product, validationErrors, err)
+}
+
+// patchProductHandler applies only the fields present in the request body.
+func (app *application) patchProductHandler(rw http.ResponseWriter, r *http.Request) {
+
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	version, err := app.readIfMatch(r)
+	if err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	var input dto.UpdateProductRequest
+	if err = app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	product, validationErrors, err := app.productService.Patch(id, *app.contextGetUser(r), version, input)
+
+	app.writeUpdatedProduct(rw, r, product, validationErrors, err)
+}
+
+func (app *application) writeUpdatedProduct(
+	rw http.ResponseWriter,
+	r *http.Request,
+	product *data.Product,
+	validationErrors map[string]string,
+	err error,
+) {
+
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(rw, r)
 		case errors.Is(err, data.ErrNoPermission):
 			app.notPermittedRResponse(rw, r)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(rw, r)
 		default:
 			app.serverErrorResponse(rw, r, err)
 		}
@@ -186,7 +304,10 @@ func (app *application) updateProductHandler(rw http.ResponseWriter, r *http.Req
 		},
 	}
 
-	if err = app.writeJson(rw, http.StatusOK, result, nil); err != nil {
+	headers := make(http.Header)
+	headers.Set("ETag", productETag(product))
+
+	if err = app.writeJson(rw, http.StatusOK, result, headers); err != nil {
 		app.serverErrorResponse(rw, r, err)
 		return
 	}
@@ -218,7 +339,7 @@ func (app *application) deleteProductHandler(rw http.ResponseWriter, r *http.Req
 
 	result := dto.ResponseObject{
 		StatusMsg: dto.Success,
-		Message:   "product successfully deleted",
+		Message:   "product successfully archived",
 	}
 
 	if err = app.writeJson(rw, http.StatusOK, result, nil); err != nil {
@@ -227,6 +348,90 @@ func (app *application) deleteProductHandler(rw http.ResponseWriter, r *http.Req
 	}
 }
 
+func (app *application) restoreProductHandler(rw http.ResponseWriter, r *http.Request) {
+
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	product, validationErrors, err := app.productService.Restore(data.Product{
+		ID:     id,
+		Seller: *app.contextGetUser(r),
+	})
+
+	app.writeUpdatedProduct(rw, r, product, validationErrors, err)
+}
+
+func (app *application) setProductCategoriesHandler(rw http.ResponseWriter, r *http.Request) {
+
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	var input dto.ProductCategoriesRequest
+	if err = app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	product, validationErrors, err := app.productService.SetCategories(data.Product{
+		ID:     id,
+		Seller: *app.contextGetUser(r),
+	}, input.CategoryIDs)
+
+	app.writeUpdatedProduct(rw, r, product, validationErrors, err)
+}
+
+func (app *application) setProductTagsHandler(rw http.ResponseWriter, r *http.Request) {
+
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	var input dto.ProductTagsRequest
+	if err = app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	product, validationErrors, err := app.productService.SetTags(data.Product{
+		ID:     id,
+		Seller: *app.contextGetUser(r),
+	}, input.Tags)
+
+	app.writeUpdatedProduct(rw, r, product, validationErrors, err)
+}
+
+func (app *application) createPurchaseHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.PurchaseRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.ProductID > 0, "product_id", "must be provided")
+	v.Check(input.Quantity > 0, "quantity", "must be greater than zero")
+	if input.Slot != "" {
+		data.ValidateSlotCode(v, "slot", input.Slot)
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	app.buyProduct(rw, r, input.ProductID, input.Quantity, input.Slot)
+}
+
+// buyProductHandler serves the deprecated GET /v1/products/{id}/buy/{amount} route.
 func (app *application) buyProductHandler(rw http.ResponseWriter, r *http.Request) {
 
 	id, err := app.extractIntParamFromContext(r, "id")
@@ -241,6 +446,11 @@ func (app *application) buyProductHandler(rw http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	app.buyProduct(rw, r, id, int(amount), "")
+}
+
+func (app *application) buyProduct(rw http.ResponseWriter, r *http.Request, id int64, quantity int, slotCode string) {
+
 	product, err := app.productService.GetOne(id)
 	if err != nil {
 		switch {
@@ -254,8 +464,10 @@ func (app *application) buyProductHandler(rw http.ResponseWriter, r *http.Reques
 
 	purchaseResponse, validationErrs, err := app.transactionService.BuyProduct(
 		app.contextGetUser(r),
+		app.machineID(r),
 		product,
-		int(amount),
+		quantity,
+		slotCode,
 	)
 
 	if validationErrs != nil {
@@ -264,7 +476,12 @@ func (app *application) buyProductHandler(rw http.ResponseWriter, r *http.Reques
 	}
 
 	if err != nil {
-		app.serverErrorResponse(rw, r, err)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
 		return
 	}
 
@@ -284,12 +501,84 @@ func (app *application) buyProductHandler(rw http.ResponseWriter, r *http.Reques
 	}
 }
 
+func (app *application) checkoutHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.CheckoutRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	receipt, validationErrs, err := app.transactionService.Checkout(app.contextGetUser(r), app.machineID(r), input.Items)
+	if validationErrs != nil {
+		app.failedValidationResponse(rw, r, validationErrs)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Message:   "Checkout successful",
+		Data: map[string]dto.CheckoutResponse{
+			"receipt": *receipt,
+		},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
 func getProductResponse(product *data.Product) dto.APIProduct {
+	categories := []dto.APICategory{}
+	for _, category := range product.Categories {
+		categories = append(categories, getAPICategory(category))
+	}
+
+	tags := product.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
 	return dto.APIProduct{
 		ID:              product.ID,
 		Cost:            product.Cost,
 		Name:            product.Name,
 		CreatedAt:       product.CreatedAt,
 		AmountAvailable: product.AmountAvailable,
+		Version:         product.Version,
+		ArchivedAt:      product.DeletedAt,
+		Categories:      categories,
+		Tags:            tags,
 	}
 }
+
+// productETag is the entity tag clients send back in If-Match.
+func productETag(product *data.Product) string {
+	return fmt.Sprintf(`"%d"`, product.Version)
+}
+
+// canSeeArchived reports whether the caller may see the archived products of
+// sellerID: the seller themselves, or admins, who may read any user's data.
+// A sellerID of 0 stands for every seller.
+func (app *application) canSeeArchived(r *http.Request, sellerID int64) (bool, error) {
+	user := app.contextGetUser(r)
+
+	if user == nil || user.IsAnonymous() {
+		return false, nil
+	}
+
+	if !user.IsMachine() && user.ID == sellerID {
+		return true, nil
+	}
+
+	return app.hasPermission(r, data.PermissionUsersRead)
+}
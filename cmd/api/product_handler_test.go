@@ -5,6 +5,9 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/golang/mock/gomock"
+
+	"github.com/terdia/mvp/internal/data"
 	"github.com/terdia/mvp/pkg/dto"
 )
 
@@ -41,4 +44,130 @@ func TestGetProduct(t *testing.T) {
 	if result.Data.Product.AmountAvailable != 20 {
 		t.Errorf("want %v; got %v", 20, result.Data.Product.AmountAvailable)
 	}
+
+	if etag := rs.Header.Get("ETag"); etag != `"3"` {
+		t.Errorf("want %v; got %v", `"3"`, etag)
+	}
+}
+
+func TestListProductsInvalidFilters(t *testing.T) {
+
+	app := createTestApplication(t, false)
+	ts := newTestServer(t, app.routes())
+
+	rs := ts.get(t, "/v1/products?min_cost=50&max_cost=10&in_stock=maybe&created_after=yesterday&sort=-quantity")
+
+	if rs.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("want %d; got %d", http.StatusUnprocessableEntity, rs.StatusCode)
+	}
+
+	result := struct {
+		Status string
+		Data   dto.ValidationError
+	}{}
+
+	_ = json.Unmarshal(rs.Body, &result)
+
+	for _, key := range []string{"min_cost", "in_stock", "created_after", "sort"} {
+		if _, ok := result.Data.Errors[key]; !ok {
+			t.Errorf("want a validation error for %s; got %v", key, result.Data.Errors)
+		}
+	}
+}
+
+func TestListProductsTamperedCursor(t *testing.T) {
+
+	app := createTestApplication(t, false)
+	ts := newTestServer(t, app.routes())
+
+	rs := ts.get(t, "/v1/products?cursor=eyJzIjoiaWQiLCJ2IjoiMSIsImlkIjoxfQ.c2lnbmF0dXJl")
+
+	if rs.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("want %d; got %d", http.StatusUnprocessableEntity, rs.StatusCode)
+	}
+
+	result := struct {
+		Status string
+		Data   dto.ValidationError
+	}{}
+
+	_ = json.Unmarshal(rs.Body, &result)
+
+	if _, ok := result.Data.Errors["cursor"]; !ok {
+		t.Errorf("want a validation error for cursor; got %v", result.Data.Errors)
+	}
+}
+
+func TestGetProductRequiresReadPermission(t *testing.T) {
+
+	app := createTestApplication(t, false)
+	app.config.RequireReadPermission = true
+	ts := newTestServer(t, app.routes())
+
+	rs := ts.get(t, "/v1/products/1")
+
+	if rs.StatusCode != http.StatusUnauthorized {
+		t.Errorf("want %d; got %d", http.StatusUnauthorized, rs.StatusCode)
+	}
+}
+
+func TestListArchivedProducts(t *testing.T) {
+
+	const token = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	testCases := map[string]struct {
+		user        *data.User
+		permissions data.Permissions
+		url         string
+		archived    bool
+	}{
+		"Anonymous": {
+			url:      "/v1/products?include_archived=true",
+			archived: false,
+		},
+		"OwningSeller": {
+			user:     &data.User{ID: 2, Role: "seller"},
+			url:      "/v1/products?seller_id=2&include_archived=true",
+			archived: true,
+		},
+		"UsersReader": {
+			user:        &data.User{ID: 5, Role: "admin"},
+			permissions: data.Permissions{data.PermissionUsersRead},
+			url:         "/v1/products?seller_id=2&include_archived=true",
+			archived:    true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// arrange
+			app, products, users := createMockedTestApplication(t)
+			ts := newTestServer(t, app.routes())
+
+			bearer := ""
+			if tc.user != nil {
+				bearer = token
+				users.EXPECT().GetUserByToken(token, data.TokenScopeAuthentication, gomock.Any()).Return(tc.user, nil)
+			}
+			if tc.permissions != nil {
+				users.EXPECT().GetPermissions(tc.user.ID).Return(tc.permissions, nil)
+			}
+
+			products.EXPECT().GetAll(gomock.Any()).DoAndReturn(func(request dto.ListProductRequest) ([]*data.Product, data.Metadata, error) {
+				if request.IncludeArchived != tc.archived {
+					t.Errorf("want include archived %v; got %v", tc.archived, request.IncludeArchived)
+				}
+				return []*data.Product{}, data.Metadata{}, nil
+			})
+			products.EXPECT().LoadLabels(gomock.Any()).Return(nil)
+
+			// act
+			rs := ts.getWithToken(t, tc.url, bearer)
+
+			// assert
+			if rs.StatusCode != http.StatusOK {
+				t.Errorf("want %d; got %d", http.StatusOK, rs.StatusCode)
+			}
+		})
+	}
 }
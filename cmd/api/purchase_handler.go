@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+func (app *application) listPurchaseHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	filters := data.Filters{
+		Page:         app.readInt(qs, "page", 1, v),
+		PageSize:     app.readInt(qs, "page_size", 10, v),
+		Sort:         app.readString(qs, "sort", "-created_at"),
+		SortSafeList: []string{"id", "created_at", "amount_spent", "-id", "-created_at", "-amount_spent"},
+	}
+
+	filters.ValidateFilters(v)
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	purchases, metadata, err := app.purchaseService.ListForBuyer(app.contextGetUser(r).ID, filters)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listPurchaseResponse := dto.ListPurchaseResponse{
+		Purchases: []dto.APIPurchase{},
+	}
+
+	for _, purchase := range purchases {
+		listPurchaseResponse.Purchases = append(listPurchaseResponse.Purchases, getAPIPurchase(purchase))
+	}
+
+	if len(purchases) > 0 {
+		listPurchaseResponse.Metadata = &metadata
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listPurchaseResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) showPurchaseHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	purchase, err := app.purchaseService.GetForBuyer(app.contextGetUser(r).ID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.PurchaseResponse{Purchase: getAPIPurchase(purchase)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func getAPIPurchase(purchase *data.Purchase) dto.APIPurchase {
+	change := purchase.Change
+	if change == nil {
+		change = []int{}
+	}
+
+	return dto.APIPurchase{
+		ID:          purchase.ID,
+		SellerID:    purchase.SellerID,
+		MachineID:   purchase.MachineID,
+		ProductID:   purchase.ProductID,
+		ProductName: purchase.ProductName,
+		ProductCost: purchase.ProductCost,
+		Quantity:    purchase.Quantity,
+		AmountSpent: purchase.AmountSpent,
+		Change:      change,
+		Slot:        purchase.SlotCode,
+		CreatedAt:   purchase.CreatedAt,
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// purgeArchivedProducts starts a background job that permanently removes
+// products archived for longer than the configured retention. It runs once per
+// purge interval until done is closed.
+func (app *application) purgeArchivedProducts(done <-chan struct{}) {
+
+	retention := app.config.Archive.Retention
+	if retention <= 0 {
+		return
+	}
+
+	app.every(done, app.config.Archive.PurgeInterval, "archived product purge", func() {
+		purged, err := app.productService.PurgeArchived(retention)
+		if err != nil {
+			app.logger.Err(err).Msg("failed to purge archived products")
+			return
+		}
+
+		if purged > 0 {
+			app.logger.Printf("purged %d archived products", purged)
+		}
+	})
+}
+
+// purgeIdempotencyKeys starts a background job that deletes idempotency keys
+// no longer replayed, which are otherwise only dropped when the same user
+// reuses the key.
+func (app *application) purgeIdempotencyKeys(done <-chan struct{}) {
+
+	app.every(done, app.config.IdempotencyPurgeInterval, "idempotency key purge", func() {
+		purged, err := app.idempotencyService.PurgeExpired()
+		if err != nil {
+			app.logger.Err(err).Msg("failed to purge idempotency keys")
+			return
+		}
+
+		if purged > 0 {
+			app.logger.Printf("purged %d idempotency keys", purged)
+		}
+	})
+}
+
+// every runs job once per interval in the background until done is closed. A
+// panic stops the job, not the server. A zero interval never runs it.
+func (app *application) every(done <-chan struct{}, interval time.Duration, name string, job func()) {
+
+	if interval <= 0 {
+		return
+	}
+
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Err(fmt.Errorf("%s", err)).Msg(name + " stopped")
+			}
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				job()
+			}
+		}
+	}()
+}
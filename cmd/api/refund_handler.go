@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+func (app *application) createRefundHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	var input dto.RefundRequest
+	if err = app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	refund, validationErrors, err := app.transactionService.RequestRefund(app.contextGetUser(r), id, input.Reason)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusCreated, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.RefundResponse{Refund: getAPIRefund(refund, nil)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) listRefundHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	filters := data.Filters{
+		Page:         app.readInt(qs, "page", 1, v),
+		PageSize:     app.readInt(qs, "page_size", 10, v),
+		Sort:         app.readString(qs, "sort", "-created_at"),
+		SortSafeList: []string{"id", "created_at", "amount", "-id", "-created_at", "-amount"},
+	}
+
+	filter := data.RefundFilter{
+		UserID: app.contextGetUser(r).ID,
+		Status: app.readString(qs, "status", ""),
+	}
+
+	filters.ValidateFilters(v)
+	if filter.Status != "" {
+		v.Check(validator.In(filter.Status, data.RefundStatuses), "status", "invalid status value")
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	manager, err := app.hasPermission(r, data.PermissionRefundsManage)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	// managers review refunds across every seller.
+	if manager {
+		filter.UserID = 0
+	}
+
+	refunds, metadata, err := app.refundService.List(filter, filters)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listRefundResponse := dto.ListRefundResponse{
+		Refunds: []dto.APIRefund{},
+	}
+
+	for _, refund := range refunds {
+		listRefundResponse.Refunds = append(listRefundResponse.Refunds, getAPIRefund(refund, nil))
+	}
+
+	if len(refunds) > 0 {
+		listRefundResponse.Metadata = &metadata
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listRefundResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) showRefundHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	manager, err := app.hasPermission(r, data.PermissionRefundsManage)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	refund, events, err := app.refundService.GetForUser(app.contextGetUser(r).ID, manager, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.RefundResponse{Refund: getAPIRefund(refund, events)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) approveRefundHandler(rw http.ResponseWriter, r *http.Request) {
+	app.resolveRefund(rw, r, app.transactionService.ApproveRefund)
+}
+
+func (app *application) rejectRefundHandler(rw http.ResponseWriter, r *http.Request) {
+	app.resolveRefund(rw, r, app.transactionService.RejectRefund)
+}
+
+type refundResolver func(actor *data.User, manager bool, refundID int64, note string) (*data.Refund, data.ValidationErrors, error)
+
+func (app *application) resolveRefund(rw http.ResponseWriter, r *http.Request, resolve refundResolver) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	var input dto.ResolveRefundRequest
+	if err = app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	manager, err := app.hasPermission(r, data.PermissionRefundsManage)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	refund, validationErrors, err := resolve(app.contextGetUser(r), manager, id, input.Note)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		case errors.Is(err, data.ErrNoPermission):
+			app.notPermittedRResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.RefundResponse{Refund: getAPIRefund(refund, nil)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func getAPIRefund(refund *data.Refund, events []*data.RefundEvent) dto.APIRefund {
+	apiRefund := dto.APIRefund{
+		ID:         refund.ID,
+		PurchaseID: refund.PurchaseID,
+		BuyerID:    refund.BuyerID,
+		SellerID:   refund.SellerID,
+		ProductID:  refund.ProductID,
+		Quantity:   refund.Quantity,
+		Amount:     refund.Amount,
+		Reason:     refund.Reason,
+		Status:     refund.Status,
+		CreatedAt:  refund.CreatedAt,
+		UpdatedAt:  refund.UpdatedAt,
+	}
+
+	for _, event := range events {
+		apiRefund.Events = append(apiRefund.Events, dto.APIRefundEvent{
+			FromStatus: event.FromStatus,
+			ToStatus:   event.ToStatus,
+			ActorID:    event.ActorID,
+			Note:       event.Note,
+			CreatedAt:  event.CreatedAt,
+		})
+	}
+
+	return apiRefund
+}
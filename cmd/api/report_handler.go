@@ -0,0 +1,125 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+func (app *application) productSalesReportHandler(rw http.ResponseWriter, r *http.Request) {
+
+	sales, err := app.reportService.ProductSales(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	if app.wantsCSV(r) {
+		records := [][]string{{"product_id", "name", "units_sold", "revenue", "amount_available"}}
+		for _, s := range sales {
+			records = append(records, []string{
+				strconv.FormatInt(s.ProductID, 10),
+				csvText(s.Name),
+				strconv.Itoa(s.UnitsSold),
+				strconv.Itoa(s.Revenue),
+				strconv.Itoa(s.AmountAvailable),
+			})
+		}
+
+		if err = app.writeCSV(rw, http.StatusOK, "product-sales.csv", records); err != nil {
+			app.logErrorWithHttpRequestContext(r, err)
+		}
+		return
+	}
+
+	report := dto.ProductSalesReport{
+		Products: []dto.APIProductSales{},
+	}
+
+	for _, s := range sales {
+		report.Products = append(report.Products, dto.APIProductSales{
+			ProductID:       s.ProductID,
+			Name:            s.Name,
+			UnitsSold:       s.UnitsSold,
+			Revenue:         s.Revenue,
+			AmountAvailable: s.AmountAvailable,
+		})
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      report,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) revenueReportHandler(rw http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+
+	qs := r.URL.Query()
+
+	period := app.readString(qs, "period", data.ReportPeriodDay)
+	dateRange := data.ReportRange{
+		From: app.readDate(qs, "from", v),
+		To:   app.readDate(qs, "to", v),
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	revenue, validationErrors, err := app.reportService.Revenue(app.contextGetUser(r).ID, period, dateRange)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	if app.wantsCSV(r) {
+		records := [][]string{{"period", "units_sold", "revenue"}}
+		for _, p := range revenue {
+			records = append(records, []string{
+				p.Period.Format(time.RFC3339),
+				strconv.Itoa(p.UnitsSold),
+				strconv.Itoa(p.Revenue),
+			})
+		}
+
+		if err = app.writeCSV(rw, http.StatusOK, "revenue-by-"+period+".csv", records); err != nil {
+			app.logErrorWithHttpRequestContext(r, err)
+		}
+		return
+	}
+
+	report := dto.RevenueReport{
+		Period:  period,
+		Revenue: []dto.APIPeriodRevenue{},
+	}
+
+	for _, p := range revenue {
+		report.Revenue = append(report.Revenue, dto.APIPeriodRevenue{
+			Period:    p.Period,
+			UnitsSold: p.UnitsSold,
+			Revenue:   p.Revenue,
+		})
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      report,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"expvar"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -26,28 +27,164 @@ func (app *application) routes() http.Handler {
 	})
 
 	router.Get("/v1/healthcheck", app.healthcheckHandler)
+	router.Get("/debug/vars", app.requirePermission(data.PermissionMetricsRead, expvar.Handler().ServeHTTP))
 
 	router.Route("/v1/products", func(r chi.Router) {
 		r.Post("/", app.requirePermission(data.PermissionProductsWrite, app.createProductHandler))
-		r.Get("/", app.listProductHandler)
+		r.Get("/", app.requireReadPermission(app.listProductHandler))
 
 		r.Route("/{id}", func(r chi.Router) {
-			r.Get("/", app.showProductHandler)
+			r.Get("/", app.requireReadPermission(app.showProductHandler))
 			r.Put("/", app.requirePermission(data.PermissionProductsWrite, app.updateProductHandler))
+			r.Patch("/", app.requirePermission(data.PermissionProductsWrite, app.patchProductHandler))
 			r.Delete("/", app.requirePermission(data.PermissionProductsWrite, app.deleteProductHandler))
+			r.Post("/restore", app.requirePermission(data.PermissionProductsWrite, app.restoreProductHandler))
+			r.Put("/categories", app.requirePermission(data.PermissionProductsWrite, app.setProductCategoriesHandler))
+			r.Put("/tags", app.requirePermission(data.PermissionProductsWrite, app.setProductTagsHandler))
+			r.Get("/stock", app.requirePermission(data.PermissionProductsWrite, app.listProductStockHandler))
+
+			if app.config.LegacyMoneyRoutes {
+				r.Get("/buy/{amount}", app.deprecated("/v1/purchases",
+					app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.buyProductHandler)),
+				))
+			}
+		})
+
+	})
+
+	router.Route("/v1/categories", func(r chi.Router) {
+		r.Get("/", app.requireReadPermission(app.listCategoryHandler))
+		r.Post("/", app.requirePermission(data.PermissionCategoriesWrite, app.createCategoryHandler))
+		r.Get("/{id}", app.requireReadPermission(app.showCategoryHandler))
+		r.Patch("/{id}", app.requirePermission(data.PermissionCategoriesWrite, app.updateCategoryHandler))
+		r.Delete("/{id}", app.requirePermission(data.PermissionCategoriesWrite, app.deleteCategoryHandler))
+	})
+
+	// machine scoped routes, the unscoped routes below act on the default machine.
+	router.Route("/v1/machines", func(r chi.Router) {
+		r.Get("/", app.requireReadPermission(app.listMachineHandler))
+		r.Post("/", app.requirePermission(data.PermissionMachinesWrite, app.createMachineHandler))
+
+		r.Route("/{machine}", func(r chi.Router) {
+			r.Use(app.requireMachine)
+
+			r.Get("/", app.requireReadPermission(app.showMachineHandler))
+			r.Patch("/", app.requirePermission(data.PermissionMachinesWrite, app.updateMachineHandler))
 
-			r.Get("/buy/{amount}", app.requirePermission(data.PermissionProductsBuy, app.buyProductHandler))
+			r.Get("/keys", app.requirePermission(data.PermissionMachinesWrite, app.listAPIKeyHandler))
+			r.Post("/keys", app.requirePermission(data.PermissionMachinesWrite, app.createAPIKeyHandler))
+			r.Delete("/keys/{id}", app.requirePermission(data.PermissionMachinesWrite, app.revokeAPIKeyHandler))
+
+			r.Get("/stock", app.requireReadPermission(app.listMachineStockHandler))
+			r.Put("/stock/{id}", app.requirePermission(data.PermissionProductsWrite, app.restockMachineHandler))
+
+			r.Get("/coins", app.requirePermission(data.PermissionProductsWrite, app.showCoinInventoryHandler))
+			r.Post("/coins", app.requirePermission(data.PermissionProductsWrite, app.refillCoinsHandler))
+
+			r.Get("/slots", app.requireReadPermission(app.listSlotHandler))
+			r.Get("/slots/{code}", app.requireReadPermission(app.showSlotHandler))
+			r.Put("/slots/{code}", app.requireAuthenticated(app.assignSlotHandler))
+			r.Delete("/slots/{code}", app.requireAuthenticated(app.clearSlotHandler))
+
+			r.Post("/deposits", app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.createDepositHandler)))
+			r.Post("/deposits/withdraw", app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.returnCoinsHandler)))
+			r.Post("/purchases", app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.createPurchaseHandler)))
+			r.Post("/checkout", app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.checkoutHandler)))
 		})
+	})
 
+	router.Route("/v1/slots", func(r chi.Router) {
+		r.Get("/", app.requireReadPermission(app.listSlotHandler))
+		r.Get("/{code}", app.requireReadPermission(app.showSlotHandler))
+		r.Put("/{code}", app.requireAuthenticated(app.assignSlotHandler))
+		r.Delete("/{code}", app.requireAuthenticated(app.clearSlotHandler))
 	})
 
 	router.Route("/v1/users", func(r chi.Router) {
 		r.Post("/", app.registerUserHandler)
-		r.Get("/deposit/{amount}", app.requirePermission(data.PermissionProductsBuy, app.depositBalanceHandler))
-		r.Get("/deposit/reset", app.requirePermission(data.PermissionProductsBuy, app.resetBalanceHandler))
+		r.Get("/me", app.requireAuthenticatedUser(app.showCurrentUserHandler))
+		r.Patch("/me", app.requireAuthenticatedUser(app.updateCurrentUserHandler))
+		r.Put("/me/password", app.requireAuthenticatedUser(app.changePasswordHandler))
+		r.Delete("/me", app.requireAuthenticatedUser(app.deleteCurrentUserHandler))
+		r.Get("/me/export", app.requireAuthenticatedUser(app.exportCurrentUserHandler))
+		r.Get("/{id}", app.requireAuthenticatedUser(app.showUserHandler))
+
+		if app.config.LegacyMoneyRoutes {
+			r.Get("/deposit/{amount}", app.deprecated("/v1/deposits",
+				app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.depositBalanceHandler)),
+			))
+			r.Get("/deposit/reset", app.deprecated("/v1/deposits/reset",
+				app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.resetBalanceHandler)),
+			))
+		}
+	})
+
+	router.Route("/v1/admin", func(r chi.Router) {
+		r.Get("/users", app.requirePermission(data.PermissionUsersRead, app.listUserHandler))
+		r.Get("/users/{id}", app.requirePermission(data.PermissionUsersRead, app.adminShowUserHandler))
+		r.Put("/users/{id}/role", app.requirePermission(data.PermissionUsersWrite, app.changeUserRoleHandler))
+		r.Post("/users/{id}/suspension", app.requirePermission(data.PermissionUsersWrite, app.suspendUserHandler))
+		r.Delete("/users/{id}/suspension", app.requirePermission(data.PermissionUsersWrite, app.unsuspendUserHandler))
+		r.Post("/users/{id}/deposit-adjustments", app.requirePermission(data.PermissionDepositsAdjust, app.idempotent(app.adjustDepositHandler)))
+		r.Get("/audit-log", app.requirePermission(data.PermissionUsersRead, app.listAuditHandler))
+
+		r.Get("/users/{id}/permissions", app.requirePermission(data.PermissionUsersRead, app.showUserPermissionsHandler))
+		r.Put("/users/{id}/permissions/{code}", app.requirePermission(data.PermissionPermissionsWrite, app.grantPermissionHandler))
+		r.Delete("/users/{id}/permissions/{code}", app.requirePermission(data.PermissionPermissionsWrite, app.revokePermissionHandler))
+
+		r.Get("/permissions", app.requirePermission(data.PermissionUsersRead, app.listPermissionHandler))
+		r.Post("/permissions", app.requirePermission(data.PermissionPermissionsWrite, app.createPermissionHandler))
+		r.Delete("/permissions/{code}", app.requirePermission(data.PermissionPermissionsWrite, app.deletePermissionHandler))
+
+		r.Get("/roles", app.requirePermission(data.PermissionUsersRead, app.listRoleHandler))
+		r.Post("/roles", app.requirePermission(data.PermissionPermissionsWrite, app.createRoleHandler))
+		r.Get("/roles/{name}", app.requirePermission(data.PermissionUsersRead, app.showRoleHandler))
+		r.Put("/roles/{name}/permissions", app.requirePermission(data.PermissionPermissionsWrite, app.setRolePermissionsHandler))
+		r.Delete("/roles/{name}", app.requirePermission(data.PermissionPermissionsWrite, app.deleteRoleHandler))
+	})
+
+	router.Route("/v1/deposits", func(r chi.Router) {
+		r.Post("/", app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.createDepositHandler)))
+		r.Post("/reset", app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.resetBalanceHandler)))
+		r.Post("/withdraw", app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.returnCoinsHandler)))
+	})
+
+	router.Route("/v1/purchases", func(r chi.Router) {
+		r.Post("/", app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.createPurchaseHandler)))
+		r.Get("/", app.requirePermission(data.PermissionProductsBuy, app.listPurchaseHandler))
+		r.Get("/{id}", app.requirePermission(data.PermissionProductsBuy, app.showPurchaseHandler))
+		r.Post("/{id}/refunds", app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.createRefundHandler)))
+	})
+
+	router.Route("/v1/refunds", func(r chi.Router) {
+		r.Get("/", app.requireAuthenticatedUser(app.listRefundHandler))
+		r.Get("/{id}", app.requireAuthenticatedUser(app.showRefundHandler))
+		r.Post("/{id}/approve", app.requireAuthenticatedUser(app.idempotent(app.approveRefundHandler)))
+		r.Post("/{id}/reject", app.requireAuthenticatedUser(app.idempotent(app.rejectRefundHandler)))
+	})
+
+	router.Post("/v1/checkout", app.requirePermission(data.PermissionProductsBuy, app.idempotent(app.checkoutHandler)))
+
+	router.Route("/v1/coins", func(r chi.Router) {
+		r.Get("/", app.requirePermission(data.PermissionProductsWrite, app.showCoinInventoryHandler))
+		r.Post("/", app.requirePermission(data.PermissionProductsWrite, app.refillCoinsHandler))
+	})
+
+	router.Route("/v1/reports", func(r chi.Router) {
+		r.Get("/products", app.requirePermission(data.PermissionProductsWrite, app.productSalesReportHandler))
+		r.Get("/revenue", app.requirePermission(data.PermissionProductsWrite, app.revenueReportHandler))
+	})
+
+	router.Get("/v1/ledger", app.requirePermission(data.PermissionProductsBuy, app.listLedgerHandler))
+
+	router.Route("/v1/auth/tokens", func(r chi.Router) {
+		r.Post("/", app.getAuthenticationToken)
+		r.Get("/", app.requireAuthenticatedUser(app.listAuthenticationTokenHandler))
+		r.Delete("/", app.requireAuthenticatedUser(app.revokeAllAuthenticationTokensHandler))
+		r.Delete("/current", app.requireAuthenticatedUser(app.revokeCurrentAuthenticationTokenHandler))
 	})
 
-	router.Post("/v1/auth/tokens", app.getAuthenticationToken)
+	router.Post("/v1/auth/refresh", app.refreshAuthenticationTokenHandler)
 
 	return router
 }
@@ -29,6 +29,11 @@ func (app *application) serve() error {
 
 	shutdownError := make(chan error)
 
+	// closed on shutdown to stop the background jobs.
+	done := make(chan struct{})
+	app.purgeArchivedProducts(done)
+	app.purgeIdempotencyKeys(done)
+
 	// shutdown mechanism.
 	go func() {
 
@@ -50,6 +55,8 @@ func (app *application) serve() error {
 
 		app.logger.Printf("completing background tasks")
 
+		close(done)
+
 		app.wg.Wait() // wait for background go routines to finish before shutting down
 		shutdownError <- nil
 	}()
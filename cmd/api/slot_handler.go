@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+)
+
+func (app *application) listSlotHandler(rw http.ResponseWriter, r *http.Request) {
+
+	slots, err := app.slotService.List(app.machineID(r))
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listSlotResponse := dto.ListSlotResponse{
+		Slots: []dto.APISlot{},
+	}
+
+	for _, slot := range slots {
+		listSlotResponse.Slots = append(listSlotResponse.Slots, getAPISlot(slot))
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listSlotResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) showSlotHandler(rw http.ResponseWriter, r *http.Request) {
+	code, ok := app.readSlotCode(r)
+	if !ok {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	slot, err := app.slotService.Get(app.machineID(r), code)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	app.writeSlot(rw, r, slot)
+}
+
+func (app *application) assignSlotHandler(rw http.ResponseWriter, r *http.Request) {
+	code, ok := app.readSlotCode(r)
+	if !ok {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	operator, ok := app.slotActor(rw, r)
+	if !ok {
+		return
+	}
+
+	var input dto.SlotRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	slot, validationErrors, err := app.slotService.Assign(app.contextGetUser(r), operator, app.machineID(r), code, input)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		case errors.Is(err, data.ErrNoPermission):
+			app.notPermittedRResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	app.writeSlot(rw, r, slot)
+}
+
+func (app *application) clearSlotHandler(rw http.ResponseWriter, r *http.Request) {
+	code, ok := app.readSlotCode(r)
+	if !ok {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	operator, ok := app.slotActor(rw, r)
+	if !ok {
+		return
+	}
+
+	slot, err := app.slotService.Clear(app.contextGetUser(r), operator, app.machineID(r), code)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		case errors.Is(err, data.ErrNoPermission):
+			app.notPermittedRResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	app.writeSlot(rw, r, slot)
+}
+
+// slotActor reports whether the caller is a machine operator. Sellers may
+// manage slots for their own products; anyone else gets a 403 and ok is false.
+func (app *application) slotActor(rw http.ResponseWriter, r *http.Request) (operator bool, ok bool) {
+	permissions, err := app.permissions(r)
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return false, false
+	}
+
+	if permissions.Includes(data.PermissionSlotsWrite) {
+		return true, true
+	}
+
+	if !permissions.Includes(data.PermissionProductsWrite) {
+		app.notPermittedRResponse(rw, r)
+		return false, false
+	}
+
+	return false, true
+}
+
+func (app *application) readSlotCode(r *http.Request) (string, bool) {
+	code := strings.ToUpper(chi.URLParam(r, "code"))
+
+	return code, data.SlotCodeRX.MatchString(code)
+}
+
+func (app *application) writeSlot(rw http.ResponseWriter, r *http.Request, slot *data.Slot) {
+	if err := app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.SlotResponse{Slot: getAPISlot(slot)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func getAPISlot(slot *data.Slot) dto.APISlot {
+	return dto.APISlot{
+		Code:      slot.Code,
+		Capacity:  slot.Capacity,
+		ProductID: slot.ProductID,
+		Quantity:  slot.Quantity,
+		UpdatedAt: slot.UpdatedAt,
+	}
+}
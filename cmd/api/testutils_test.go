@@ -15,6 +15,7 @@ import (
 	"github.com/terdia/mvp/internal/data"
 	"github.com/terdia/mvp/internal/service/productservice"
 	repo "github.com/terdia/mvp/mocks/repository"
+	svc "github.com/terdia/mvp/mocks/service"
 )
 
 type TestResponse struct {
@@ -26,14 +27,7 @@ type TestResponse struct {
 // createTestApplication is the application for test
 func createTestApplication(t *testing.T, mockProductRepo bool) *application {
 
-	cfg := new(config)
-	logger := zerolog.New(&bytes.Buffer{})
-
-	ctrl := gomock.NewController(t)
-	productRepo := repo.NewMockProductRepository(ctrl)
-	newProductService := productservice.NewProductService(
-		productRepo,
-	)
+	app, productRepo, _ := createMockedTestApplication(t)
 
 	if mockProductRepo {
 		productRepo.EXPECT().Get(gomock.Any()).Return(&data.Product{
@@ -43,15 +37,35 @@ func createTestApplication(t *testing.T, mockProductRepo bool) *application {
 			Seller:          data.User{ID: 2},
 			CreatedAt:       time.Now(),
 			AmountAvailable: 20,
+			Version:         3,
 		}, nil)
+		productRepo.EXPECT().LoadLabels(gomock.Any()).Return(nil)
 	}
 
+	return app
+}
+
+// createMockedTestApplication is the application for test together with the
+// mocks behind it, without any expectations set.
+func createMockedTestApplication(t *testing.T) (*application, *repo.MockProductRepository, *svc.MockUserService) {
+
+	cfg := new(config)
+	logger := zerolog.New(&bytes.Buffer{})
+
+	ctrl := gomock.NewController(t)
+	productRepo := repo.NewMockProductRepository(ctrl)
+	userService := svc.NewMockUserService(ctrl)
+
 	return &application{
-		wg:             new(sync.WaitGroup),
-		config:         cfg,
-		logger:         &logger,
-		productService: newProductService,
-	}
+		wg:     new(sync.WaitGroup),
+		config: cfg,
+		logger: &logger,
+		productService: productservice.NewProductService(
+			productRepo,
+			[]byte("secret"),
+		),
+		userService: userService,
+	}, productRepo, userService
 }
 
 // Define a custom testServer type which anonymously embeds a httptest.Server instance.
@@ -74,7 +88,22 @@ func newTestServer(t *testing.T, h http.Handler) *testServer {
 
 // get makes a request to given urlPath
 func (ts *testServer) get(t *testing.T, urlPath string) TestResponse {
-	rs, err := ts.Client().Get(ts.URL + urlPath)
+	return ts.getWithToken(t, urlPath, "")
+}
+
+// getWithToken makes a request to given urlPath, authenticated with token
+// unless it is empty.
+func (ts *testServer) getWithToken(t *testing.T, urlPath, token string) TestResponse {
+	req, err := http.NewRequest(http.MethodGet, ts.URL+urlPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rs, err := ts.Client().Do(req)
 	if err != nil {
 		t.Fatal(err)
 	}
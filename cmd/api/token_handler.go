@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/tomasen/realip"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// refreshAuthenticationTokenHandler exchanges a refresh token for a new access
+// and refresh token. The presented refresh token stops working.
+func (app *application) refreshAuthenticationTokenHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.RefreshTokenRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(input.RefreshToken != "", "refresh_token", "must be provided")
+	v.Check(len(input.RefreshToken) == 26, "refresh_token", "must be 26 bytes long")
+	if !v.Valid() {
+		app.failedValidationResponse(rw, r, v.Errors)
+		return
+	}
+
+	tokens, err := app.tokenService.Refresh(input.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrTokenReused):
+			app.logger.Warn().Str("ip", realip.FromRequest(r)).Msg("refresh token reused, token family revoked")
+			app.invalidAuthenticationTokenResponse(rw, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.invalidAuthenticationTokenResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      getTokenResponse(tokens),
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+// listAuthenticationTokenHandler lists the caller's active sessions.
+func (app *application) listAuthenticationTokenHandler(rw http.ResponseWriter, r *http.Request) {
+
+	sessions, err := app.tokenService.ListSessions(app.contextGetUser(r).ID, app.contextGetToken(r))
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	listSessionResponse := dto.ListSessionResponse{
+		Sessions: []dto.Session{},
+	}
+
+	for _, session := range sessions {
+		listSessionResponse.Sessions = append(listSessionResponse.Sessions, dto.Session{
+			ID:         session.FamilyID,
+			CreatedAt:  session.CreatedAt,
+			Expiry:     session.Expiry,
+			LastSeenIP: session.LastSeenIP,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.Current,
+		})
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      listSessionResponse,
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+// revokeCurrentAuthenticationTokenHandler logs out of the session the request
+// was made with.
+func (app *application) revokeCurrentAuthenticationTokenHandler(rw http.ResponseWriter, r *http.Request) {
+
+	if err := app.tokenService.Revoke(app.contextGetToken(r)); err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.invalidAuthenticationTokenResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err := app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Message:   "token successfully revoked",
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+// revokeAllAuthenticationTokensHandler logs the caller out everywhere,
+// including the session the request was made with.
+func (app *application) revokeAllAuthenticationTokensHandler(rw http.ResponseWriter, r *http.Request) {
+
+	userID := app.contextGetUser(r).ID
+
+	for _, scope := range []string{data.TokenScopeRefresh, data.TokenScopeAuthentication} {
+		if err := app.tokenService.DeleteByUserIdAndScope(userID, scope); err != nil {
+			app.serverErrorResponse(rw, r, err)
+			return
+		}
+	}
+
+	if err := app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Message:   "all tokens successfully revoked",
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func getTokenResponse(tokens *data.TokenPair) dto.TokenResponse {
+	return dto.TokenResponse{
+		Token: dto.Token{
+			PlainText: tokens.Access.Plaintext,
+			Expiry:    tokens.Access.Expiry,
+		},
+		RefreshToken: dto.Token{
+			PlainText: tokens.Refresh.Plaintext,
+			Expiry:    tokens.Refresh.Expiry,
+		},
+	}
+}
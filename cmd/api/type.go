@@ -2,10 +2,24 @@ package main
 
 import (
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 
+	"github.com/terdia/mvp/internal/service/accountservice"
+	"github.com/terdia/mvp/internal/service/adminservice"
+	"github.com/terdia/mvp/internal/service/auth"
+	"github.com/terdia/mvp/internal/service/categoryservice"
+	"github.com/terdia/mvp/internal/service/coinservice"
+	"github.com/terdia/mvp/internal/service/idempotency"
+	"github.com/terdia/mvp/internal/service/ledger"
+	"github.com/terdia/mvp/internal/service/machineservice"
+	"github.com/terdia/mvp/internal/service/permissionservice"
 	"github.com/terdia/mvp/internal/service/productservice"
+	"github.com/terdia/mvp/internal/service/purchaseservice"
+	"github.com/terdia/mvp/internal/service/refundservice"
+	"github.com/terdia/mvp/internal/service/reportservice"
+	"github.com/terdia/mvp/internal/service/slotservice"
 	"github.com/terdia/mvp/internal/service/transaction"
 	"github.com/terdia/mvp/internal/service/userservice"
 )
@@ -16,7 +30,21 @@ type (
 		config             *config
 		logger             *zerolog.Logger
 		userService        userservice.UserService
+		tokenService       auth.TokenService
 		productService     productservice.ProductService
+		categoryService    categoryservice.CategoryService
+		ledgerService      ledger.Service
+		coinService        coinservice.CoinService
+		purchaseService    purchaseservice.PurchaseService
+		refundService      refundservice.RefundService
+		reportService      reportservice.ReportService
+		slotService        slotservice.SlotService
+		machineService     machineservice.MachineService
+		apiKeyService      auth.APIKeyService
+		adminService       adminservice.AdminService
+		permissionService  permissionservice.PermissionService
+		accountService     accountservice.AccountService
+		idempotencyService idempotency.Service
 		transactionService transaction.Service
 	}
 
@@ -27,6 +55,39 @@ type (
 		Cors    struct {
 			TrustedOrigins []string `env:"CORS_ALLOWED" envSeparator:","`
 		}
+		// LegacyMoneyRoutes keeps the deprecated GET deposit, reset and buy
+		// routes mounted until clients have moved to the POST resources.
+		LegacyMoneyRoutes bool `env:"LEGACY_MONEY_ROUTES" envDefault:"true"`
+		// RequireReadPermission closes the catalogue to callers
+		// without products:read. Machines read it through the routes of
+		// their own machine.
+		RequireReadPermission bool `env:"REQUIRE_READ_PERMISSION" envDefault:"false"`
+		// CursorSecret signs product list cursors. When it is not set a random
+		// secret is used and cursors stop working after a restart.
+		CursorSecret string `env:"CURSOR_SECRET"`
+		// Archive controls how long deleted products are kept before they are
+		// purged for good. A zero retention keeps them forever.
+		Archive struct {
+			Retention     time.Duration `env:"PRODUCT_ARCHIVE_RETENTION" envDefault:"720h"`
+			PurgeInterval time.Duration `env:"PRODUCT_PURGE_INTERVAL" envDefault:"1h"`
+		}
+		// IdempotencyPurgeInterval is how often idempotency keys past their
+		// replay window are deleted; 0 keeps them until they are reused.
+		IdempotencyPurgeInterval time.Duration `env:"IDEMPOTENCY_PURGE_INTERVAL" envDefault:"1h"`
+		// Tokens sets how long a login lasts: access tokens authenticate
+		// requests, refresh tokens are exchanged for new ones at
+		// POST /v1/auth/refresh.
+		Tokens struct {
+			AccessTTL  time.Duration `env:"ACCESS_TOKEN_TTL" envDefault:"15m"`
+			RefreshTTL time.Duration `env:"REFRESH_TOKEN_TTL" envDefault:"168h"`
+		}
+		// AuthCache keeps the users behind access tokens and their
+		// permissions in memory. Changes made through another instance
+		// show up here after at most TTL; a Size of 0 turns it off.
+		AuthCache struct {
+			Size int           `env:"AUTH_CACHE_SIZE" envDefault:"10000"`
+			TTL  time.Duration `env:"AUTH_CACHE_TTL" envDefault:"30s"`
+		}
 	}
 
 	db struct {
@@ -39,6 +39,112 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+func (app *application) showCurrentUserHandler(rw http.ResponseWriter, r *http.Request) {
+	app.showUser(rw, r, app.contextGetUser(r).ID)
+}
+
+// showUserHandler serves a user's own profile, or any profile to callers who
+// may read users.
+func (app *application) showUserHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := app.extractIntParamFromContext(r, "id")
+	if err != nil || id < 1 {
+		app.notFoundResponse(rw, r)
+		return
+	}
+
+	if id != app.contextGetUser(r).ID {
+		allowed, err := app.hasPermission(r, data.PermissionUsersRead)
+		if err != nil {
+			app.serverErrorResponse(rw, r, err)
+			return
+		}
+
+		if !allowed {
+			app.notPermittedRResponse(rw, r)
+			return
+		}
+	}
+
+	app.showUser(rw, r, id)
+}
+
+func (app *application) showUser(rw http.ResponseWriter, r *http.Request, id int64) {
+	user, err := app.userService.GetUser(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(rw, r)
+		default:
+			app.serverErrorResponse(rw, r, err)
+		}
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.UserResponse{User: getAPIUser(user)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+func (app *application) updateCurrentUserHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.UpdateUserRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	user := app.contextGetUser(r)
+	validationErrors, err := app.userService.UpdateProfile(user, input)
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Data:      dto.UserResponse{User: getAPIUser(user)},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
+// changePasswordHandler sets a new password and signs out every other session.
+func (app *application) changePasswordHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.ChangePasswordRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	user := app.contextGetUser(r)
+	validationErrors, err := app.userService.ChangePassword(user, input, app.contextGetToken(r))
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Message:   "Password was changed, other sessions were signed out",
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+	}
+}
+
 func (app *application) getAuthenticationToken(rw http.ResponseWriter, r *http.Request) {
 
 	request := dto.AuthTokenRequest{}
@@ -49,9 +155,7 @@ func (app *application) getAuthenticationToken(rw http.ResponseWriter, r *http.R
 		return
 	}
 
-	token, validationErrors, err := app.userService.CreateAuthenticationToken(
-		request, data.TokenScopeAuthentication,
-	)
+	tokens, validationErrors, err := app.userService.CreateAuthenticationToken(request)
 	if validationErrors != nil {
 		app.failedValidationResponse(rw, r, validationErrors)
 		return
@@ -63,28 +167,35 @@ func (app *application) getAuthenticationToken(rw http.ResponseWriter, r *http.R
 			app.invalidCredentialsResponse(rw, r)
 		case errors.Is(err, data.ErrInvalidCredentials):
 			app.invalidCredentialsResponse(rw, r)
+		case errors.Is(err, data.ErrAccountSuspended):
+			app.accountSuspendedResponse(rw, r)
 		default:
 			app.serverErrorResponse(rw, r, err)
 		}
 		return
 	}
 
-	tokenDto := dto.Token{
-		PlainText: token.Plaintext,
-		Expiry:    token.Expiry,
-	}
-
 	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
 		StatusMsg: dto.Success,
-		Data: dto.TokenResponse{
-			Token: tokenDto,
-		},
+		Data:      getTokenResponse(tokens),
 	}, nil); err != nil {
 		app.serverErrorResponse(rw, r, err)
 		return
 	}
 }
 
+func (app *application) createDepositHandler(rw http.ResponseWriter, r *http.Request) {
+
+	var input dto.DepositRequest
+	if err := app.readJson(rw, r, &input); err != nil {
+		app.badRequestResponse(rw, r, err)
+		return
+	}
+
+	app.deposit(rw, r, input.Amount)
+}
+
+// depositBalanceHandler serves the deprecated GET /v1/users/deposit/{amount} route.
 func (app *application) depositBalanceHandler(rw http.ResponseWriter, r *http.Request) {
 
 	amount, err := app.extractIntParamFromContext(r, "amount")
@@ -93,8 +204,13 @@ func (app *application) depositBalanceHandler(rw http.ResponseWriter, r *http.Re
 		return
 	}
 
+	app.deposit(rw, r, int(amount))
+}
+
+func (app *application) deposit(rw http.ResponseWriter, r *http.Request, amount int) {
+
 	user := app.contextGetUser(r)
-	validationErrors, err := app.transactionService.DepositCoin(user, int(amount))
+	validationErrors, err := app.transactionService.DepositCoin(user, app.machineID(r), amount)
 	if validationErrors != nil {
 		app.failedValidationResponse(rw, r, validationErrors)
 		return
@@ -118,7 +234,7 @@ func (app *application) depositBalanceHandler(rw http.ResponseWriter, r *http.Re
 func (app *application) resetBalanceHandler(rw http.ResponseWriter, r *http.Request) {
 
 	user := app.contextGetUser(r)
-	validationErrors, err := app.transactionService.DepositReset(user)
+	coins, validationErrors, err := app.transactionService.DepositReset(user)
 	if validationErrors != nil {
 		app.failedValidationResponse(rw, r, validationErrors)
 		return
@@ -132,7 +248,37 @@ func (app *application) resetBalanceHandler(rw http.ResponseWriter, r *http.Requ
 	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
 		StatusMsg: dto.Success,
 		Message:   "Reset balance was successful",
-		Data:      dto.UserResponse{User: getAPIUser(user)},
+		Data: dto.ReturnCoinsResponse{
+			Change: coins,
+			User:   getAPIUser(user),
+		},
+	}, nil); err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+}
+
+func (app *application) returnCoinsHandler(rw http.ResponseWriter, r *http.Request) {
+
+	user := app.contextGetUser(r)
+	coins, validationErrors, err := app.transactionService.ReturnCoins(user, app.machineID(r))
+	if validationErrors != nil {
+		app.failedValidationResponse(rw, r, validationErrors)
+		return
+	}
+
+	if err != nil {
+		app.serverErrorResponse(rw, r, err)
+		return
+	}
+
+	if err = app.writeJson(rw, http.StatusOK, dto.ResponseObject{
+		StatusMsg: dto.Success,
+		Message:   "Coins returned",
+		Data: dto.ReturnCoinsResponse{
+			Change: coins,
+			User:   getAPIUser(user),
+		},
 	}, nil); err != nil {
 		app.serverErrorResponse(rw, r, err)
 		return
@@ -141,10 +287,13 @@ func (app *application) resetBalanceHandler(rw http.ResponseWriter, r *http.Requ
 
 func getAPIUser(user *data.User) dto.APIUser {
 	return dto.APIUser{
-		ID:        user.ID,
-		Role:      user.Role,
-		Username:  user.Username,
-		Deposit:   user.Deposit,
-		CreatedAt: user.CreatedAt,
+		ID:               user.ID,
+		Role:             user.Role,
+		Username:         user.Username,
+		Deposit:          user.Deposit,
+		DepositMachineID: user.DepositMachineID,
+		CreatedAt:        user.CreatedAt,
+		SuspendedAt:      user.SuspendedAt,
+		DeletedAt:        user.DeletedAt,
 	}
 }
@@ -0,0 +1,63 @@
+package data
+
+import (
+	"strings"
+	"time"
+
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// APIKeyPrefix starts every machine API key, which is how authenticate tells
+// them apart from user tokens sent in the same Authorization header.
+const APIKeyPrefix = "mk_"
+
+// APIKeyScopes are the permissions a machine may be granted. Anything that acts
+// on behalf of a buyer or seller account stays with user tokens.
+var APIKeyScopes = []string{
+	PermissionProductsRead,
+	PermissionSlotsWrite,
+}
+
+// APIKey lets a machine call the API as itself. Plaintext is only set when the
+// key is created; afterwards the key is known by its Prefix and Hash. A nil
+// Expiry never expires. LastUsedAt is only kept to within TokenSeenInterval.
+type APIKey struct {
+	ID         int64
+	MachineID  int64
+	Name       string
+	Prefix     string
+	Plaintext  string
+	Hash       []byte
+	Scopes     Permissions
+	Expiry     *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// Principal is the user the key authenticates as. It has no account behind it,
+// its permissions are the key's scopes.
+func (k *APIKey) Principal() *User {
+	return &User{
+		Role:     roleMachine,
+		Username: k.Prefix,
+		APIKey:   k,
+	}
+}
+
+func (k *APIKey) Validate(v *validator.Validator) {
+	v.Check(k.Name != "", "name", "must be provided")
+	v.Check(len(k.Name) <= 100, "name", "must not be more than 100 bytes long")
+
+	v.Check(len(k.Scopes) > 0, "scopes", "must contain at least one scope")
+	v.Check(validator.Unique([]string(k.Scopes)), "scopes", "must not contain duplicate values")
+	for _, scope := range k.Scopes {
+		if !validator.In(scope, APIKeyScopes) {
+			v.AddError("scopes", "must only contain "+strings.Join(APIKeyScopes, ", "))
+			break
+		}
+	}
+
+	if k.Expiry != nil {
+		v.Check(k.Expiry.After(time.Now()), "expires_at", "must be in the future")
+	}
+}
@@ -0,0 +1,52 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+func TestAPIKeyValidate(t *testing.T) {
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	testCases := map[string]struct {
+		key   APIKey
+		valid bool
+	}{
+		"Valid":          {key: APIKey{Name: "lobby", Scopes: Permissions{PermissionSlotsWrite}}, valid: true},
+		"Expiring":       {key: APIKey{Name: "lobby", Scopes: Permissions{PermissionSlotsWrite}, Expiry: &future}, valid: true},
+		"Expired":        {key: APIKey{Name: "lobby", Scopes: Permissions{PermissionSlotsWrite}, Expiry: &past}, valid: false},
+		"NoName":         {key: APIKey{Scopes: Permissions{PermissionSlotsWrite}}, valid: false},
+		"NoScopes":       {key: APIKey{Name: "lobby"}, valid: false},
+		"DuplicateScope": {key: APIKey{Name: "lobby", Scopes: Permissions{PermissionSlotsWrite, PermissionSlotsWrite}}, valid: false},
+		"UserScope":      {key: APIKey{Name: "lobby", Scopes: Permissions{PermissionProductsBuy}}, valid: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v := validator.New()
+			tc.key.Validate(v)
+
+			if v.Valid() != tc.valid {
+				t.Errorf("want valid %t; got %t (%v)", tc.valid, v.Valid(), v.Errors)
+			}
+		})
+	}
+}
+
+func TestAPIKeyPrincipal(t *testing.T) {
+	key := &APIKey{MachineID: 2, Prefix: APIKeyPrefix + "ABCDEFGH"}
+
+	user := key.Principal()
+
+	if !user.IsMachine() || user.IsAnonymous() {
+		t.Fatalf("want an authenticated machine principal; got %+v", user)
+	}
+
+	if user.ID != 0 || user.APIKey.MachineID != 2 {
+		t.Errorf("want principal without an account for machine 2; got user %d machine %d", user.ID, user.APIKey.MachineID)
+	}
+}
@@ -0,0 +1,39 @@
+package data
+
+import (
+	"time"
+)
+
+const (
+	AuditUserRoleChanged = "user.role_changed"
+	AuditUserSuspended   = "user.suspended"
+	AuditUserUnsuspended = "user.unsuspended"
+	AuditUserDeleted     = "user.deleted"
+	AuditDepositAdjusted = "deposit.adjusted"
+
+	AuditPermissionCreated = "permission.created"
+	AuditPermissionDeleted = "permission.deleted"
+	AuditPermissionGranted = "user.permission_granted"
+	AuditPermissionRevoked = "user.permission_revoked"
+	AuditRoleCreated       = "role.created"
+	AuditRoleChanged       = "role.permissions_changed"
+	AuditRoleDeleted       = "role.deleted"
+)
+
+// AuditEntry records an action an admin took. Details holds what changed, e.g.
+// the old and new role. TargetUserID is 0 for actions that are not about a user.
+type AuditEntry struct {
+	ID           int64
+	ActorID      int64
+	Action       string
+	TargetUserID int64
+	Reason       string
+	Details      map[string]interface{}
+	CreatedAt    time.Time
+}
+
+type AuditFilter struct {
+	ActorID      int64
+	TargetUserID int64
+	Action       string
+}
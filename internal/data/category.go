@@ -0,0 +1,72 @@
+package data
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+const (
+	maxTagsPerProduct       = 20
+	maxCategoriesPerProduct = 10
+)
+
+var (
+	SlugRX = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+)
+
+// Category groups products for browsing, e.g. snacks or drinks. Categories
+// form a tree through ParentID; a zero ParentID is a top level category.
+type Category struct {
+	ID        int64
+	Name      string
+	Slug      string
+	ParentID  int64
+	CreatedAt time.Time
+}
+
+func (c *Category) Validate(v *validator.Validator) {
+	v.Check(c.Name != "", "name", "must be provided")
+	v.Check(len(c.Name) <= 100, "name", "must not be more than 100 bytes long")
+
+	v.Check(c.Slug != "", "slug", "must be provided")
+	v.Check(len(c.Slug) <= 100, "slug", "must not be more than 100 bytes long")
+	v.Check(validator.Matches(c.Slug, SlugRX), "slug", "must only contain lowercase letters, digits and dashes")
+
+	v.Check(c.ParentID >= 0, "parent_id", "must be a positive integer")
+	if c.ID > 0 {
+		v.Check(c.ParentID != c.ID, "parent_id", "a category can not be its own parent")
+	}
+}
+
+// NormaliseTags lowercases and trims tags so "Vegan " and "vegan" are the same tag.
+func NormaliseTags(tags []string) []string {
+	normalised := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		normalised = append(normalised, strings.ToLower(strings.TrimSpace(tag)))
+	}
+
+	return normalised
+}
+
+func ValidateTags(v *validator.Validator, tags []string) {
+	v.Check(len(tags) <= maxTagsPerProduct, "tags", "must not contain more than 20 tags")
+	v.Check(validator.Unique(tags), "tags", "must not contain duplicate values")
+
+	for _, tag := range tags {
+		v.Check(tag != "", "tags", "must not contain empty tags")
+		v.Check(len(tag) <= 50, "tags", "must not contain tags longer than 50 bytes")
+		v.Check(validator.Matches(tag, SlugRX), "tags", "must only contain lowercase letters, digits and dashes")
+	}
+}
+
+func ValidateCategoryIDs(v *validator.Validator, ids []int64) {
+	v.Check(len(ids) <= maxCategoriesPerProduct, "category_ids", "must not contain more than 10 categories")
+	v.Check(validator.Unique(ids), "category_ids", "must not contain duplicate values")
+
+	for _, id := range ids {
+		v.Check(id > 0, "category_ids", "must only contain positive ids")
+	}
+}
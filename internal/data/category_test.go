@@ -0,0 +1,32 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+func TestValidateTags(t *testing.T) {
+
+	testCases := map[string]struct {
+		tags  []string
+		valid bool
+	}{
+		"Valid":      {tags: NormaliseTags([]string{" Vegan", "gluten-free"}), valid: true},
+		"None":       {tags: []string{}, valid: true},
+		"Duplicates": {tags: NormaliseTags([]string{"vegan", "Vegan "}), valid: false},
+		"Spaces":     {tags: []string{"no sugar"}, valid: false},
+		"Empty":      {tags: []string{""}, valid: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v := validator.New()
+			ValidateTags(v, tc.tags)
+
+			if v.Valid() != tc.valid {
+				t.Errorf("want valid %t; got %t (%v)", tc.valid, v.Valid(), v.Errors)
+			}
+		})
+	}
+}
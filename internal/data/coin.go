@@ -0,0 +1,88 @@
+package data
+
+import (
+	"math"
+	"sort"
+)
+
+// Coins lists the accepted coins, largest first.
+var Coins = []int{CoinHundredCent, CoinFiftyCent, CoinTwentyCent, CoinTenCent, CoinFiveCent}
+
+// CoinInventory is the number of coins of each value held by the machine.
+type CoinInventory map[int]int
+
+// MakeChange finds the fewest coins, taken from the inventory, that add up to
+// amount. The coins are returned largest first; ok is false when the inventory
+// cannot make the exact amount.
+func (inv CoinInventory) MakeChange(amount int) (change []int, ok bool) {
+
+	if amount == 0 {
+		return nil, true
+	}
+
+	if amount < 0 || amount%CoinFiveCent != 0 {
+		return nil, false
+	}
+
+	// split each coin stack into bundles of 1, 2, 4, ... coins so the bounded
+	// problem can be solved as a 0/1 knapsack over the bundles.
+	type bundle struct {
+		coin, count int
+	}
+
+	var bundles []bundle
+	for _, coin := range Coins {
+		available := inv[coin]
+		if available > amount/coin {
+			available = amount / coin
+		}
+
+		for size := 1; available > 0; size *= 2 {
+			if size > available {
+				size = available
+			}
+			bundles = append(bundles, bundle{coin: coin, count: size})
+			available -= size
+		}
+	}
+
+	// amounts are tracked in units of the smallest coin.
+	units := amount / CoinFiveCent
+
+	fewest := make([]int, units+1)
+	for i := 1; i <= units; i++ {
+		fewest[i] = math.MaxInt32
+	}
+
+	taken := make([][]bool, len(bundles))
+	for i, b := range bundles {
+		taken[i] = make([]bool, units+1)
+		weight := b.coin * b.count / CoinFiveCent
+
+		for a := units; a >= weight; a-- {
+			if fewest[a-weight] != math.MaxInt32 && fewest[a-weight]+b.count < fewest[a] {
+				fewest[a] = fewest[a-weight] + b.count
+				taken[i][a] = true
+			}
+		}
+	}
+
+	if fewest[units] == math.MaxInt32 {
+		return nil, false
+	}
+
+	for i, a := len(bundles)-1, units; i >= 0; i-- {
+		if !taken[i][a] {
+			continue
+		}
+
+		for k := 0; k < bundles[i].count; k++ {
+			change = append(change, bundles[i].coin)
+		}
+		a -= bundles[i].coin * bundles[i].count / CoinFiveCent
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(change)))
+
+	return change, true
+}
@@ -0,0 +1,69 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCoinInventory_MakeChange(t *testing.T) {
+
+	testCases := map[string]struct {
+		inventory CoinInventory
+		amount    int
+		change    []int
+		ok        bool
+	}{
+		"Nothing": {
+			inventory: CoinInventory{},
+			amount:    0,
+			change:    nil,
+			ok:        true,
+		},
+		"FewestCoins": {
+			inventory: CoinInventory{100: 10, 50: 10, 20: 10, 10: 10, 5: 10},
+			amount:    275,
+			change:    []int{100, 100, 50, 20, 5},
+			ok:        true,
+		},
+		"GreedyWouldFail": {
+			// greedy takes the 50 and is left with 10 it cannot pay
+			inventory: CoinInventory{50: 1, 20: 3},
+			amount:    60,
+			change:    []int{20, 20, 20},
+			ok:        true,
+		},
+		"LimitedLargeCoins": {
+			inventory: CoinInventory{100: 1, 10: 20},
+			amount:    250,
+			change:    []int{100, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10, 10},
+			ok:        true,
+		},
+		"NotEnoughCoins": {
+			inventory: CoinInventory{100: 1, 50: 1},
+			amount:    200,
+			change:    nil,
+			ok:        false,
+		},
+		"NotAMultipleOfFive": {
+			inventory: CoinInventory{5: 100},
+			amount:    12,
+			change:    nil,
+			ok:        false,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			change, ok := tc.inventory.MakeChange(tc.amount)
+
+			if ok != tc.ok {
+				t.Errorf("want ok %v; got %v", tc.ok, ok)
+			}
+
+			if !cmp.Equal(tc.change, change) {
+				t.Errorf("want %v; got %v", tc.change, change)
+			}
+		})
+	}
+}
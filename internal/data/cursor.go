@@ -0,0 +1,82 @@
+package data
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("models: invalid cursor")
+
+// Cursor marks a position in a keyset paginated listing: the sort it was
+// issued for, the sort key and id of the row at the edge of the page, and
+// whether it points before or after that row.
+type Cursor struct {
+	Sort   string `json:"s"`
+	Value  string `json:"v"`
+	ID     int64  `json:"id"`
+	Before bool   `json:"b,omitempty"`
+}
+
+// CursorMetadata describes a page fetched by cursor. An empty cursor means
+// there is no page in that direction.
+type CursorMetadata struct {
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// IsStart reports whether the cursor is the zero cursor, i.e. the first page.
+func (c Cursor) IsStart() bool {
+	return c.ID == 0
+}
+
+// EncodeCursor serialises c and signs it with secret so clients can not forge
+// or alter it.
+func EncodeCursor(secret []byte, c Cursor) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signCursor(secret, encoded)), nil
+}
+
+// DecodeCursor verifies and parses a cursor produced by EncodeCursor. Anything
+// that was not signed with secret gives ErrInvalidCursor.
+func DecodeCursor(secret []byte, token string) (Cursor, error) {
+	var c Cursor
+
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return c, ErrInvalidCursor
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, signCursor(secret, encoded)) {
+		return c, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if err = json.Unmarshal(payload, &c); err != nil || c.ID < 1 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+func signCursor(secret []byte, encoded string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded)) //nolint
+
+	return mac.Sum(nil)
+}
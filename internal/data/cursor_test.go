@@ -0,0 +1,51 @@
+package data
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCursor(t *testing.T) {
+
+	secret := []byte("secret")
+	cursor := Cursor{Sort: "-cost", Value: "150", ID: 42, Before: true}
+
+	token, err := EncodeCursor(secret, cursor)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	testCases := map[string]struct {
+		secret []byte
+		token  string
+		want   Cursor
+		err    error
+	}{
+		"RoundTrip":   {secret: secret, token: token, want: cursor},
+		"WrongSecret": {secret: []byte("other"), token: token, err: ErrInvalidCursor},
+		"TamperedPayload": {
+			secret: secret,
+			token:  strings.Replace(token, token[:4], "eyJ0", 1),
+			err:    ErrInvalidCursor,
+		},
+		"Unsigned": {secret: secret, token: strings.Split(token, ".")[0], err: ErrInvalidCursor},
+		"Garbage":  {secret: secret, token: "not-a-cursor", err: ErrInvalidCursor},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got, err := DecodeCursor(tc.secret, tc.token)
+
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("want error %v; got %v", tc.err, err)
+			}
+
+			if !cmp.Equal(tc.want, got) {
+				t.Errorf("want %+v; got %+v", tc.want, got)
+			}
+		})
+	}
+}
@@ -8,12 +8,30 @@ var (
 	ErrRecordNotFound       = errors.New("models: record not found")
 	ErrDuplicateUsername    = errors.New("models: duplicate username")
 	ErrInvalidCredentials   = errors.New("models: invalid credentials")
+	ErrAccountSuspended     = errors.New("models: account suspended")
 	ErrNoPermission         = errors.New("models: no permission")
 	ErrDuplicateProductName = errors.New("models: you have created a product with the same name")
+	ErrEditConflict         = errors.New("models: edit conflict")
+	ErrDuplicateCategory    = errors.New("models: a category with this slug already exists")
+	ErrCategoryInUse        = errors.New("models: category still has sub categories")
+	ErrDuplicateMachine     = errors.New("models: a machine with this name already exists")
+	ErrMachineStock         = errors.New("models: not enough units stocked in the machine")
+	ErrDuplicatePermission  = errors.New("models: a permission with this code already exists")
+	ErrDuplicateRole        = errors.New("models: a role with this name already exists")
+	ErrRoleInUse            = errors.New("models: role is still held by users")
+
+	ErrDuplicateIdempotencyKey  = errors.New("models: duplicate idempotency key")
+	ErrIdempotencyKeyMismatch   = errors.New("models: idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("models: a request with this idempotency key is still being processed")
+
+	ErrDuplicateRefund = errors.New("models: a refund has already been requested for this purchase")
+
+	ErrTokenReused = errors.New("models: refresh token was already used")
 )
 
 const (
 	TokenScopeAuthentication = "authentication"
+	TokenScopeRefresh        = "refresh"
 )
 
 type ValidationErrors map[string]string
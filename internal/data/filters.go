@@ -12,6 +12,9 @@ type Filters struct {
 	PageSize     int
 	Sort         string
 	SortSafeList []string
+	// SortColumns maps a sort value to its column when the two are named
+	// differently, e.g. "amount_available" sorts on "quantity".
+	SortColumns map[string]string
 }
 
 type Metadata struct {
@@ -36,7 +39,12 @@ func (f Filters) ValidateFilters(v *validator.Validator) {
 func (f Filters) SortColumn() string {
 	for _, safeValue := range f.SortSafeList {
 		if f.Sort == safeValue {
-			return strings.TrimPrefix(f.Sort, "-")
+			column := strings.TrimPrefix(f.Sort, "-")
+			if mapped, ok := f.SortColumns[column]; ok {
+				return mapped
+			}
+
+			return column
 		}
 	}
 
@@ -0,0 +1,26 @@
+package data
+
+import (
+	"time"
+)
+
+// IdempotencyKey is a client supplied key for a mutating request. StatusCode,
+// Response and Headers stay empty while the first request holding the key is
+// in flight. Headers only keeps the response headers worth replaying, see
+// IdempotentHeaders.
+type IdempotencyKey struct {
+	UserID      int64
+	Key         string
+	Fingerprint []byte
+	StatusCode  int
+	Response    []byte
+	Headers     map[string][]string
+	CreatedAt   time.Time
+}
+
+// IdempotentHeaders are the response headers stored with a key and replayed.
+var IdempotentHeaders = []string{"Location", "ETag"}
+
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
@@ -0,0 +1,28 @@
+package data
+
+import (
+	"time"
+)
+
+const (
+	LedgerEntryOpeningBalance = "opening_balance"
+	LedgerEntryDeposit        = "deposit"
+	LedgerEntryPurchase       = "purchase"
+	LedgerEntryChange         = "change"
+	LedgerEntryReset          = "reset" // old entries only, resets pay out change now
+	LedgerEntryRefund         = "refund"
+	LedgerEntryAdjustment     = "adjustment"
+)
+
+// LedgerEntry is a signed movement on a user's balance. users.deposit is always
+// the sum of the user's entries. MachineID is 0 for entries written before
+// machines were tracked.
+type LedgerEntry struct {
+	ID           int64
+	UserID       int64
+	MachineID    int64
+	Kind         string
+	Amount       int
+	BalanceAfter int
+	CreatedAt    time.Time
+}
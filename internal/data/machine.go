@@ -0,0 +1,35 @@
+package data
+
+import (
+	"time"
+
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// DefaultMachineID is the machine the service modelled before it tracked a
+// fleet. Routes that are not scoped to a machine act on it.
+const DefaultMachineID int64 = 1
+
+type Machine struct {
+	ID        int64
+	Name      string
+	Location  string
+	CreatedAt time.Time
+}
+
+// MachineStock is how many units of a product are loaded into one machine.
+// The product's AmountAvailable is the sum over all machines.
+type MachineStock struct {
+	MachineID   int64
+	MachineName string
+	ProductID   int64
+	ProductName string
+	ProductCost int
+	Quantity    int
+}
+
+func (m *Machine) Validate(v *validator.Validator) {
+	v.Check(m.Name != "", "name", "must be provided")
+	v.Check(len(m.Name) <= 100, "name", "must not be more than 100 bytes long")
+	v.Check(len(m.Location) <= 200, "location", "must not be more than 200 bytes long")
+}
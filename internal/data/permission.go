@@ -1,11 +1,48 @@
 package data
 
+import (
+	"regexp"
+	"time"
+
+	"github.com/terdia/mvp/pkg/validator"
+)
+
 const (
-	PermissionProductsRead  = "products:read"
-	PermissionProductsWrite = "products:write"
-	PermissionProductsBuy   = "products:buy"
+	PermissionProductsRead     = "products:read"
+	PermissionProductsWrite    = "products:write"
+	PermissionProductsBuy      = "products:buy"
+	PermissionRefundsManage    = "refunds:manage"
+	PermissionCategoriesWrite  = "categories:write"
+	PermissionSlotsWrite       = "slots:write"
+	PermissionMachinesWrite    = "machines:write"
+	PermissionUsersRead        = "users:read"
+	PermissionUsersWrite       = "users:write"
+	PermissionDepositsAdjust   = "deposits:adjust"
+	PermissionPermissionsWrite = "permissions:write"
+	PermissionMetricsRead      = "metrics:read"
+)
+
+var (
+	PermissionCodeRX = regexp.MustCompile(`^[a-z][a-z0-9-]*:[a-z][a-z0-9-]*$`)
 )
 
+// BuiltinPermissions are checked by the API itself, removing one would leave
+// routes nobody can use.
+var BuiltinPermissions = Permissions{
+	PermissionProductsRead,
+	PermissionProductsWrite,
+	PermissionProductsBuy,
+	PermissionRefundsManage,
+	PermissionCategoriesWrite,
+	PermissionSlotsWrite,
+	PermissionMachinesWrite,
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionDepositsAdjust,
+	PermissionPermissionsWrite,
+	PermissionMetricsRead,
+}
+
 type Permissions []string
 
 func (p Permissions) Includes(code string) bool {
@@ -18,3 +55,73 @@ func (p Permissions) Includes(code string) bool {
 
 	return false
 }
+
+// Permission is a code a route can be gated on, e.g. products:write.
+type Permission struct {
+	ID          int64
+	Code        string
+	Description string
+	CreatedAt   time.Time
+}
+
+func (p *Permission) Validate(v *validator.Validator) {
+	ValidatePermissionCode(v, "code", p.Code)
+	v.Check(len(p.Description) <= 500, "description", "must not be more than 500 bytes long")
+}
+
+// Role is a named bundle of permissions. Every user holds the permissions of
+// their role on top of the ones granted to them directly, so changing a role's
+// permissions changes them for all of its users.
+type Role struct {
+	ID          int64
+	Name        string
+	Permissions Permissions
+	CreatedAt   time.Time
+}
+
+// UserPermissions is what a user may do, split by where it comes from.
+type UserPermissions struct {
+	UserID  int64
+	Role    *Role
+	Granted Permissions
+}
+
+// All returns the role's permissions and the granted ones.
+func (u *UserPermissions) All() Permissions {
+	all := append(Permissions{}, u.Role.Permissions...)
+	for _, code := range u.Granted {
+		if !all.Includes(code) {
+			all = append(all, code)
+		}
+	}
+
+	return all
+}
+
+func IsBuiltinRole(name string) bool {
+	return validator.In(name, Roles)
+}
+
+func (r *Role) Validate(v *validator.Validator) {
+	v.Check(r.Name != "", "name", "must be provided")
+	v.Check(len(r.Name) <= 15, "name", "must not be more than 15 bytes long")
+	v.Check(validator.Matches(r.Name, SlugRX), "name", "must only contain lowercase letters, digits and dashes")
+	v.Check(r.Name != roleMachine, "name", "is reserved")
+
+	ValidatePermissionCodes(v, r.Permissions)
+}
+
+func ValidatePermissionCodes(v *validator.Validator, codes []string) {
+	v.Check(len(codes) <= 100, "permissions", "must not contain more than 100 permissions")
+	v.Check(validator.Unique(codes), "permissions", "must not contain duplicate values")
+
+	for _, code := range codes {
+		ValidatePermissionCode(v, "permissions", code)
+	}
+}
+
+func ValidatePermissionCode(v *validator.Validator, key, code string) {
+	v.Check(code != "", key, "must be provided")
+	v.Check(len(code) <= 100, key, "must not be more than 100 bytes long")
+	v.Check(validator.Matches(code, PermissionCodeRX), key, "must look like resource:action, e.g. products:read")
+}
@@ -1,6 +1,7 @@
 package data
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/terdia/mvp/pkg/validator"
@@ -21,6 +22,32 @@ type Product struct {
 	Seller          User
 	CreatedAt       time.Time
 	AmountAvailable int
+	Version         int32
+	DeletedAt       *time.Time
+	Categories      []Category
+	Tags            []string
+}
+
+// SortKey returns the value of the given sort column as it is stored in a
+// cursor.
+func (p *Product) SortKey(column string) string {
+	switch column {
+	case "name":
+		return p.Name
+	case "cost":
+		return strconv.Itoa(p.Cost)
+	case "quantity":
+		return strconv.Itoa(p.AmountAvailable)
+	case "created_at":
+		return p.CreatedAt.Format(time.RFC3339Nano)
+	default:
+		return strconv.FormatInt(p.ID, 10)
+	}
+}
+
+// IsArchived reports whether the product has been soft deleted.
+func (p *Product) IsArchived() bool {
+	return p.DeletedAt != nil
 }
 
 func (p *Product) Validate(v *validator.Validator) {
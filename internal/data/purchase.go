@@ -0,0 +1,25 @@
+package data
+
+import (
+	"time"
+)
+
+// Purchase is the receipt of a completed purchase. The product name and cost
+// are copied at the time of sale so the receipt survives later edits; ProductID
+// is 0 once the product itself has been removed. SlotCode is empty for
+// products that were not loaded into a slot. Change holds the coins the machine
+// paid out; a checkout keeps them on its last purchase.
+type Purchase struct {
+	ID          int64
+	BuyerID     int64
+	SellerID    int64
+	MachineID   int64
+	ProductID   int64
+	ProductName string
+	ProductCost int
+	Quantity    int
+	AmountSpent int
+	Change      []int
+	SlotCode    string
+	CreatedAt   time.Time
+}
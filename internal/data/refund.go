@@ -0,0 +1,71 @@
+package data
+
+import (
+	"time"
+
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+const (
+	RefundRequested = "requested"
+	RefundApproved  = "approved"
+	RefundRejected  = "rejected"
+	RefundRefunded  = "refunded"
+)
+
+var RefundStatuses = []string{RefundRequested, RefundApproved, RefundRejected, RefundRefunded}
+
+// refundTransitions lists the states a refund may move to from each state.
+// Rejected and refunded are final.
+var refundTransitions = map[string][]string{
+	RefundRequested: {RefundApproved, RefundRejected},
+	RefundApproved:  {RefundRefunded},
+}
+
+// Refund asks for a purchase to be reversed. The whole purchase is refunded:
+// Quantity goes back into stock and Amount back onto the buyer's deposit.
+type Refund struct {
+	ID         int64
+	PurchaseID int64
+	BuyerID    int64
+	SellerID   int64
+	ProductID  int64
+	Quantity   int
+	Amount     int
+	Reason     string
+	Status     string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// RefundEvent records one state change of a refund and who made it. The first
+// event of a refund has an empty FromStatus.
+type RefundEvent struct {
+	ID         int64
+	RefundID   int64
+	FromStatus string
+	ToStatus   string
+	ActorID    int64
+	Note       string
+	CreatedAt  time.Time
+}
+
+// RefundFilter narrows a refund listing. A zero UserID lists every refund,
+// otherwise only refunds the user is the buyer or seller of.
+type RefundFilter struct {
+	UserID int64
+	Status string
+}
+
+func (r *Refund) CanTransition(to string) bool {
+	return validator.In(to, refundTransitions[r.Status])
+}
+
+func ValidateRefundReason(v *validator.Validator, reason string) {
+	v.Check(reason != "", "reason", "must be provided")
+	v.Check(len(reason) <= 500, "reason", "must not be more than 500 bytes long")
+}
+
+func ValidateRefundNote(v *validator.Validator, note string) {
+	v.Check(len(note) <= 500, "note", "must not be more than 500 bytes long")
+}
@@ -0,0 +1,38 @@
+package data
+
+import (
+	"time"
+)
+
+const (
+	ReportPeriodDay   = "day"
+	ReportPeriodWeek  = "week"
+	ReportPeriodMonth = "month"
+)
+
+var ReportPeriods = []string{ReportPeriodDay, ReportPeriodWeek, ReportPeriodMonth}
+
+// ProductSales is how one of a seller's products has sold so far, net of
+// refunds. ProductID is 0 for products that have been purged, AmountAvailable
+// is 0 for archived ones since they are no longer on sale.
+type ProductSales struct {
+	ProductID       int64
+	Name            string
+	UnitsSold       int
+	Revenue         int
+	AmountAvailable int
+}
+
+// PeriodRevenue is a seller's takings for the day, week or month starting at Period.
+type PeriodRevenue struct {
+	Period    time.Time
+	UnitsSold int
+	Revenue   int
+}
+
+// ReportRange limits a report to purchases made in [From, To). A zero bound
+// is open.
+type ReportRange struct {
+	From time.Time
+	To   time.Time
+}
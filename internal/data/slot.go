@@ -0,0 +1,35 @@
+package data
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+var SlotCodeRX = regexp.MustCompile(`^[A-F][1-8]$`)
+
+// Slot is a spiral in a machine, A1 to F8. It holds units of at most one
+// product; ProductID is 0 for an empty slot. Quantity counts the units loaded
+// and never exceeds Capacity.
+type Slot struct {
+	ID        int64
+	MachineID int64
+	Code      string
+	Capacity  int
+	ProductID int64
+	Quantity  int
+	UpdatedAt time.Time
+}
+
+func ValidateSlotCode(v *validator.Validator, key, code string) {
+	v.Check(validator.Matches(code, SlotCodeRX), key, "must be a slot code from A1 to F8")
+}
+
+func (s *Slot) Validate(v *validator.Validator) {
+	v.Check(s.Capacity > 0, "capacity", "must be greater than zero")
+	v.Check(s.Capacity <= 50, "capacity", "must not be more than 50")
+	v.Check(s.Quantity >= 0, "quantity", "must not be negative")
+	v.Check(s.Quantity <= s.Capacity, "quantity", "must not be more than the slot capacity")
+	v.Check(s.ProductID > 0 || s.Quantity == 0, "quantity", "an empty slot can not hold units")
+}
@@ -4,10 +4,45 @@ import (
 	"time"
 )
 
+// TokenSeenInterval is how stale a token's LastSeenAt, or an API key's
+// LastUsedAt, may get before a request with it records it again; a request with
+// a token from another address always does.
+const TokenSeenInterval = 5 * time.Minute
+
+// Token is a session of a user. LastSeenIP and LastSeenAt record where and
+// when the token's family last authenticated a request, to within
+// TokenSeenInterval; they are empty until then. Tokens issued by one login, and
+// by refreshing it, share a FamilyID. RotatedAt is set on refresh tokens that
+// have been exchanged for new ones.
 type Token struct {
-	Plaintext string
-	Hash      []byte
-	UserId    int64
-	Expiry    time.Time
-	Scope     string
+	ID         int64
+	FamilyID   int64
+	Plaintext  string
+	Hash       []byte
+	UserId     int64
+	Expiry     time.Time
+	Scope      string
+	CreatedAt  time.Time
+	LastSeenIP string
+	LastSeenAt *time.Time
+	RotatedAt  *time.Time
+}
+
+// Session is a login that can still be used: a token family whose latest
+// refresh token has not expired. CreatedAt is when the oldest token left in the
+// family was issued. Current marks the session a request was made with.
+type Session struct {
+	FamilyID   int64
+	CreatedAt  time.Time
+	Expiry     time.Time
+	LastSeenIP string
+	LastSeenAt *time.Time
+	Current    bool
+}
+
+// TokenPair is a short lived access token together with the refresh token that
+// replaces it once it expires.
+type TokenPair struct {
+	Access  *Token
+	Refresh *Token
 }
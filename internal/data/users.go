@@ -2,6 +2,8 @@ package data
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -12,35 +14,78 @@ import (
 const (
 	roleSeller = "seller"
 	roleBuyer  = "buyer"
+	roleAdmin  = "admin"
+
+	// roleMachine is held by principals authenticated with an APIKey.
+	roleMachine = "machine"
+
+	// DeletedUsernamePrefix followed by the id replaces the username of a
+	// deleted account; it can not be registered.
+	DeletedUsernamePrefix = "deleted-user-"
+
+	// MaxDeposit is the largest balance a buyer can hold, in cents. Paying
+	// it out as change must stay cheap, so neither coin deposits nor admin
+	// adjustments may go beyond it.
+	MaxDeposit = 10000
 )
 
 var AnonymousUser = &User{}
 
+// Roles are the built in roles. The API relies on them, so they can not be
+// removed, only their permissions changed. Registration is limited to buyers
+// and sellers.
+var Roles = []string{roleBuyer, roleSeller, roleAdmin}
+
+// User is an account. DepositMachineID is the machine holding the coins behind
+// a non zero Deposit; it is 0 while the deposit is empty. APIKey is only set on
+// machine principals, which have no account and an ID of 0. SuspendedAt is set
+// while an admin has suspended the account, DeletedAt once the owner deleted it.
 type User struct {
-	ID        int64
+	ID               int64
+	Role             string
+	Deposit          int
+	DepositMachineID int64
+	Username         string
+	Password         Password
+	CreatedAt        time.Time
+	SuspendedAt      *time.Time
+	DeletedAt        *time.Time
+	APIKey           *APIKey
+}
+
+// AccountExport is everything kept about a user, handed to them on request.
+type AccountExport struct {
+	User      *User
+	Tokens    []*Token
+	Purchases []*Purchase
+	Ledger    []*LedgerEntry
+}
+
+// UserFilter narrows the admin user list. Query matches part of the username.
+type UserFilter struct {
+	Query     string
 	Role      string
-	Deposit   int
-	Username  string
-	Password  Password
-	CreatedAt time.Time
+	Suspended *bool
 }
 
 func (u *User) IsAnonymous() bool {
 	return u == AnonymousUser
 }
 
-func (u *User) GetRolePermissions() []string {
-	permissions := []string{PermissionProductsRead}
+func (u *User) IsMachine() bool {
+	return u.APIKey != nil
+}
 
-	if u.Role == roleSeller {
-		permissions = append(permissions, PermissionProductsWrite)
-	}
+func (u *User) IsBuyer() bool {
+	return u.Role == roleBuyer
+}
 
-	if u.Role == roleBuyer {
-		permissions = append(permissions, PermissionProductsBuy)
-	}
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
 
-	return permissions
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
 }
 
 type Password struct {
@@ -72,11 +117,10 @@ func (p *Password) Matches(plaintextPassword string) (bool, error) {
 }
 
 func (u *User) Validate(v *validator.Validator) {
-	v.Check(u.Username != "", "username", "must be provided")
-	v.Check(len(u.Username) <= 500, "username", "must not be more than 500 bytes long")
+	ValidateUsername(v, u.Username)
 
 	if u.Password.Plaintext != nil {
-		validatePasswordPlaintext(v, *u.Password.Plaintext)
+		ValidatePasswordPlaintext(v, "password", *u.Password.Plaintext)
 	}
 
 	if u.Deposit != 0 {
@@ -94,10 +138,27 @@ func (u *User) Validate(v *validator.Validator) {
 	}
 }
 
-func validatePasswordPlaintext(v *validator.Validator, password string) {
-	v.Check(password != "", "password", "must be provided")
-	v.Check(len(password) >= 6, "password", "must be at least 6 bytes long")
-	v.Check(len(password) <= 72, "password", "must not be more than 72 bytes long")
+// ValidateDepositAdjustment checks a correction an admin makes to a buyer's
+// deposit. It must keep the deposit payable in coins and not below zero.
+func ValidateDepositAdjustment(v *validator.Validator, deposit, amount int, reason string) {
+	v.Check(amount != 0, "amount", "must not be zero")
+	v.Check(amount%CoinFiveCent == 0, "amount", "must be a multiple of 5")
+	v.Check(deposit+amount >= 0, "amount", "must not take the deposit below zero")
+	v.Check(deposit+amount <= MaxDeposit, "amount", fmt.Sprintf("must not take the deposit above %d", MaxDeposit))
+	v.Check(reason != "", "reason", "must be provided")
+	v.Check(len(reason) <= 500, "reason", "must not be more than 500 bytes long")
+}
+
+func ValidateUsername(v *validator.Validator, username string) {
+	v.Check(username != "", "username", "must be provided")
+	v.Check(len(username) <= 500, "username", "must not be more than 500 bytes long")
+	v.Check(!strings.HasPrefix(username, DeletedUsernamePrefix), "username", "is reserved")
+}
+
+func ValidatePasswordPlaintext(v *validator.Validator, key, password string) {
+	v.Check(password != "", key, "must be provided")
+	v.Check(len(password) >= 6, key, "must be at least 6 bytes long")
+	v.Check(len(password) <= 72, key, "must not be more than 72 bytes long")
 }
 
 func ValidateDeposit(v *validator.Validator, amount int) {
@@ -0,0 +1,35 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+func TestValidateDepositAdjustment(t *testing.T) {
+
+	testCases := map[string]struct {
+		deposit int
+		amount  int
+		valid   bool
+	}{
+		"Credit":         {deposit: 100, amount: 50, valid: true},
+		"Debit":          {deposit: 100, amount: -100, valid: true},
+		"UpToMaximum":    {deposit: 100, amount: MaxDeposit - 100, valid: true},
+		"AboveMaximum":   {deposit: 100, amount: MaxDeposit - 95, valid: false},
+		"Huge":           {deposit: 0, amount: 1_000_000_000, valid: false},
+		"BelowZero":      {deposit: 100, amount: -105, valid: false},
+		"NotMultipleOf5": {deposit: 100, amount: 7, valid: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			v := validator.New()
+			ValidateDepositAdjustment(v, tc.deposit, tc.amount, "correction")
+
+			if v.Valid() != tc.valid {
+				t.Errorf("want valid %t; got %t (%v)", tc.valid, v.Valid(), v.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package repositoryapikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+const apiKeyColumns = `id, machine_id, name, prefix, hash, scopes, expiry, last_used_at, created_at`
+
+type apiKeyRepository struct {
+	DB repository.DBTX
+}
+
+func NewAPIKeyRepository(db repository.DBTX) repository.APIKeyRepository {
+	return &apiKeyRepository{DB: db}
+}
+
+func (repo *apiKeyRepository) Insert(key *data.APIKey) error {
+	query := `
+		INSERT INTO api_keys (machine_id, name, prefix, hash, scopes, expiry)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	args := []interface{}{key.MachineID, key.Name, key.Prefix, key.Hash, pq.Array([]string(key.Scopes)), key.Expiry}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	return repo.DB.QueryRowContext(ctx, query, args...).Scan(&key.ID, &key.CreatedAt)
+}
+
+func (repo *apiKeyRepository) GetAllForMachine(machineID int64) ([]*data.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE machine_id = $1 ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, machineID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := []*data.APIKey{}
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// GetForKey looks an unexpired key up by the hash of its plaintext and records
+// that it was used. The row is only written when it was last used more than
+// data.TokenSeenInterval ago, so busy machines do not write on every request;
+// the returned LastUsedAt is the one from before.
+func (repo *apiKeyRepository) GetForKey(keyPlainText string) (*data.APIKey, error) {
+
+	hash := sha256.Sum256([]byte(keyPlainText))
+
+	query := `
+		WITH key AS (
+			SELECT ` + apiKeyColumns + ` FROM api_keys
+			WHERE hash = $1 AND (expiry IS NULL OR expiry > NOW())
+		), used AS (
+			UPDATE api_keys SET last_used_at = NOW()
+			WHERE id = (SELECT id FROM key) AND (last_used_at IS NULL OR last_used_at < $2)
+		)
+		SELECT ` + apiKeyColumns + ` FROM key`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	staleBefore := time.Now().Add(-data.TokenSeenInterval)
+
+	key, err := scanAPIKey(repo.DB.QueryRowContext(ctx, query, hash[:], staleBefore))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+func (repo *apiKeyRepository) Delete(machineID, id int64) error {
+
+	if id < 1 {
+		return data.ErrRecordNotFound
+	}
+
+	query := `DELETE FROM api_keys WHERE id = $1 AND machine_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, id, machineID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row scanner) (*data.APIKey, error) {
+	var (
+		key    data.APIKey
+		scopes []string
+	)
+
+	err := row.Scan(
+		&key.ID,
+		&key.MachineID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		pq.Array(&scopes),
+		&key.Expiry,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Scopes = scopes
+
+	return &key, nil
+}
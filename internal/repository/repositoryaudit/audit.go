@@ -0,0 +1,103 @@
+package repositoryaudit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+type auditRepository struct {
+	DB repository.DBTX
+}
+
+func NewAuditRepository(db repository.DBTX) repository.AuditRepository {
+	return &auditRepository{DB: db}
+}
+
+func (repo *auditRepository) Insert(entry *data.AuditEntry) error {
+	if entry.Details == nil {
+		entry.Details = map[string]interface{}{}
+	}
+
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_user_id, reason, details)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5::jsonb)
+		RETURNING id, created_at`
+
+	args := []interface{}{entry.ActorID, entry.Action, entry.TargetUserID, entry.Reason, string(details)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	return repo.DB.QueryRowContext(ctx, query, args...).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+func (repo *auditRepository) GetAll(filter data.AuditFilter, filters data.Filters) ([]*data.AuditEntry, data.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, actor_id, action, COALESCE(target_user_id, 0), reason, details, created_at
+		FROM audit_log
+		WHERE ($1 = 0 OR actor_id = $1)
+		AND ($2 = 0 OR target_user_id = $2)
+		AND ($3 = '' OR action = $3)
+		ORDER BY %s %s, id ASC
+		LIMIT $4 OFFSET $5`, filters.SortColumn(), filters.SortDirection(),
+	)
+
+	args := []interface{}{filter.ActorID, filter.TargetUserID, filter.Action, filters.Limit(), filters.Offset()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	var entries []*data.AuditEntry
+
+	for rows.Next() {
+		var (
+			entry   data.AuditEntry
+			details []byte
+		)
+
+		err = rows.Scan(
+			&totalRecords,
+			&entry.ID,
+			&entry.ActorID,
+			&entry.Action,
+			&entry.TargetUserID,
+			&entry.Reason,
+			&details,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, data.Metadata{}, err
+		}
+
+		if err = json.Unmarshal(details, &entry.Details); err != nil {
+			return nil, data.Metadata{}, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	metadata := data.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return entries, metadata, nil
+}
@@ -0,0 +1,213 @@
+package repositorycategory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+type categoryRepository struct {
+	DB repository.DBTX
+}
+
+func NewCategoryRepository(db repository.DBTX) repository.CategoryRepository {
+	return &categoryRepository{DB: db}
+}
+
+func (repo *categoryRepository) Insert(category *data.Category) error {
+	query := `
+		INSERT INTO categories (name, slug, parent_id)
+		VALUES ($1, $2, NULLIF($3, 0))
+		RETURNING id, created_at`
+
+	args := []interface{}{category.Name, category.Slug, category.ParentID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(&category.ID, &category.CreatedAt)
+	if err != nil {
+		return categoryError(err)
+	}
+
+	return nil
+}
+
+func (repo *categoryRepository) Get(id int64) (*data.Category, error) {
+
+	if id < 1 {
+		return nil, data.ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, name, slug, COALESCE(parent_id, 0), created_at
+		FROM categories
+		WHERE id = $1`
+
+	var category data.Category
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, id).Scan(
+		&category.ID,
+		&category.Name,
+		&category.Slug,
+		&category.ParentID,
+		&category.CreatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &category, nil
+}
+
+// GetAll returns every category ordered so parents come before their children.
+func (repo *categoryRepository) GetAll() ([]*data.Category, error) {
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT id, name, slug, parent_id, created_at, ARRAY[name::text] AS path
+			FROM categories
+			WHERE parent_id IS NULL
+			UNION ALL
+			SELECT c.id, c.name, c.slug, c.parent_id, c.created_at, tree.path || c.name::text
+			FROM categories c
+			INNER JOIN tree ON c.parent_id = tree.id
+		)
+		SELECT id, name, slug, COALESCE(parent_id, 0), created_at
+		FROM tree
+		ORDER BY path`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var categories []*data.Category
+
+	for rows.Next() {
+		var category data.Category
+
+		err = rows.Scan(
+			&category.ID,
+			&category.Name,
+			&category.Slug,
+			&category.ParentID,
+			&category.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		categories = append(categories, &category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (repo *categoryRepository) Update(category *data.Category) error {
+	query := `
+		UPDATE categories SET name = $1, slug = $2, parent_id = NULLIF($3, 0)
+		WHERE id = $4`
+
+	args := []interface{}{category.Name, category.Slug, category.ParentID, category.ID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return categoryError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete removes the category and unlinks its products. Categories that still
+// have sub categories give data.ErrCategoryInUse.
+func (repo *categoryRepository) Delete(id int64) error {
+	if id < 1 {
+		return data.ErrRecordNotFound
+	}
+
+	query := `DELETE FROM categories WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return categoryError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// IsDescendant reports whether category id sits somewhere below ancestorID.
+func (repo *categoryRepository) IsDescendant(id, ancestorID int64) (bool, error) {
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE parent_id = $2
+			UNION ALL
+			SELECT c.id FROM categories c INNER JOIN tree ON c.parent_id = tree.id
+		)
+		SELECT EXISTS (SELECT 1 FROM tree WHERE id = $1)`
+
+	var exists bool
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, id, ancestorID).Scan(&exists)
+
+	return exists, err
+}
+
+func categoryError(err error) error {
+	switch {
+	case err.Error() == `pq: duplicate key value violates unique constraint "categories_slug_key"`:
+		return data.ErrDuplicateCategory
+	case err.Error() == `pq: update or delete on table "categories" violates foreign key constraint "categories_parent_id_fkey" on table "categories"`:
+		return data.ErrCategoryInUse
+	default:
+		return err
+	}
+}
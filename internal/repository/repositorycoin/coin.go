@@ -0,0 +1,85 @@
+package repositorycoin
+
+import (
+	"context"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+type coinRepository struct {
+	DB repository.DBTX
+}
+
+func NewCoinRepository(db repository.DBTX) repository.CoinRepository {
+	return &coinRepository{DB: db}
+}
+
+func (repo *coinRepository) GetInventory(machineID int64) (data.CoinInventory, error) {
+	return repo.getInventory(`SELECT coin, quantity FROM coin_inventory WHERE machine_id = $1 ORDER BY coin`, machineID)
+}
+
+// GetInventoryForUpdate locks the machine's whole coin float until the
+// surrounding transaction ends, so change is never promised from coins another
+// purchase is about to pay out.
+func (repo *coinRepository) GetInventoryForUpdate(machineID int64) (data.CoinInventory, error) {
+	return repo.getInventory(`SELECT coin, quantity FROM coin_inventory WHERE machine_id = $1 ORDER BY coin FOR UPDATE`, machineID)
+}
+
+func (repo *coinRepository) getInventory(query string, machineID int64) (data.CoinInventory, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, machineID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	inventory := make(data.CoinInventory)
+
+	for rows.Next() {
+		var coin, quantity int
+
+		if err = rows.Scan(&coin, &quantity); err != nil {
+			return nil, err
+		}
+
+		inventory[coin] = quantity
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return inventory, nil
+}
+
+// Adjust adds delta coins of the given value to the machine's float, a
+// negative delta removes them.
+func (repo *coinRepository) Adjust(machineID int64, coin, delta int) error {
+	query := `
+		UPDATE coin_inventory SET quantity = quantity + $1
+		WHERE machine_id = $2 AND coin = $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, delta, machineID, coin)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
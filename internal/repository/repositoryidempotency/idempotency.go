@@ -0,0 +1,132 @@
+package repositoryidempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+type idempotencyRepository struct {
+	DB repository.DBTX
+}
+
+func NewIdempotencyRepository(db repository.DBTX) repository.IdempotencyRepository {
+	return &idempotencyRepository{DB: db}
+}
+
+// Insert claims the key for the user. It returns data.ErrDuplicateIdempotencyKey
+// when another request already holds it.
+func (repo *idempotencyRepository) Insert(key *data.IdempotencyKey) error {
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, fingerprint)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, key) DO NOTHING
+		RETURNING created_at`
+
+	args := []interface{}{key.UserID, key.Key, key.Fingerprint}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(&key.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return data.ErrDuplicateIdempotencyKey
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *idempotencyRepository) Get(userID int64, key string) (*data.IdempotencyKey, error) {
+	query := `
+		SELECT user_id, key, fingerprint, COALESCE(status_code, 0), response, headers, created_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2`
+
+	var record data.IdempotencyKey
+	var headers []byte
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, userID, key).Scan(
+		&record.UserID,
+		&record.Key,
+		&record.Fingerprint,
+		&record.StatusCode,
+		&record.Response,
+		&headers,
+		&record.CreatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if err = json.Unmarshal(headers, &record.Headers); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (repo *idempotencyRepository) Update(key *data.IdempotencyKey) error {
+	query := `
+		UPDATE idempotency_keys SET status_code = $1, response = $2, headers = $3
+		WHERE user_id = $4 AND key = $5`
+
+	headers, err := json.Marshal(key.Headers)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{key.StatusCode, key.Response, headers, key.UserID, key.Key}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	_, err = repo.DB.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+func (repo *idempotencyRepository) Delete(userID int64, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repo.DB.ExecContext(ctx, query, userID, key)
+
+	return err
+}
+
+// DeleteCreatedBefore deletes the keys claimed before the given time and
+// returns how many were deleted.
+func (repo *idempotencyRepository) DeleteCreatedBefore(before time.Time) (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE created_at < $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,92 @@
+package repositoryledger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+type ledgerRepository struct {
+	DB repository.DBTX
+}
+
+func NewLedgerRepository(db repository.DBTX) repository.LedgerRepository {
+	return &ledgerRepository{DB: db}
+}
+
+// Append moves the user's deposit by entry.Amount and records the entry in the
+// same statement, so the balance can never change without a matching entry.
+// The deposit is then held by entry.MachineID, or by no machine once it is 0.
+func (repo *ledgerRepository) Append(entry *data.LedgerEntry) error {
+	query := `
+		WITH balance AS (
+			UPDATE users SET deposit = deposit + $2,
+			deposit_machine_id = CASE WHEN deposit + $2 = 0 THEN NULL ELSE NULLIF($4, 0) END
+			WHERE id = $1
+			RETURNING deposit
+		)
+		INSERT INTO ledger_entries (user_id, amount, kind, balance_after, machine_id)
+		SELECT $1, $2, $3, deposit, NULLIF($4, 0) FROM balance
+		RETURNING id, balance_after, created_at`
+
+	args := []interface{}{entry.UserID, entry.Amount, entry.Kind, entry.MachineID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	return repo.DB.QueryRowContext(ctx, query, args...).Scan(&entry.ID, &entry.BalanceAfter, &entry.CreatedAt)
+}
+
+func (repo *ledgerRepository) GetAllForUser(userID int64, filters data.Filters) ([]*data.LedgerEntry, data.Metadata, error) {
+	query := fmt.Sprintf(`
+			SELECT count(*) OVER(), id, user_id, COALESCE(machine_id, 0), kind, amount, balance_after, created_at
+			FROM ledger_entries
+			WHERE user_id = $1
+			ORDER BY %s %s, id ASC
+			LIMIT $2 OFFSET $3`, filters.SortColumn(), filters.SortDirection(),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, userID, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	var entries []*data.LedgerEntry
+
+	for rows.Next() {
+		var entry data.LedgerEntry
+
+		err = rows.Scan(
+			&totalRecords,
+			&entry.ID,
+			&entry.UserID,
+			&entry.MachineID,
+			&entry.Kind,
+			&entry.Amount,
+			&entry.BalanceAfter,
+			&entry.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, data.Metadata{}, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	metadata := data.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return entries, metadata, nil
+}
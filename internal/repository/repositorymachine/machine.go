@@ -0,0 +1,264 @@
+package repositorymachine
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+type machineRepository struct {
+	DB repository.DBTX
+}
+
+func NewMachineRepository(db repository.DBTX) repository.MachineRepository {
+	return &machineRepository{DB: db}
+}
+
+// Insert adds the machine together with an empty coin float and its 48 empty
+// slots.
+func (repo *machineRepository) Insert(machine *data.Machine) error {
+	query := `
+		WITH machine AS (
+			INSERT INTO machines (name, location)
+			VALUES ($1, $2)
+			RETURNING id, created_at
+		), coins AS (
+			INSERT INTO coin_inventory (machine_id, coin)
+			SELECT machine.id, coin FROM machine, unnest($3::integer[]) AS coin
+		), slots AS (
+			INSERT INTO slots (machine_id, code)
+			SELECT machine.id, chr(64 + r) || c
+			FROM machine, generate_series(1, 6) AS r, generate_series(1, 8) AS c
+		)
+		SELECT id, created_at FROM machine`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	coins := make([]int64, len(data.Coins))
+	for i, coin := range data.Coins {
+		coins[i] = int64(coin)
+	}
+
+	err := repo.DB.QueryRowContext(ctx, query, machine.Name, machine.Location, pq.Array(coins)).Scan(&machine.ID, &machine.CreatedAt)
+	if err != nil {
+		return machineError(err)
+	}
+
+	return nil
+}
+
+func (repo *machineRepository) Get(id int64) (*data.Machine, error) {
+
+	if id < 1 {
+		return nil, data.ErrRecordNotFound
+	}
+
+	query := `SELECT id, name, location, created_at FROM machines WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	var machine data.Machine
+
+	err := repo.DB.QueryRowContext(ctx, query, id).Scan(&machine.ID, &machine.Name, &machine.Location, &machine.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &machine, nil
+}
+
+func (repo *machineRepository) GetAll() ([]*data.Machine, error) {
+	query := `SELECT id, name, location, created_at FROM machines ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	machines := []*data.Machine{}
+
+	for rows.Next() {
+		var machine data.Machine
+
+		if err = rows.Scan(&machine.ID, &machine.Name, &machine.Location, &machine.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		machines = append(machines, &machine)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return machines, nil
+}
+
+func (repo *machineRepository) Update(machine *data.Machine) error {
+	query := `UPDATE machines SET name = $1, location = $2 WHERE id = $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, machine.Name, machine.Location, machine.ID)
+	if err != nil {
+		return machineError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetStock lists the products loaded into the machine, archived products
+// excluded.
+func (repo *machineRepository) GetStock(machineID int64) ([]*data.MachineStock, error) {
+	return repo.getStock(`
+		SELECT machines.id, machines.name, products.id, products.name, products.cost, machine_products.quantity
+		FROM machine_products
+		INNER JOIN machines ON machines.id = machine_products.machine_id
+		INNER JOIN products ON products.id = machine_products.product_id
+		WHERE machine_products.machine_id = $1 AND products.deleted_at IS NULL
+		ORDER BY products.name, products.id`, machineID)
+}
+
+// GetStockForProduct splits the product's stock by machine.
+func (repo *machineRepository) GetStockForProduct(productID int64) ([]*data.MachineStock, error) {
+	return repo.getStock(`
+		SELECT machines.id, machines.name, products.id, products.name, products.cost, machine_products.quantity
+		FROM machine_products
+		INNER JOIN machines ON machines.id = machine_products.machine_id
+		INNER JOIN products ON products.id = machine_products.product_id
+		WHERE machine_products.product_id = $1
+		ORDER BY machines.id`, productID)
+}
+
+// GetQuantityForUpdate returns how many units of the product the machine
+// holds, 0 when it was never stocked. Callers lock the product row first, which
+// serialises every change to its machine stock.
+func (repo *machineRepository) GetQuantityForUpdate(machineID, productID int64) (int, error) {
+	query := `
+		SELECT quantity FROM machine_products
+		WHERE machine_id = $1 AND product_id = $2
+		FOR UPDATE`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	var quantity int
+
+	err := repo.DB.QueryRowContext(ctx, query, machineID, productID).Scan(&quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, nil
+		default:
+			return 0, err
+		}
+	}
+
+	return quantity, nil
+}
+
+// AdjustStock moves the machine's stock of the product by delta and keeps the
+// product total in step, in one statement. Taking out more than the machine
+// holds returns data.ErrMachineStock.
+func (repo *machineRepository) AdjustStock(machineID, productID int64, delta int) error {
+	query := `
+		WITH stock AS (
+			INSERT INTO machine_products (machine_id, product_id, quantity)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (machine_id, product_id)
+			DO UPDATE SET quantity = machine_products.quantity + EXCLUDED.quantity
+			RETURNING product_id
+		)
+		UPDATE products SET quantity = quantity + $3
+		FROM stock
+		WHERE products.id = stock.product_id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, machineID, productID, delta)
+	if err != nil {
+		return machineError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (repo *machineRepository) getStock(query string, id int64) ([]*data.MachineStock, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	stock := []*data.MachineStock{}
+
+	for rows.Next() {
+		var s data.MachineStock
+
+		err = rows.Scan(&s.MachineID, &s.MachineName, &s.ProductID, &s.ProductName, &s.ProductCost, &s.Quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		stock = append(stock, &s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stock, nil
+}
+
+func machineError(err error) error {
+	switch {
+	case err.Error() == `pq: duplicate key value violates unique constraint "machines_name_key"`:
+		return data.ErrDuplicateMachine
+	case err.Error() == `pq: new row for relation "machine_products" violates check constraint "machine_products_quantity_check"`:
+		return data.ErrMachineStock
+	default:
+		return err
+	}
+}
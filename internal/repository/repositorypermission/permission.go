@@ -3,6 +3,7 @@ package repositorypermission
 import (
 	"context"
 	"database/sql"
+	"errors"
 
 	"github.com/lib/pq"
 
@@ -11,26 +12,178 @@ import (
 )
 
 type permissionRepository struct {
-	*sql.DB
+	DB repository.DBTX
 }
 
-func NewPermissionRepository(db *sql.DB) repository.PermissionRepository {
+func NewPermissionRepository(db repository.DBTX) repository.PermissionRepository {
 	return &permissionRepository{DB: db}
 }
 
+func (p *permissionRepository) Insert(permission *data.Permission) error {
+	query := `
+		INSERT INTO permissions (code, description)
+		VALUES ($1, $2)
+		RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := p.DB.QueryRowContext(ctx, query, permission.Code, permission.Description).Scan(
+		&permission.ID,
+		&permission.CreatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "permissions_code_key"`:
+			return data.ErrDuplicatePermission
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *permissionRepository) GetByCode(code string) (*data.Permission, error) {
+	query := `
+		SELECT id, code, description, created_at
+		FROM permissions
+		WHERE code = $1`
+
+	var permission data.Permission
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := p.DB.QueryRowContext(ctx, query, code).Scan(
+		&permission.ID,
+		&permission.Code,
+		&permission.Description,
+		&permission.CreatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &permission, nil
+}
+
+func (p *permissionRepository) GetAll() ([]*data.Permission, error) {
+	query := `
+		SELECT id, code, description, created_at
+		FROM permissions
+		ORDER BY code`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := p.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var permissions []*data.Permission
+
+	for rows.Next() {
+		var permission data.Permission
+
+		err = rows.Scan(
+			&permission.ID,
+			&permission.Code,
+			&permission.Description,
+			&permission.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, &permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// Delete removes the permission from every role and user holding it.
+func (p *permissionRepository) Delete(id int64) error {
+	if id < 1 {
+		return data.ErrRecordNotFound
+	}
+
+	query := `DELETE FROM permissions WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	result, err := p.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAllForUser returns what the user may do: the permissions of their role
+// and the ones granted to them directly.
 func (p *permissionRepository) GetAllForUser(userID int64) (data.Permissions, error) {
 
+	query := `
+			SELECT permissions.code
+			FROM permissions
+			INNER JOIN roles_permissions ON roles_permissions.permission_id = permissions.id
+			INNER JOIN roles ON roles_permissions.role_id = roles.id
+			INNER JOIN users ON users.role = roles.name
+			WHERE users.id = $1
+			UNION
+			SELECT permissions.code
+			FROM permissions
+			INNER JOIN users_permissions ON users_permissions.permission_id = permissions.id
+			WHERE users_permissions.user_id = $1`
+
+	return p.codes(query, userID)
+}
+
+// GetGrantedForUser returns only the permissions granted to the user directly.
+func (p *permissionRepository) GetGrantedForUser(userID int64) (data.Permissions, error) {
+
 	query := `
 			SELECT permissions.code
 			FROM permissions
 			INNER JOIN users_permissions ON users_permissions.permission_id = permissions.id
-			INNER JOIN users ON users_permissions.user_id = users.id
-			WHERE users.id = $1`
+			WHERE users_permissions.user_id = $1
+			ORDER BY permissions.code`
+
+	return p.codes(query, userID)
+}
+
+func (p *permissionRepository) codes(query string, args ...interface{}) (data.Permissions, error) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
 	defer cancel()
 
-	rows, err := p.DB.QueryContext(ctx, query, userID)
+	rows, err := p.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +213,23 @@ func (p *permissionRepository) GetAllForUser(userID int64) (data.Permissions, er
 func (p *permissionRepository) AddForUser(userID int64, codes ...string) error {
 	query := `
 		INSERT INTO users_permissions
-		SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)`
+		SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)
+		ON CONFLICT DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	_, err := p.DB.ExecContext(ctx, query, userID, pq.Array(codes))
+
+	return err
+}
+
+func (p *permissionRepository) RemoveForUser(userID int64, codes ...string) error {
+	query := `
+		DELETE FROM users_permissions
+		USING permissions
+		WHERE users_permissions.permission_id = permissions.id
+		AND users_permissions.user_id = $1 AND permissions.code = ANY($2)`
 
 	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
 	defer cancel()
@@ -5,6 +5,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/terdia/mvp/internal/data"
 	"github.com/terdia/mvp/internal/repository"
@@ -12,25 +16,34 @@ import (
 )
 
 type productRepository struct {
-	*sql.DB
+	DB repository.DBTX
 }
 
-func NewProductRepository(db *sql.DB) repository.ProductRepository {
-	return &productRepository{db}
+func NewProductRepository(db repository.DBTX) repository.ProductRepository {
+	return &productRepository{DB: db}
 }
 
+// Insert adds the product and loads its whole amount available into the
+// default machine.
 func (repo *productRepository) Insert(product *data.Product) error {
-	query := `INSERT INTO products (name, cost, quantity, seller_id)
-			 VALUES($1, $2, $3, $4)
-			 RETURNING id, name, cost, quantity, created_at`
-
-	queryParams := []interface{}{product.Name, product.Cost, product.AmountAvailable, product.Seller.ID}
+	query := `
+			WITH product AS (
+				INSERT INTO products (name, cost, quantity, seller_id)
+				VALUES($1, $2, $3, $4)
+				RETURNING id, name, cost, quantity, created_at, version
+			), stock AS (
+				INSERT INTO machine_products (machine_id, product_id, quantity)
+				SELECT $5, id, quantity FROM product
+			)
+			SELECT id, name, cost, quantity, created_at, version FROM product`
+
+	queryParams := []interface{}{product.Name, product.Cost, product.AmountAvailable, product.Seller.ID, data.DefaultMachineID}
 
 	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
 	defer cancel()
 
 	if err := repo.DB.QueryRowContext(ctx, query, queryParams...).Scan(
-		&product.ID, &product.Name, &product.Cost, &product.AmountAvailable, &product.CreatedAt,
+		&product.ID, &product.Name, &product.Cost, &product.AmountAvailable, &product.CreatedAt, &product.Version,
 	); err != nil {
 		switch {
 		case err.Error() == `pq: duplicate key value violates unique constraint "products_name_seller_id_key"`:
@@ -44,14 +57,30 @@ func (repo *productRepository) Insert(product *data.Product) error {
 }
 
 func (repo *productRepository) Get(id int64) (*data.Product, error) {
+	return repo.get(id, "AND deleted_at IS NULL")
+}
+
+// GetIncludingArchived also finds products that have been soft deleted.
+func (repo *productRepository) GetIncludingArchived(id int64) (*data.Product, error) {
+	return repo.get(id, "")
+}
+
+// GetForUpdate loads the product by id and locks the row until the surrounding
+// transaction ends. It is only meaningful on a repository bound to a UnitOfWork.
+func (repo *productRepository) GetForUpdate(id int64) (*data.Product, error) {
+	return repo.get(id, "AND deleted_at IS NULL FOR UPDATE")
+}
+
+func (repo *productRepository) get(id int64, clause string) (*data.Product, error) {
 
 	if id < 1 {
 		return nil, data.ErrRecordNotFound
 	}
 
-	query := `SELECT id, name, cost, quantity, seller_id, created_at
+	query := fmt.Sprintf(`SELECT id, name, cost, quantity, seller_id, created_at, version, deleted_at
 			  FROM products
-			  WHERE id = $1`
+			  WHERE id = $1 %s`, clause,
+	)
 
 	var product data.Product
 
@@ -65,6 +94,8 @@ func (repo *productRepository) Get(id int64) (*data.Product, error) {
 		&product.AmountAvailable,
 		&product.Seller.ID,
 		&product.CreatedAt,
+		&product.Version,
+		&product.DeletedAt,
 	)
 
 	if err != nil {
@@ -79,24 +110,59 @@ func (repo *productRepository) Get(id int64) (*data.Product, error) {
 	return &product, nil
 }
 
-func (repo *productRepository) Update(product *data.Product) error {
+// Update saves the product. The version only guards the fields the seller
+// edits, sales and refunds move the stock without changing it. The amount
+// available is therefore changed by its difference to fromQuantity, the amount
+// the edit started from, so units sold in the meantime are not put back. The
+// change is applied to the stock of the default machine, the other machines
+// are restocked through MachineRepository.AdjustStock. Lowering the amount
+// below what the other machines hold, or the default machine's stock below the
+// units loaded into its slots, returns data.ErrMachineStock.
+func (repo *productRepository) Update(product *data.Product, fromQuantity int) error {
 	query := `
-			UPDATE products SET name = $1, cost = $2, quantity = $3
-			WHERE id = $4 
-			RETURNING name, cost, quantity`
-
-	args := []interface{}{product.Name, product.Cost, product.AmountAvailable, product.ID}
+			WITH loaded AS (
+				SELECT COALESCE(SUM(quantity), 0) AS units FROM slots
+				WHERE machine_id = $6 AND product_id = $4
+			), stocked AS (
+				SELECT COALESCE(SUM(quantity), 0) AS units FROM machine_products
+				WHERE machine_id = $6 AND product_id = $4
+			), product AS (
+				UPDATE products SET name = $1, cost = $2, quantity = quantity + $3 - $7, version = version + 1
+				WHERE id = $4 AND version = $5 AND deleted_at IS NULL
+				AND (SELECT units FROM stocked) + $3 - $7 >= (SELECT units FROM loaded)
+				RETURNING id, name, cost, quantity, version
+			), stock AS (
+				INSERT INTO machine_products (machine_id, product_id, quantity)
+				SELECT $6, product.id, $3 - $7 FROM product
+				ON CONFLICT (machine_id, product_id)
+				DO UPDATE SET quantity = machine_products.quantity + EXCLUDED.quantity
+			)
+			SELECT name, cost, quantity, version FROM product`
+
+	args := []interface{}{
+		product.Name,
+		product.Cost,
+		product.AmountAvailable,
+		product.ID,
+		product.Version,
+		data.DefaultMachineID,
+		fromQuantity,
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
 	defer cancel()
 
-	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(&product.Name, &product.Cost, &product.AmountAvailable)
+	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(
+		&product.Name, &product.Cost, &product.AmountAvailable, &product.Version,
+	)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return data.ErrRecordNotFound
+			return repo.missingOrConflict(product.ID, product.Version)
 		case err.Error() == `pq: duplicate key value violates unique constraint "products_name_seller_id_key"`:
 			return data.ErrDuplicateProductName
+		case err.Error() == `pq: new row for relation "machine_products" violates check constraint "machine_products_quantity_check"`:
+			return data.ErrMachineStock
 		default:
 			return err
 		}
@@ -105,12 +171,39 @@ func (repo *productRepository) Update(product *data.Product) error {
 	return nil
 }
 
+// missingOrConflict tells why an update matched no row: the product was
+// archived or removed, another edit changed its version, or, with the version
+// unchanged, the stock left would not cover the units loaded into slots.
+func (repo *productRepository) missingOrConflict(id int64, version int32) error {
+	query := `SELECT version FROM products WHERE id = $1 AND deleted_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	var current int32
+	err := repo.DB.QueryRowContext(ctx, query, id).Scan(&current)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return data.ErrRecordNotFound
+	case err != nil:
+		return err
+	case current != version:
+		return data.ErrEditConflict
+	}
+
+	return data.ErrMachineStock
+}
+
+// Delete archives the product. The row is kept so receipts and refunds can
+// still refer to it until PurgeArchived removes it.
 func (repo *productRepository) Delete(id int64) error {
 	if id < 1 {
 		return data.ErrRecordNotFound
 	}
 
-	query := `DELETE FROM products WHERE id = $1`
+	query := `
+			UPDATE products SET deleted_at = NOW(), version = version + 1
+			WHERE id = $1 AND deleted_at IS NULL`
 
 	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
 	defer cancel()
@@ -132,27 +225,88 @@ func (repo *productRepository) Delete(id int64) error {
 	return nil
 }
 
+// Restore brings an archived product back. It returns data.ErrDuplicateProductName
+// when the seller has since created another product with the same name.
+func (repo *productRepository) Restore(product *data.Product) error {
+	query := `
+			UPDATE products SET deleted_at = NULL, version = version + 1
+			WHERE id = $1 AND deleted_at IS NOT NULL
+			RETURNING version, deleted_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, product.ID).Scan(&product.Version, &product.DeletedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return data.ErrRecordNotFound
+		case err.Error() == `pq: duplicate key value violates unique constraint "products_name_seller_id_key"`:
+			return data.ErrDuplicateProductName
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeArchived hard deletes products archived before the given time and
+// returns how many were removed. Slots still loaded with them are emptied in
+// the same statement rather than left holding units of no product.
+func (repo *productRepository) PurgeArchived(before time.Time) (int64, error) {
+	query := `
+			WITH purged AS (
+				DELETE FROM products WHERE deleted_at IS NOT NULL AND deleted_at < $1
+				RETURNING id
+			), emptied AS (
+				UPDATE slots SET product_id = NULL, quantity = 0, updated_at = NOW()
+				WHERE product_id IN (SELECT id FROM purged)
+			)
+			SELECT count(*) FROM purged`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	var purged int64
+	if err := repo.DB.QueryRowContext(ctx, query, before).Scan(&purged); err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
 func (repo *productRepository) GetAll(r dto.ListProductRequest) ([]*data.Product, data.Metadata, error) {
 
 	filters := r.Filters
+
+	predicates, args := listPredicates(r)
+
+	whereClause := ""
+	if len(predicates) > 0 {
+		whereClause = "WHERE " + strings.Join(predicates, " AND ")
+	}
+
+	args = append(args, filters.Limit(), filters.Offset())
+
 	query := fmt.Sprintf(`
-			SELECT count(*) OVER(), id, name, cost, quantity, seller_id, created_at
+			SELECT count(*) OVER(), id, name, cost, quantity, seller_id, created_at, version, deleted_at
 			FROM products
-			WHERE (to_tsvector('simple', name) @@ plainto_tsquery('simple', $1) OR $1 = '')
-			ORDER BY %s %s, id ASC 
-			LIMIT $2  OFFSET $3`, filters.SortColumn(), filters.SortDirection(),
+			%s
+			ORDER BY %s %s, id ASC
+			LIMIT $%d OFFSET $%d`, whereClause, filters.SortColumn(), filters.SortDirection(), len(args)-1, len(args),
 	)
 
 	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
 	defer cancel()
 
-	args := []interface{}{r.Name, filters.Limit(), filters.Offset()}
-
 	rows, err := repo.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, data.Metadata{}, err
 	}
 
+	defer rows.Close()
+
 	totalRecords := 0
 	var products []*data.Product
 
@@ -167,6 +321,8 @@ func (repo *productRepository) GetAll(r dto.ListProductRequest) ([]*data.Product
 			&product.AmountAvailable,
 			&product.Seller.ID,
 			&product.CreatedAt,
+			&product.Version,
+			&product.DeletedAt,
 		)
 
 		if err != nil {
@@ -176,7 +332,312 @@ func (repo *productRepository) GetAll(r dto.ListProductRequest) ([]*data.Product
 		products = append(products, &product)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, data.Metadata{}, err
+	}
+
 	metadata := data.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
 
 	return products, metadata, nil
 }
+
+// cursorColumnTypes casts a cursor's sort key back to the type of its column.
+var cursorColumnTypes = map[string]string{
+	"id":         "bigint",
+	"name":       "text",
+	"cost":       "numeric",
+	"quantity":   "numeric",
+	"created_at": "timestamptz",
+}
+
+// GetAllByCursor returns up to limit products on the far side of cursor, in the
+// order of r.Filters. Rows are found by seeking on (sort column, id) rather than
+// with an offset, so pages stay stable while stock changes.
+func (repo *productRepository) GetAllByCursor(r dto.ListProductRequest, cursor data.Cursor, limit int) ([]*data.Product, error) {
+
+	filters := r.Filters
+	column := filters.SortColumn()
+
+	columnType, ok := cursorColumnTypes[column]
+	if !ok {
+		return nil, fmt.Errorf("no cursor support for sort column %s", column)
+	}
+
+	predicates, args := listPredicates(r)
+
+	// both keys run in the sort direction so a row comparison can seek past
+	// the cursor; walking backwards flips the direction and the page is
+	// reversed once read.
+	descending := filters.SortDirection() == "DESC"
+	if cursor.Before {
+		descending = !descending
+	}
+
+	direction, comparison := "ASC", ">"
+	if descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if !cursor.IsStart() {
+		args = append(args, cursor.Value, cursor.ID)
+		predicates = append(predicates, fmt.Sprintf(
+			"(%s, id) %s ($%d::%s, $%d)", column, comparison, len(args)-1, columnType, len(args),
+		))
+	}
+
+	whereClause := ""
+	if len(predicates) > 0 {
+		whereClause = "WHERE " + strings.Join(predicates, " AND ")
+	}
+
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+			SELECT id, name, cost, quantity, seller_id, created_at, version, deleted_at
+			FROM products
+			%s
+			ORDER BY %s %s, id %s
+			LIMIT $%d`, whereClause, column, direction, direction, len(args),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var products []*data.Product
+
+	for rows.Next() {
+		var product data.Product
+
+		err = rows.Scan(
+			&product.ID,
+			&product.Name,
+			&product.Cost,
+			&product.AmountAvailable,
+			&product.Seller.ID,
+			&product.CreatedAt,
+			&product.Version,
+			&product.DeletedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, &product)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if cursor.Before {
+		for i, j := 0, len(products)-1; i < j; i, j = i+1, j-1 {
+			products[i], products[j] = products[j], products[i]
+		}
+	}
+
+	return products, nil
+}
+
+// listPredicates builds the WHERE predicates for the list filters in r along
+// with their arguments, numbered from $1.
+func listPredicates(r dto.ListProductRequest) ([]string, []interface{}) {
+
+	var predicates []string
+	var args []interface{}
+
+	// where adds a predicate, binding value to the next placeholder.
+	where := func(predicate string, value interface{}) {
+		args = append(args, value)
+		predicates = append(predicates, fmt.Sprintf(predicate, len(args)))
+	}
+
+	if r.Name != "" {
+		where("to_tsvector('simple', name) @@ plainto_tsquery('simple', $%d)", r.Name)
+	}
+
+	if !r.IncludeArchived {
+		predicates = append(predicates, "deleted_at IS NULL")
+	}
+
+	if r.MinCost > 0 {
+		where("cost >= $%d", r.MinCost)
+	}
+
+	if r.MaxCost > 0 {
+		where("cost <= $%d", r.MaxCost)
+	}
+
+	if r.SellerID > 0 {
+		where("seller_id = $%d", r.SellerID)
+	}
+
+	if r.InStock {
+		predicates = append(predicates, "quantity > 0")
+	}
+
+	if !r.CreatedAfter.IsZero() {
+		where("created_at >= $%d", r.CreatedAfter)
+	}
+
+	if !r.CreatedBefore.IsZero() {
+		where("created_at < $%d", r.CreatedBefore)
+	}
+
+	// a category matches its own products and those of its sub categories.
+	if r.Category != "" {
+		where(`id IN (
+				SELECT product_id FROM products_categories WHERE category_id IN (
+					WITH RECURSIVE tree AS (
+						SELECT id FROM categories WHERE slug = $%d
+						UNION ALL
+						SELECT c.id FROM categories c INNER JOIN tree ON c.parent_id = tree.id
+					)
+					SELECT id FROM tree
+				)
+			)`, r.Category)
+	}
+
+	if r.Tag != "" {
+		where("id IN (SELECT product_id FROM products_tags WHERE tag = $%d)", r.Tag)
+	}
+
+	return predicates, args
+}
+
+// SetCategories replaces the categories of the product. It returns
+// data.ErrRecordNotFound when one of the categories does not exist, in which
+// case the product keeps its categories. The check and the replacement run as
+// one statement so a category deleted in between can not slip through.
+func (repo *productRepository) SetCategories(productID int64, categoryIDs []int64) error {
+	query := `
+			WITH wanted AS (
+				SELECT id FROM categories WHERE id = ANY($2) FOR KEY SHARE
+			), complete AS (
+				SELECT count(*) = (SELECT count(DISTINCT id) FROM unnest($2::bigint[]) AS id) AS ok
+				FROM wanted
+			), removed AS (
+				DELETE FROM products_categories
+				WHERE product_id = $1 AND NOT (category_id = ANY($2)) AND (SELECT ok FROM complete)
+			), inserted AS (
+				INSERT INTO products_categories (product_id, category_id)
+				SELECT $1, id FROM wanted WHERE (SELECT ok FROM complete)
+				ON CONFLICT DO NOTHING
+			)
+			SELECT ok FROM complete`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	var complete bool
+	err := repo.DB.QueryRowContext(ctx, query, productID, pq.Array(categoryIDs)).Scan(&complete)
+	if err != nil {
+		return err
+	}
+
+	if !complete {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// SetTags replaces the tags of the product.
+func (repo *productRepository) SetTags(productID int64, tags []string) error {
+	query := `
+			WITH removed AS (
+				DELETE FROM products_tags
+				WHERE product_id = $1 AND NOT (tag = ANY($2))
+			)
+			INSERT INTO products_tags (product_id, tag)
+			SELECT $1, unnest($2::text[])
+			ON CONFLICT DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repo.DB.ExecContext(ctx, query, productID, pq.Array(tags))
+
+	return err
+}
+
+// LoadLabels fills in the categories and tags of the given products.
+func (repo *productRepository) LoadLabels(products []*data.Product) error {
+
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(products))
+	byID := make(map[int64]*data.Product, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+		byID[product.ID] = product
+		product.Categories = []data.Category{}
+		product.Tags = []string{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, `
+			SELECT pc.product_id, c.id, c.name, c.slug, COALESCE(c.parent_id, 0), c.created_at
+			FROM products_categories pc
+			INNER JOIN categories c ON c.id = pc.category_id
+			WHERE pc.product_id = ANY($1)
+			ORDER BY c.name`, pq.Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int64
+		var category data.Category
+
+		err = rows.Scan(&productID, &category.ID, &category.Name, &category.Slug, &category.ParentID, &category.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		byID[productID].Categories = append(byID[productID].Categories, category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	tagRows, err := repo.DB.QueryContext(ctx, `
+			SELECT product_id, tag
+			FROM products_tags
+			WHERE product_id = ANY($1)
+			ORDER BY tag`, pq.Array(ids),
+	)
+	if err != nil {
+		return err
+	}
+
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var productID int64
+		var tag string
+
+		if err = tagRows.Scan(&productID, &tag); err != nil {
+			return err
+		}
+
+		byID[productID].Tags = append(byID[productID].Tags, tag)
+	}
+
+	return tagRows.Err()
+}
@@ -0,0 +1,180 @@
+package repositorypurchase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+type purchaseRepository struct {
+	DB repository.DBTX
+}
+
+func NewPurchaseRepository(db repository.DBTX) repository.PurchaseRepository {
+	return &purchaseRepository{DB: db}
+}
+
+func (repo *purchaseRepository) Insert(purchase *data.Purchase) error {
+	query := `
+		INSERT INTO purchases (buyer_id, seller_id, product_id, product_name, product_cost, quantity, amount_spent, change, slot_code, machine_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, 0))
+		RETURNING id, created_at`
+
+	args := []interface{}{
+		purchase.BuyerID,
+		purchase.SellerID,
+		purchase.ProductID,
+		purchase.ProductName,
+		purchase.ProductCost,
+		purchase.Quantity,
+		purchase.AmountSpent,
+		pq.Array(toInt64s(purchase.Change)),
+		purchase.SlotCode,
+		purchase.MachineID,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	return repo.DB.QueryRowContext(ctx, query, args...).Scan(&purchase.ID, &purchase.CreatedAt)
+}
+
+func (repo *purchaseRepository) Get(id int64) (*data.Purchase, error) {
+
+	if id < 1 {
+		return nil, data.ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, buyer_id, seller_id, COALESCE(product_id, 0), product_name, product_cost,
+		quantity, amount_spent, change, COALESCE(slot_code, ''), COALESCE(machine_id, 0), created_at
+		FROM purchases
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	purchase, err := scanPurchase(repo.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return purchase, nil
+}
+
+func (repo *purchaseRepository) GetAllForBuyer(buyerID int64, filters data.Filters) ([]*data.Purchase, data.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, buyer_id, seller_id, COALESCE(product_id, 0), product_name, product_cost,
+		quantity, amount_spent, change, COALESCE(slot_code, ''), COALESCE(machine_id, 0), created_at
+		FROM purchases
+		WHERE buyer_id = $1
+		ORDER BY %s %s, id ASC
+		LIMIT $2 OFFSET $3`, filters.SortColumn(), filters.SortDirection(),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, buyerID, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	var purchases []*data.Purchase
+
+	for rows.Next() {
+		var purchase data.Purchase
+		var change []int64
+
+		err = rows.Scan(
+			&totalRecords,
+			&purchase.ID,
+			&purchase.BuyerID,
+			&purchase.SellerID,
+			&purchase.ProductID,
+			&purchase.ProductName,
+			&purchase.ProductCost,
+			&purchase.Quantity,
+			&purchase.AmountSpent,
+			pq.Array(&change),
+			&purchase.SlotCode,
+			&purchase.MachineID,
+			&purchase.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, data.Metadata{}, err
+		}
+
+		purchase.Change = toInts(change)
+		purchases = append(purchases, &purchase)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	metadata := data.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return purchases, metadata, nil
+}
+
+func scanPurchase(row *sql.Row) (*data.Purchase, error) {
+	var purchase data.Purchase
+	var change []int64
+
+	err := row.Scan(
+		&purchase.ID,
+		&purchase.BuyerID,
+		&purchase.SellerID,
+		&purchase.ProductID,
+		&purchase.ProductName,
+		&purchase.ProductCost,
+		&purchase.Quantity,
+		&purchase.AmountSpent,
+		pq.Array(&change),
+		&purchase.SlotCode,
+		&purchase.MachineID,
+		&purchase.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	purchase.Change = toInts(change)
+
+	return &purchase, nil
+}
+
+// pq.Array only understands fixed size integers.
+func toInt64s(values []int) []int64 {
+	out := make([]int64, len(values))
+	for i := range values {
+		out[i] = int64(values[i])
+	}
+
+	return out
+}
+
+func toInts(values []int64) []int {
+	out := make([]int, len(values))
+	for i := range values {
+		out[i] = int(values[i])
+	}
+
+	return out
+}
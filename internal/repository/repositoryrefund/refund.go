@@ -0,0 +1,244 @@
+package repositoryrefund
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+type refundRepository struct {
+	DB repository.DBTX
+}
+
+func NewRefundRepository(db repository.DBTX) repository.RefundRepository {
+	return &refundRepository{DB: db}
+}
+
+// Insert returns data.ErrDuplicateRefund when the purchase already has a refund
+// that was not rejected.
+func (repo *refundRepository) Insert(refund *data.Refund) error {
+	query := `
+		INSERT INTO refunds (purchase_id, buyer_id, seller_id, product_id, quantity, amount, reason, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at`
+
+	// the product may have been removed since the purchase was made
+	productID := sql.NullInt64{Int64: refund.ProductID, Valid: refund.ProductID > 0}
+
+	args := []interface{}{
+		refund.PurchaseID,
+		refund.BuyerID,
+		refund.SellerID,
+		productID,
+		refund.Quantity,
+		refund.Amount,
+		refund.Reason,
+		refund.Status,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(&refund.ID, &refund.CreatedAt, &refund.UpdatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "refunds_purchase_id_key"`:
+			return data.ErrDuplicateRefund
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *refundRepository) Get(id int64) (*data.Refund, error) {
+	return repo.get(id, "")
+}
+
+// GetForUpdate locks the refund row until the surrounding transaction ends so
+// two reviewers can not resolve the same refund at once.
+func (repo *refundRepository) GetForUpdate(id int64) (*data.Refund, error) {
+	return repo.get(id, "FOR UPDATE")
+}
+
+func (repo *refundRepository) get(id int64, lock string) (*data.Refund, error) {
+
+	if id < 1 {
+		return nil, data.ErrRecordNotFound
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, purchase_id, buyer_id, seller_id, COALESCE(product_id, 0), quantity, amount,
+		reason, status, created_at, updated_at
+		FROM refunds
+		WHERE id = $1
+		%s`, lock,
+	)
+
+	var refund data.Refund
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, id).Scan(
+		&refund.ID,
+		&refund.PurchaseID,
+		&refund.BuyerID,
+		&refund.SellerID,
+		&refund.ProductID,
+		&refund.Quantity,
+		&refund.Amount,
+		&refund.Reason,
+		&refund.Status,
+		&refund.CreatedAt,
+		&refund.UpdatedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &refund, nil
+}
+
+func (repo *refundRepository) Update(refund *data.Refund) error {
+	query := `
+		UPDATE refunds SET status = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING updated_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	return repo.DB.QueryRowContext(ctx, query, refund.Status, refund.ID).Scan(&refund.UpdatedAt)
+}
+
+func (repo *refundRepository) GetAll(filter data.RefundFilter, filters data.Filters) ([]*data.Refund, data.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, purchase_id, buyer_id, seller_id, COALESCE(product_id, 0), quantity, amount,
+		reason, status, created_at, updated_at
+		FROM refunds
+		WHERE ($1 = 0 OR buyer_id = $1 OR seller_id = $1)
+		AND ($2 = '' OR status = $2)
+		ORDER BY %s %s, id ASC
+		LIMIT $3 OFFSET $4`, filters.SortColumn(), filters.SortDirection(),
+	)
+
+	args := []interface{}{filter.UserID, filter.Status, filters.Limit(), filters.Offset()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	var refunds []*data.Refund
+
+	for rows.Next() {
+		var refund data.Refund
+
+		err = rows.Scan(
+			&totalRecords,
+			&refund.ID,
+			&refund.PurchaseID,
+			&refund.BuyerID,
+			&refund.SellerID,
+			&refund.ProductID,
+			&refund.Quantity,
+			&refund.Amount,
+			&refund.Reason,
+			&refund.Status,
+			&refund.CreatedAt,
+			&refund.UpdatedAt,
+		)
+
+		if err != nil {
+			return nil, data.Metadata{}, err
+		}
+
+		refunds = append(refunds, &refund)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	metadata := data.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return refunds, metadata, nil
+}
+
+func (repo *refundRepository) AddEvent(event *data.RefundEvent) error {
+	query := `
+		INSERT INTO refund_events (refund_id, from_status, to_status, actor_id, note)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+		RETURNING id, created_at`
+
+	args := []interface{}{event.RefundID, event.FromStatus, event.ToStatus, event.ActorID, event.Note}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	return repo.DB.QueryRowContext(ctx, query, args...).Scan(&event.ID, &event.CreatedAt)
+}
+
+func (repo *refundRepository) GetEvents(refundID int64) ([]*data.RefundEvent, error) {
+	query := `
+		SELECT id, refund_id, COALESCE(from_status, ''), to_status, actor_id, note, created_at
+		FROM refund_events
+		WHERE refund_id = $1
+		ORDER BY id ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var events []*data.RefundEvent
+
+	for rows.Next() {
+		var event data.RefundEvent
+
+		err = rows.Scan(
+			&event.ID,
+			&event.RefundID,
+			&event.FromStatus,
+			&event.ToStatus,
+			&event.ActorID,
+			&event.Note,
+			&event.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, &event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,128 @@
+package repositoryreport
+
+import (
+	"context"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+type reportRepository struct {
+	DB repository.DBTX
+}
+
+func NewReportRepository(db repository.DBTX) repository.ReportRepository {
+	return &reportRepository{DB: db}
+}
+
+// ProductSales sums the seller's purchases net of refunds per product. Sales are
+// grouped on the purchase snapshot, so purged products are still reported,
+// under the name they were last sold as; products never sold are included too.
+func (repo *reportRepository) ProductSales(sellerID int64) ([]*data.ProductSales, error) {
+	query := `
+		WITH sales AS (
+			SELECT purchases.product_id,
+			(array_agg(purchases.product_name ORDER BY purchases.id DESC))[1] AS name,
+			SUM(purchases.quantity - COALESCE(refunds.quantity, 0)) AS units_sold,
+			SUM(purchases.amount_spent - COALESCE(refunds.amount, 0)) AS revenue
+			FROM purchases
+			LEFT JOIN refunds ON refunds.purchase_id = purchases.id AND refunds.status IN ($2, $3)
+			WHERE purchases.seller_id = $1
+			GROUP BY purchases.product_id, CASE WHEN purchases.product_id IS NULL THEN purchases.product_name END
+		), stock AS (
+			SELECT id, name, quantity FROM products WHERE seller_id = $1 AND deleted_at IS NULL
+		)
+		SELECT COALESCE(stock.id, sales.product_id, 0), COALESCE(stock.name, sales.name),
+		COALESCE(sales.units_sold, 0), COALESCE(sales.revenue, 0), COALESCE(stock.quantity, 0)
+		FROM sales
+		FULL JOIN stock ON stock.id = sales.product_id
+		ORDER BY 4 DESC, 1 ASC, 2 ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, sellerID, data.RefundApproved, data.RefundRefunded)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var sales []*data.ProductSales
+
+	for rows.Next() {
+		var productSales data.ProductSales
+
+		err = rows.Scan(
+			&productSales.ProductID,
+			&productSales.Name,
+			&productSales.UnitsSold,
+			&productSales.Revenue,
+			&productSales.AmountAvailable,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sales = append(sales, &productSales)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sales, nil
+}
+
+// RevenueByPeriod buckets the seller's purchases by period, which must be one
+// of data.ReportPeriods, and sums them in the database. Refunds are taken off
+// the period of the purchase they refund.
+func (repo *reportRepository) RevenueByPeriod(sellerID int64, period string, dateRange data.ReportRange) ([]*data.PeriodRevenue, error) {
+	query := `
+		SELECT date_trunc($2, purchases.created_at),
+		SUM(purchases.quantity - COALESCE(refunds.quantity, 0)),
+		SUM(purchases.amount_spent - COALESCE(refunds.amount, 0))
+		FROM purchases
+		LEFT JOIN refunds ON refunds.purchase_id = purchases.id AND refunds.status IN ($5, $6)
+		WHERE purchases.seller_id = $1
+		AND ($3::timestamptz IS NULL OR purchases.created_at >= $3)
+		AND ($4::timestamptz IS NULL OR purchases.created_at < $4)
+		GROUP BY 1
+		ORDER BY 1 ASC`
+
+	var from, to interface{}
+	if !dateRange.From.IsZero() {
+		from = dateRange.From
+	}
+	if !dateRange.To.IsZero() {
+		to = dateRange.To
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, sellerID, period, from, to, data.RefundApproved, data.RefundRefunded)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var revenue []*data.PeriodRevenue
+
+	for rows.Next() {
+		var periodRevenue data.PeriodRevenue
+
+		if err = rows.Scan(&periodRevenue.Period, &periodRevenue.UnitsSold, &periodRevenue.Revenue); err != nil {
+			return nil, err
+		}
+
+		revenue = append(revenue, &periodRevenue)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revenue, nil
+}
@@ -0,0 +1,175 @@
+package repositoryrole
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+const selectRole = `
+		SELECT roles.id, roles.name, roles.created_at,
+			COALESCE(array_agg(permissions.code ORDER BY permissions.code) FILTER (WHERE permissions.id IS NOT NULL), '{}')
+		FROM roles
+		LEFT JOIN roles_permissions ON roles_permissions.role_id = roles.id
+		LEFT JOIN permissions ON permissions.id = roles_permissions.permission_id`
+
+type roleRepository struct {
+	DB repository.DBTX
+}
+
+func NewRoleRepository(db repository.DBTX) repository.RoleRepository {
+	return &roleRepository{DB: db}
+}
+
+// Insert creates the role without permissions, SetPermissions adds them.
+func (repo *roleRepository) Insert(role *data.Role) error {
+	query := `
+		INSERT INTO roles (name)
+		VALUES ($1)
+		RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, role.Name).Scan(&role.ID, &role.CreatedAt)
+	if err != nil {
+		return roleError(err)
+	}
+
+	return nil
+}
+
+func (repo *roleRepository) GetByName(name string) (*data.Role, error) {
+	query := selectRole + ` WHERE roles.name = $1 GROUP BY roles.id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	role, err := scanRole(repo.DB.QueryRowContext(ctx, query, name))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return role, nil
+}
+
+func (repo *roleRepository) GetAll() ([]*data.Role, error) {
+	query := selectRole + ` GROUP BY roles.id ORDER BY roles.name`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var roles []*data.Role
+
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, role)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// SetPermissions replaces the role's permissions with role.Permissions. Codes
+// that do not exist are skipped, callers check them first.
+func (repo *roleRepository) SetPermissions(role *data.Role) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repo.DB.ExecContext(ctx, `DELETE FROM roles_permissions WHERE role_id = $1`, role.ID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO roles_permissions
+		SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)`
+
+	_, err = repo.DB.ExecContext(ctx, query, role.ID, pq.Array([]string(role.Permissions)))
+
+	return err
+}
+
+// Delete removes the role. Roles still held by users give data.ErrRoleInUse.
+func (repo *roleRepository) Delete(id int64) error {
+	if id < 1 {
+		return data.ErrRecordNotFound
+	}
+
+	query := `DELETE FROM roles WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return roleError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRole(row scanner) (*data.Role, error) {
+	var (
+		role  data.Role
+		codes []string
+	)
+
+	err := row.Scan(&role.ID, &role.Name, &role.CreatedAt, pq.Array(&codes))
+	if err != nil {
+		return nil, err
+	}
+
+	role.Permissions = codes
+
+	return &role, nil
+}
+
+func roleError(err error) error {
+	switch {
+	case err.Error() == `pq: duplicate key value violates unique constraint "roles_name_key"`:
+		return data.ErrDuplicateRole
+	case err.Error() == `pq: update or delete on table "roles" violates foreign key constraint "users_role_fkey" on table "users"`:
+		return data.ErrRoleInUse
+	default:
+		return err
+	}
+}
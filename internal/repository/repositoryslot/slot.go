@@ -0,0 +1,135 @@
+package repositoryslot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+type slotRepository struct {
+	DB repository.DBTX
+}
+
+func NewSlotRepository(db repository.DBTX) repository.SlotRepository {
+	return &slotRepository{DB: db}
+}
+
+const slotColumns = `id, machine_id, code, capacity, COALESCE(product_id, 0), quantity, updated_at`
+
+func (repo *slotRepository) GetAll(machineID int64) ([]*data.Slot, error) {
+	return repo.getAll(`SELECT `+slotColumns+` FROM slots WHERE machine_id = $1 ORDER BY code`, machineID)
+}
+
+// GetAllForProductForUpdate locks every slot of the machine holding the
+// product, fullest first, so a purchase dispenses from the spiral with the most
+// units.
+func (repo *slotRepository) GetAllForProductForUpdate(machineID, productID int64) ([]*data.Slot, error) {
+	return repo.getAll(`
+		SELECT `+slotColumns+` FROM slots
+		WHERE machine_id = $1 AND product_id = $2
+		ORDER BY quantity DESC, code
+		FOR UPDATE`, machineID, productID)
+}
+
+func (repo *slotRepository) GetByCode(machineID int64, code string) (*data.Slot, error) {
+	return repo.get(`SELECT `+slotColumns+` FROM slots WHERE machine_id = $1 AND code = $2`, machineID, code)
+}
+
+func (repo *slotRepository) GetByCodeForUpdate(machineID int64, code string) (*data.Slot, error) {
+	return repo.get(`SELECT `+slotColumns+` FROM slots WHERE machine_id = $1 AND code = $2 FOR UPDATE`, machineID, code)
+}
+
+func (repo *slotRepository) Update(slot *data.Slot) error {
+	query := `
+		UPDATE slots SET capacity = $1, product_id = NULLIF($2, 0), quantity = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at`
+
+	args := []interface{}{slot.Capacity, slot.ProductID, slot.Quantity, slot.ID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(&slot.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return data.ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *slotRepository) get(query string, machineID int64, code string) (*data.Slot, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	var slot data.Slot
+
+	err := repo.DB.QueryRowContext(ctx, query, machineID, code).Scan(
+		&slot.ID,
+		&slot.MachineID,
+		&slot.Code,
+		&slot.Capacity,
+		&slot.ProductID,
+		&slot.Quantity,
+		&slot.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &slot, nil
+}
+
+func (repo *slotRepository) getAll(query string, args ...interface{}) ([]*data.Slot, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	slots := []*data.Slot{}
+
+	for rows.Next() {
+		var slot data.Slot
+
+		err = rows.Scan(
+			&slot.ID,
+			&slot.MachineID,
+			&slot.Code,
+			&slot.Capacity,
+			&slot.ProductID,
+			&slot.Quantity,
+			&slot.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		slots = append(slots, &slot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
@@ -3,31 +3,207 @@ package repositorytoken
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/terdia/mvp/internal/data"
 	"github.com/terdia/mvp/internal/repository"
 )
 
 type tokenRepository struct {
-	*sql.DB
+	DB repository.DBTX
 }
 
-func NewTokenRepository(db *sql.DB) repository.TokenRepository {
-	return &tokenRepository{db}
+func NewTokenRepository(db repository.DBTX) repository.TokenRepository {
+	return &tokenRepository{DB: db}
 }
 
+// Create stores the token in token.FamilyID, or in a new family when it is 0.
 func (repo *tokenRepository) Create(token *data.Token) error {
 
 	query := `
-			INSERT INTO tokens (hash, user_id, expiry, scope)
-			VALUES ($1, $2, $3, $4)`
+			INSERT INTO tokens (hash, user_id, expiry, scope, family_id)
+			VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, 0), nextval('tokens_family_id_seq')))
+			RETURNING id, created_at, family_id`
 
-	args := []interface{}{token.Hash, token.UserId, token.Expiry, token.Scope}
+	args := []interface{}{token.Hash, token.UserId, token.Expiry, token.Scope, token.FamilyID}
 
 	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
 	defer cancel()
 
-	_, err := repo.DB.ExecContext(ctx, query, args...)
+	return repo.DB.QueryRowContext(ctx, query, args...).Scan(&token.ID, &token.CreatedAt, &token.FamilyID)
+}
+
+// Rotate marks an unexpired, not yet rotated refresh token as used and deletes
+// the access tokens of its family, in one statement so that a refresh token
+// can only be rotated once. It returns the user and family the token belongs
+// to, or data.ErrRecordNotFound.
+func (repo *tokenRepository) Rotate(hash []byte) (*data.Token, error) {
+
+	query := `
+			WITH rotated AS (
+				UPDATE tokens SET rotated_at = NOW()
+				WHERE hash = $1 AND scope = $2 AND expiry > $3 AND rotated_at IS NULL
+				RETURNING user_id, family_id
+			), access AS (
+				DELETE FROM tokens
+				USING rotated
+				WHERE tokens.family_id = rotated.family_id AND tokens.scope = $4
+			)
+			SELECT user_id, family_id FROM rotated`
+
+	args := []interface{}{hash, data.TokenScopeRefresh, time.Now(), data.TokenScopeAuthentication}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	token := data.Token{Hash: hash, Scope: data.TokenScopeRefresh}
+
+	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(&token.UserId, &token.FamilyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &token, nil
+}
+
+// GetRotated returns the family of a refresh token that was already rotated,
+// or data.ErrRecordNotFound.
+func (repo *tokenRepository) GetRotated(hash []byte) (*data.Token, error) {
+
+	query := `
+			SELECT user_id, family_id FROM tokens
+			WHERE hash = $1 AND scope = $2 AND rotated_at IS NOT NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	token := data.Token{Hash: hash, Scope: data.TokenScopeRefresh}
+
+	err := repo.DB.QueryRowContext(ctx, query, hash, data.TokenScopeRefresh).Scan(&token.UserId, &token.FamilyID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &token, nil
+}
+
+// GetAllForUser lists the user's unexpired tokens of the scope, newest first.
+// The plaintext is never known here, only the hash is returned.
+func (repo *tokenRepository) GetAllForUser(userID int64, scope string) ([]*data.Token, error) {
+
+	query := `
+			SELECT id, family_id, hash, user_id, expiry, scope, created_at, last_seen_ip, last_seen_at, rotated_at
+			FROM tokens
+			WHERE user_id = $1 AND scope = $2 AND expiry > $3
+			ORDER BY created_at DESC, id DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, userID, scope, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tokens := []*data.Token{}
+
+	for rows.Next() {
+		var token data.Token
+
+		err = rows.Scan(
+			&token.ID,
+			&token.FamilyID,
+			&token.Hash,
+			&token.UserId,
+			&token.Expiry,
+			&token.Scope,
+			&token.CreatedAt,
+			&token.LastSeenIP,
+			&token.LastSeenAt,
+			&token.RotatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, &token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// DeleteFamilyByHash deletes the token with hash together with every token of
+// its family, which ends the whole session.
+func (repo *tokenRepository) DeleteFamilyByHash(hash []byte) error {
+
+	query := `
+			DELETE FROM tokens
+			WHERE family_id = (SELECT family_id FROM tokens WHERE hash = $1)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, hash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return data.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (repo *tokenRepository) DeleteFamily(familyID int64) error {
+
+	query := `
+			DELETE FROM tokens
+			WHERE family_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repo.DB.ExecContext(ctx, query, familyID)
+
+	return err
+}
+
+// DeleteOtherFamilies deletes every token of the user except the ones in the
+// family of the token with hash, which ends all of the user's other sessions.
+func (repo *tokenRepository) DeleteOtherFamilies(userID int64, hash []byte) error {
+
+	query := `
+			DELETE FROM tokens
+			WHERE user_id = $1
+			AND family_id IS DISTINCT FROM (SELECT family_id FROM tokens WHERE hash = $2)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repo.DB.ExecContext(ctx, query, userID, hash)
 
 	return err
 }
@@ -45,3 +221,23 @@ func (repo *tokenRepository) DeleteAllForUserByScope(scope string, userID int64)
 
 	return err
 }
+
+// Touch records ip as the address the token, and the refresh token of its
+// family, were last seen from, so the session keeps it after the access token
+// expires. Rows are only written when the address changed or they were last
+// seen before staleBefore, so busy tokens do not write on every request.
+func (repo *tokenRepository) Touch(hash []byte, ip string, staleBefore time.Time) error {
+
+	query := `
+			UPDATE tokens SET last_seen_ip = $2, last_seen_at = NOW()
+			WHERE family_id = (SELECT family_id FROM tokens WHERE hash = $1)
+			AND rotated_at IS NULL
+			AND (last_seen_ip <> $2 OR last_seen_at IS NULL OR last_seen_at < $3)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	_, err := repo.DB.ExecContext(ctx, query, hash, ip, staleBefore)
+
+	return err
+}
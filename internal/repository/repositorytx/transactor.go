@@ -0,0 +1,92 @@
+package repositorytx
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/internal/repository/repositoryaudit"
+	"github.com/terdia/mvp/internal/repository/repositorycoin"
+	"github.com/terdia/mvp/internal/repository/repositoryledger"
+	"github.com/terdia/mvp/internal/repository/repositorymachine"
+	"github.com/terdia/mvp/internal/repository/repositorypermission"
+	"github.com/terdia/mvp/internal/repository/repositoryproduct"
+	"github.com/terdia/mvp/internal/repository/repositorypurchase"
+	"github.com/terdia/mvp/internal/repository/repositoryrefund"
+	"github.com/terdia/mvp/internal/repository/repositoryrole"
+	"github.com/terdia/mvp/internal/repository/repositoryslot"
+	"github.com/terdia/mvp/internal/repository/repositoryuser"
+)
+
+type transactor struct {
+	db *sql.DB
+}
+
+func NewTransactor(db *sql.DB) repository.Transactor {
+	return &transactor{db: db}
+}
+
+func (t *transactor) WithinTransaction(fn func(uow repository.UnitOfWork) error) error {
+
+	tx, err := t.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	// a no-op once the transaction has been committed
+	defer tx.Rollback() //nolint
+
+	if err = fn(&unitOfWork{tx: tx}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type unitOfWork struct {
+	tx *sql.Tx
+}
+
+func (u *unitOfWork) Users() repository.UserRepository {
+	return repositoryuser.NewUserRepository(u.tx)
+}
+
+func (u *unitOfWork) Products() repository.ProductRepository {
+	return repositoryproduct.NewProductRepository(u.tx)
+}
+
+func (u *unitOfWork) Ledger() repository.LedgerRepository {
+	return repositoryledger.NewLedgerRepository(u.tx)
+}
+
+func (u *unitOfWork) Coins() repository.CoinRepository {
+	return repositorycoin.NewCoinRepository(u.tx)
+}
+
+func (u *unitOfWork) Purchases() repository.PurchaseRepository {
+	return repositorypurchase.NewPurchaseRepository(u.tx)
+}
+
+func (u *unitOfWork) Refunds() repository.RefundRepository {
+	return repositoryrefund.NewRefundRepository(u.tx)
+}
+
+func (u *unitOfWork) Slots() repository.SlotRepository {
+	return repositoryslot.NewSlotRepository(u.tx)
+}
+
+func (u *unitOfWork) Machines() repository.MachineRepository {
+	return repositorymachine.NewMachineRepository(u.tx)
+}
+
+func (u *unitOfWork) Permissions() repository.PermissionRepository {
+	return repositorypermission.NewPermissionRepository(u.tx)
+}
+
+func (u *unitOfWork) Roles() repository.RoleRepository {
+	return repositoryrole.NewRoleRepository(u.tx)
+}
+
+func (u *unitOfWork) Audit() repository.AuditRepository {
+	return repositoryaudit.NewAuditRepository(u.tx)
+}
@@ -5,6 +5,8 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/terdia/mvp/internal/data"
@@ -12,13 +14,11 @@ import (
 )
 
 type userRepository struct {
-	*sql.DB
+	DB repository.DBTX
 }
 
-func NewUserRepository(db *sql.DB) repository.UserRepository {
-	return &userRepository{
-		db,
-	}
+func NewUserRepository(db repository.DBTX) repository.UserRepository {
+	return &userRepository{DB: db}
 }
 
 func (repo *userRepository) Insert(user *data.User) error {
@@ -46,9 +46,10 @@ func (repo *userRepository) Insert(user *data.User) error {
 
 func (repo *userRepository) Get(username string) (*data.User, error) {
 
-	query := `SELECT id, username, deposit, password_hash, role, created_at
+	query := `SELECT id, username, deposit, COALESCE(deposit_machine_id, 0), password_hash, role, created_at, suspended_at, deleted_at
 			  FROM users
-			  WHERE username = $1`
+			  WHERE username = $1
+			  AND deleted_at IS NULL`
 
 	var user data.User
 
@@ -59,9 +60,12 @@ func (repo *userRepository) Get(username string) (*data.User, error) {
 		&user.ID,
 		&user.Username,
 		&user.Deposit,
+		&user.DepositMachineID,
 		&user.Password.Hash,
 		&user.Role,
 		&user.CreatedAt,
+		&user.SuspendedAt,
+		&user.DeletedAt,
 	)
 
 	if err != nil {
@@ -76,38 +80,188 @@ func (repo *userRepository) Get(username string) (*data.User, error) {
 	return &user, nil
 }
 
+func (repo *userRepository) GetByID(id int64) (*data.User, error) {
+	return repo.getByID(`SELECT id, username, deposit, COALESCE(deposit_machine_id, 0), password_hash, role, created_at, suspended_at, deleted_at
+			  FROM users
+			  WHERE id = $1`, id)
+}
+
+// GetForUpdate loads the user by id and locks the row until the surrounding
+// transaction ends. It is only meaningful on a repository bound to a UnitOfWork.
+func (repo *userRepository) GetForUpdate(id int64) (*data.User, error) {
+	return repo.getByID(`SELECT id, username, deposit, COALESCE(deposit_machine_id, 0), password_hash, role, created_at, suspended_at, deleted_at
+			  FROM users
+			  WHERE id = $1
+			  FOR UPDATE`, id)
+}
+
+func (repo *userRepository) getByID(query string, id int64) (*data.User, error) {
+
+	if id < 1 {
+		return nil, data.ErrRecordNotFound
+	}
+
+	var user data.User
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	err := repo.DB.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Deposit,
+		&user.DepositMachineID,
+		&user.Password.Hash,
+		&user.Role,
+		&user.CreatedAt,
+		&user.SuspendedAt,
+		&user.DeletedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, data.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+// GetAll lists users for admins. filter.Query matches anywhere in the
+// username, case insensitively.
+func (repo *userRepository) GetAll(filter data.UserFilter, filters data.Filters) ([]*data.User, data.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, username, deposit, COALESCE(deposit_machine_id, 0), role, created_at, suspended_at, deleted_at
+		FROM users
+		WHERE ($1 = '' OR username ILIKE '%%' || $1 || '%%')
+		AND ($2 = '' OR role = $2)
+		AND ($3::boolean IS NULL OR (suspended_at IS NOT NULL) = $3)
+		ORDER BY %s %s, id ASC
+		LIMIT $4 OFFSET $5`, filters.SortColumn(), filters.SortDirection(),
+	)
+
+	args := []interface{}{escapeLike(filter.Query), filter.Role, filter.Suspended, filters.Limit(), filters.Offset()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	rows, err := repo.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	var users []*data.User
+
+	for rows.Next() {
+		var user data.User
+
+		err = rows.Scan(
+			&totalRecords,
+			&user.ID,
+			&user.Username,
+			&user.Deposit,
+			&user.DepositMachineID,
+			&user.Role,
+			&user.CreatedAt,
+			&user.SuspendedAt,
+			&user.DeletedAt,
+		)
+		if err != nil {
+			return nil, data.Metadata{}, err
+		}
+
+		users = append(users, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	metadata := data.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return users, metadata, nil
+}
+
+// Update saves the user's profile. The deposit is only ever changed through the
+// ledger, so it is read back here but never written.
 func (repo *userRepository) Update(user *data.User) error {
 	query := `
 		UPDATE users
-		SET username = $1, password_hash = $2, deposit = $3
-		WHERE id = $4 RETURNING username, deposit`
+		SET username = $1, password_hash = $2
+		WHERE id = $3 RETURNING username, deposit, COALESCE(deposit_machine_id, 0)`
 
-	args := []interface{}{user.Username, user.Password.Hash, user.Deposit, user.ID}
+	args := []interface{}{user.Username, user.Password.Hash, user.ID}
 
 	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
 	defer cancel()
 
-	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(&user.Username, &user.Deposit)
+	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(&user.Username, &user.Deposit, &user.DepositMachineID)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_username_key"`:
+			return data.ErrDuplicateUsername
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *userRepository) UpdateRole(user *data.User) error {
+	return repo.exec(`UPDATE users SET role = $1 WHERE id = $2`, user.Role, user.ID)
+}
+
+func (repo *userRepository) UpdateSuspendedAt(user *data.User) error {
+	return repo.exec(`UPDATE users SET suspended_at = $1 WHERE id = $2`, user.SuspendedAt, user.ID)
+}
+
+func (repo *userRepository) exec(query string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
+	defer cancel()
+
+	result, err := repo.DB.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return data.ErrRecordNotFound
+	}
+
 	return nil
 }
 
-func (repo *userRepository) GetForToken(tokenPlainText, scope string) (*data.User, error) {
+// GetForToken returns the owner of an unexpired token together with the token.
+// Only the owner's identity is loaded, Deposit and DepositMachineID are left
+// empty since the result is cached and the balance must be read under lock.
+// Tokens of suspended or deleted users are ignored.
+func (repo *userRepository) GetForToken(tokenPlainText, scope string) (*data.User, *data.Token, error) {
 
 	hash := sha256.Sum256([]byte(tokenPlainText))
 
 	query := `
-			SELECT users.id, users.created_at, users.username, users.role, 
-			users.password_hash, users.deposit
+			SELECT users.id, users.created_at, users.username, users.role,
+			users.password_hash, tokens.expiry, tokens.last_seen_ip, tokens.last_seen_at
 			FROM users
 			INNER JOIN tokens
 			ON users.id = tokens.user_id
 			WHERE tokens.hash = $1
 			AND tokens.scope = $2
-			AND tokens.expiry > $3`
+			AND tokens.expiry > $3
+			AND users.suspended_at IS NULL
+			AND users.deleted_at IS NULL`
 
 	args := []interface{}{hash[:], scope, time.Now()}
 
@@ -116,51 +270,64 @@ func (repo *userRepository) GetForToken(tokenPlainText, scope string) (*data.Use
 
 	var user data.User
 
+	token := data.Token{Hash: hash[:], Scope: scope}
+
 	err := repo.DB.QueryRowContext(ctx, query, args...).Scan(
 		&user.ID,
 		&user.CreatedAt,
 		&user.Username,
 		&user.Role,
 		&user.Password.Hash,
-		&user.Deposit,
+		&token.Expiry,
+		&token.LastSeenIP,
+		&token.LastSeenAt,
 	)
 
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return nil, data.ErrRecordNotFound
+			return nil, nil, data.ErrRecordNotFound
 		default:
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	return &user, nil
+	token.UserId = user.ID
+
+	return &user, &token, nil
 
 }
 
+// Delete anonymises the account rather than removing it, since purchases,
+// ledger entries and the audit log refer to it. The username and password are
+// replaced, the user's tokens, permission grants and idempotency keys deleted
+// and the products of a seller archived.
 func (repo *userRepository) Delete(id int64) error {
 	if id < 1 {
 		return data.ErrRecordNotFound
 	}
 
-	query := `DELETE FROM users WHERE id = $1`
-
-	ctx, cancel := context.WithTimeout(context.Background(), repository.QueryTimeout)
-	defer cancel()
-
-	result, err := repo.DB.ExecContext(ctx, query, id)
-	if err != nil {
-		return err
-	}
+	query := `
+		WITH tokens AS (
+			DELETE FROM tokens WHERE user_id = $1
+		), grants AS (
+			DELETE FROM users_permissions WHERE user_id = $1
+		), keys AS (
+			DELETE FROM idempotency_keys WHERE user_id = $1
+		), products AS (
+			UPDATE products SET deleted_at = NOW(), version = version + 1
+			WHERE seller_id = $1 AND deleted_at IS NULL
+		)
+		UPDATE users
+		SET username = $2::text || id, password_hash = '', deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
+	return repo.exec(query, id, data.DeletedUsernamePrefix)
+}
 
-	if rowsAffected == 0 {
-		return data.ErrRecordNotFound
-	}
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
 
-	return nil
+// escapeLike makes the wildcards in s match literally in an ILIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
 }
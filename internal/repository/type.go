@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"time"
 
 	"github.com/terdia/mvp/internal/data"
@@ -12,6 +14,35 @@ const (
 )
 
 type (
+	// DBTX is satisfied by both *sql.DB and *sql.Tx, so a repository can run
+	// against the connection pool or join a transaction opened by a Transactor.
+	DBTX interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+		QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	}
+
+	// UnitOfWork hands out repositories bound to a single database transaction.
+	UnitOfWork interface {
+		Users() UserRepository
+		Products() ProductRepository
+		Ledger() LedgerRepository
+		Coins() CoinRepository
+		Purchases() PurchaseRepository
+		Refunds() RefundRepository
+		Slots() SlotRepository
+		Machines() MachineRepository
+		Permissions() PermissionRepository
+		Roles() RoleRepository
+		Audit() AuditRepository
+	}
+
+	// Transactor runs fn inside one database transaction. The transaction is
+	// committed when fn returns nil and rolled back otherwise.
+	Transactor interface {
+		WithinTransaction(fn func(uow UnitOfWork) error) error
+	}
+
 	Repository interface {
 		Delete(id int64) error
 	}
@@ -20,25 +51,139 @@ type (
 		Repository
 		Insert(user *data.User) error
 		Get(username string) (*data.User, error)
+		GetByID(id int64) (*data.User, error)
+		GetForUpdate(id int64) (*data.User, error)
+		GetAll(filter data.UserFilter, filters data.Filters) ([]*data.User, data.Metadata, error)
 		Update(user *data.User) error
-		GetForToken(tokenPlainText, scope string) (*data.User, error)
+		UpdateRole(user *data.User) error
+		UpdateSuspendedAt(user *data.User) error
+		GetForToken(tokenPlainText, scope string) (*data.User, *data.Token, error)
 	}
 
 	ProductRepository interface {
 		Repository
 		Insert(product *data.Product) error
 		Get(id int64) (*data.Product, error)
-		Update(product *data.Product) error
+		GetIncludingArchived(id int64) (*data.Product, error)
+		GetForUpdate(id int64) (*data.Product, error)
+		Update(product *data.Product, fromQuantity int) error
+		Restore(product *data.Product) error
+		PurgeArchived(before time.Time) (int64, error)
 		GetAll(request dto.ListProductRequest) ([]*data.Product, data.Metadata, error)
+		GetAllByCursor(request dto.ListProductRequest, cursor data.Cursor, limit int) ([]*data.Product, error)
+		SetCategories(productID int64, categoryIDs []int64) error
+		SetTags(productID int64, tags []string) error
+		LoadLabels(products []*data.Product) error
+	}
+
+	CategoryRepository interface {
+		Repository
+		Insert(category *data.Category) error
+		Get(id int64) (*data.Category, error)
+		GetAll() ([]*data.Category, error)
+		Update(category *data.Category) error
+		IsDescendant(id, ancestorID int64) (bool, error)
 	}
 
 	PermissionRepository interface {
+		Repository
+		Insert(permission *data.Permission) error
+		GetByCode(code string) (*data.Permission, error)
+		GetAll() ([]*data.Permission, error)
 		GetAllForUser(userID int64) (data.Permissions, error)
+		GetGrantedForUser(userID int64) (data.Permissions, error)
 		AddForUser(userID int64, codes ...string) error
+		RemoveForUser(userID int64, codes ...string) error
+	}
+
+	RoleRepository interface {
+		Repository
+		Insert(role *data.Role) error
+		GetByName(name string) (*data.Role, error)
+		GetAll() ([]*data.Role, error)
+		SetPermissions(role *data.Role) error
+	}
+
+	AuditRepository interface {
+		Insert(entry *data.AuditEntry) error
+		GetAll(filter data.AuditFilter, filters data.Filters) ([]*data.AuditEntry, data.Metadata, error)
+	}
+
+	LedgerRepository interface {
+		Append(entry *data.LedgerEntry) error
+		GetAllForUser(userID int64, filters data.Filters) ([]*data.LedgerEntry, data.Metadata, error)
+	}
+
+	PurchaseRepository interface {
+		Insert(purchase *data.Purchase) error
+		Get(id int64) (*data.Purchase, error)
+		GetAllForBuyer(buyerID int64, filters data.Filters) ([]*data.Purchase, data.Metadata, error)
+	}
+
+	RefundRepository interface {
+		Insert(refund *data.Refund) error
+		Get(id int64) (*data.Refund, error)
+		GetForUpdate(id int64) (*data.Refund, error)
+		Update(refund *data.Refund) error
+		GetAll(filter data.RefundFilter, filters data.Filters) ([]*data.Refund, data.Metadata, error)
+		AddEvent(event *data.RefundEvent) error
+		GetEvents(refundID int64) ([]*data.RefundEvent, error)
+	}
+
+	SlotRepository interface {
+		GetAll(machineID int64) ([]*data.Slot, error)
+		GetByCode(machineID int64, code string) (*data.Slot, error)
+		GetByCodeForUpdate(machineID int64, code string) (*data.Slot, error)
+		GetAllForProductForUpdate(machineID, productID int64) ([]*data.Slot, error)
+		Update(slot *data.Slot) error
+	}
+
+	MachineRepository interface {
+		Insert(machine *data.Machine) error
+		Get(id int64) (*data.Machine, error)
+		GetAll() ([]*data.Machine, error)
+		Update(machine *data.Machine) error
+		GetStock(machineID int64) ([]*data.MachineStock, error)
+		GetStockForProduct(productID int64) ([]*data.MachineStock, error)
+		GetQuantityForUpdate(machineID, productID int64) (int, error)
+		AdjustStock(machineID, productID int64, delta int) error
+	}
+
+	ReportRepository interface {
+		ProductSales(sellerID int64) ([]*data.ProductSales, error)
+		RevenueByPeriod(sellerID int64, period string, dateRange data.ReportRange) ([]*data.PeriodRevenue, error)
+	}
+
+	CoinRepository interface {
+		GetInventory(machineID int64) (data.CoinInventory, error)
+		GetInventoryForUpdate(machineID int64) (data.CoinInventory, error)
+		Adjust(machineID int64, coin, delta int) error
+	}
+
+	IdempotencyRepository interface {
+		Insert(key *data.IdempotencyKey) error
+		Get(userID int64, key string) (*data.IdempotencyKey, error)
+		Update(key *data.IdempotencyKey) error
+		Delete(userID int64, key string) error
+		DeleteCreatedBefore(before time.Time) (int64, error)
 	}
 
 	TokenRepository interface {
 		Create(token *data.Token) error
+		GetAllForUser(userID int64, scope string) ([]*data.Token, error)
+		Rotate(hash []byte) (*data.Token, error)
+		GetRotated(hash []byte) (*data.Token, error)
+		DeleteFamily(familyID int64) error
+		DeleteFamilyByHash(hash []byte) error
+		DeleteOtherFamilies(userID int64, hash []byte) error
 		DeleteAllForUserByScope(scope string, userID int64) error
+		Touch(hash []byte, ip string, staleBefore time.Time) error
+	}
+
+	APIKeyRepository interface {
+		Insert(key *data.APIKey) error
+		GetAllForMachine(machineID int64) ([]*data.APIKey, error)
+		GetForKey(keyPlainText string) (*data.APIKey, error)
+		Delete(machineID, id int64) error
 	}
 )
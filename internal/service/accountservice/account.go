@@ -0,0 +1,156 @@
+package accountservice
+
+import (
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/internal/service/auth"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// AccountService lets users take their data with them and delete their
+// account.
+type AccountService interface {
+	Delete(user *data.User, input dto.DeleteAccountRequest) (map[string]string, error)
+	Export(user *data.User) (*data.AccountExport, error)
+}
+
+type accountService struct {
+	users        repository.UserRepository
+	purchases    repository.PurchaseRepository
+	ledger       repository.LedgerRepository
+	tokenService auth.TokenService
+	transactor   repository.Transactor
+	cache        *auth.Cache
+}
+
+func NewAccountService(
+	users repository.UserRepository,
+	purchases repository.PurchaseRepository,
+	ledger repository.LedgerRepository,
+	tokenService auth.TokenService,
+	transactor repository.Transactor,
+	cache *auth.Cache,
+) AccountService {
+	return &accountService{
+		users:        users,
+		purchases:    purchases,
+		ledger:       ledger,
+		tokenService: tokenService,
+		transactor:   transactor,
+		cache:        cache,
+	}
+}
+
+// Delete anonymises the account once the password is confirmed, which signs
+// the user out everywhere. A user holding a deposit has to withdraw it first,
+// the coins would be lost otherwise.
+func (srv *accountService) Delete(user *data.User, input dto.DeleteAccountRequest) (map[string]string, error) {
+	v := validator.New()
+	v.Check(input.Password != "", "password", "must be provided")
+	if !v.Valid() {
+		return v.Errors, nil
+	}
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		current, err := uow.Users().GetForUpdate(user.ID)
+		if err != nil {
+			return err
+		}
+
+		matchPassword, err := current.Password.Matches(input.Password)
+		if err != nil {
+			return err
+		}
+
+		if !matchPassword {
+			v.AddError("password", "is incorrect")
+			return nil
+		}
+
+		if current.Deposit != 0 {
+			v.AddError("deposit", "must be withdrawn before the account can be deleted")
+			return nil
+		}
+
+		if err = uow.Users().Delete(current.ID); err != nil {
+			return err
+		}
+
+		return uow.Audit().Insert(&data.AuditEntry{
+			ActorID:      current.ID,
+			Action:       data.AuditUserDeleted,
+			TargetUserID: current.ID,
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !v.Valid() {
+		return v.Errors, nil
+	}
+
+	srv.cache.ForgetUser(user.ID)
+
+	return nil, nil
+}
+
+// Export collects the user's profile, the metadata of their tokens, their
+// purchases and their ledger entries.
+func (srv *accountService) Export(user *data.User) (*data.AccountExport, error) {
+	current, err := srv.users.GetByID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &data.AccountExport{User: current}
+
+	for _, scope := range []string{data.TokenScopeAuthentication, data.TokenScopeRefresh} {
+		tokens, err := srv.tokenService.ListForUser(user.ID, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		export.Tokens = append(export.Tokens, tokens...)
+	}
+
+	export.Purchases, err = all(func(filters data.Filters) ([]*data.Purchase, data.Metadata, error) {
+		return srv.purchases.GetAllForBuyer(user.ID, filters)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	export.Ledger, err = all(func(filters data.Filters) ([]*data.LedgerEntry, data.Metadata, error) {
+		return srv.ledger.GetAllForUser(user.ID, filters)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// all reads every page of list, oldest record first.
+func all[T any](list func(filters data.Filters) ([]T, data.Metadata, error)) ([]T, error) {
+	filters := data.Filters{Page: 1, PageSize: 100, Sort: "id", SortSafeList: []string{"id"}}
+
+	var records []T
+
+	for {
+		page, metadata, err := list(filters)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, page...)
+
+		if filters.Page >= metadata.LastPage {
+			return records, nil
+		}
+
+		filters.Page++
+	}
+}
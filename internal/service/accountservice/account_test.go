@@ -0,0 +1,115 @@
+package accountservice
+
+import (
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/internal/service/auth"
+	repo "github.com/terdia/mvp/mocks/repository"
+	"github.com/terdia/mvp/pkg/dto"
+)
+
+func TestAccountService_Delete(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+
+	users := repo.NewMockUserRepository(ctrl)
+	audit := repo.NewMockAuditRepository(ctrl)
+
+	uow := repo.NewMockUnitOfWork(ctrl)
+	uow.EXPECT().Users().Return(users).AnyTimes()
+	uow.EXPECT().Audit().Return(audit).AnyTimes()
+
+	transactor := repo.NewMockTransactor(ctrl)
+	transactor.EXPECT().WithinTransaction(gomock.Any()).DoAndReturn(
+		func(fn func(uow repository.UnitOfWork) error) error {
+			return fn(uow)
+		},
+	).AnyTimes()
+
+	aService := NewAccountService(users, nil, nil, nil, transactor, auth.NewCache(10, time.Minute))
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("pa55word"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := dto.DeleteAccountRequest{Password: "pa55word"}
+
+	testCases := map[string]interface{}{
+		"DeletionIsAudited": func() bool {
+			// arrange
+			users.EXPECT().GetForUpdate(int64(2)).Return(&data.User{ID: 2, Password: data.Password{Hash: hash}}, nil)
+			users.EXPECT().Delete(int64(2)).Return(nil)
+			audit.EXPECT().Insert(gomock.Any()).DoAndReturn(func(entry *data.AuditEntry) error {
+				if entry.Action != data.AuditUserDeleted || entry.ActorID != 2 || entry.TargetUserID != 2 {
+					t.Errorf("want user 2 deleting themselves; got %s by %d of %d", entry.Action, entry.ActorID, entry.TargetUserID)
+				}
+				return nil
+			})
+
+			// act
+			validationErrs, err := aService.Delete(&data.User{ID: 2}, input)
+
+			// assert
+			return err == nil && validationErrs == nil
+		},
+		"DepositMustBeWithdrawn": func() bool {
+			// arrange
+			users.EXPECT().GetForUpdate(int64(2)).Return(&data.User{ID: 2, Deposit: 15, Password: data.Password{Hash: hash}}, nil)
+
+			// act
+			validationErrs, err := aService.Delete(&data.User{ID: 2}, input)
+
+			// assert
+			return err == nil && validationErrs["deposit"] != ""
+		},
+		"WrongPassword": func() bool {
+			// arrange
+			users.EXPECT().GetForUpdate(int64(2)).Return(&data.User{ID: 2, Password: data.Password{Hash: hash}}, nil)
+
+			// act
+			validationErrs, err := aService.Delete(&data.User{ID: 2}, dto.DeleteAccountRequest{Password: "password"})
+
+			// assert
+			return err == nil && validationErrs["password"] != ""
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
+
+func TestAll(t *testing.T) {
+
+	// arrange
+	var pages []int
+
+	list := func(filters data.Filters) ([]int, data.Metadata, error) {
+		pages = append(pages, filters.Page)
+		return []int{filters.Page}, data.CalculateMetadata(250, filters.Page, filters.PageSize), nil
+	}
+
+	// act
+	records, err := all(list)
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 3 || len(pages) != 3 || pages[2] != 3 {
+		t.Errorf("want 3 pages read; got records %v from pages %v", records, pages)
+	}
+}
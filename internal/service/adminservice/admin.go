@@ -0,0 +1,294 @@
+package adminservice
+
+import (
+	"errors"
+	"time"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/internal/service/auth"
+	"github.com/terdia/mvp/internal/service/ledger"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// AdminService manages user accounts. Every change is written to the audit log
+// in the same transaction as the change itself.
+type AdminService interface {
+	ListUsers(filter data.UserFilter, filters data.Filters) ([]*data.User, data.Metadata, error)
+	GetUser(id int64) (*data.User, error)
+	ChangeRole(admin *data.User, id int64, input dto.RoleRequest) (*data.User, map[string]string, error)
+	Suspend(admin *data.User, id int64, input dto.SuspendRequest) (*data.User, map[string]string, error)
+	Unsuspend(admin *data.User, id int64, input dto.SuspendRequest) (*data.User, map[string]string, error)
+	AdjustDeposit(admin *data.User, id int64, input dto.DepositAdjustmentRequest) (*data.User, *data.LedgerEntry, map[string]string, error)
+	ListAudit(filter data.AuditFilter, filters data.Filters) ([]*data.AuditEntry, data.Metadata, error)
+}
+
+type adminService struct {
+	users         repository.UserRepository
+	audit         repository.AuditRepository
+	tokenService  auth.TokenService
+	ledgerService ledger.Service
+	transactor    repository.Transactor
+	cache         *auth.Cache
+}
+
+func NewAdminService(
+	users repository.UserRepository,
+	audit repository.AuditRepository,
+	tokenService auth.TokenService,
+	ledgerService ledger.Service,
+	transactor repository.Transactor,
+	cache *auth.Cache,
+) AdminService {
+	return &adminService{
+		users:         users,
+		audit:         audit,
+		tokenService:  tokenService,
+		ledgerService: ledgerService,
+		transactor:    transactor,
+		cache:         cache,
+	}
+}
+
+func (srv *adminService) ListUsers(filter data.UserFilter, filters data.Filters) ([]*data.User, data.Metadata, error) {
+	return srv.users.GetAll(filter, filters)
+}
+
+func (srv *adminService) GetUser(id int64) (*data.User, error) {
+	return srv.users.GetByID(id)
+}
+
+// ChangeRole moves the user to another role, they hold its permissions from
+// their next request on; permissions granted on top of the role are kept. A
+// buyer keeps the role while holding a deposit, they could not spend or
+// withdraw it otherwise.
+func (srv *adminService) ChangeRole(admin *data.User, id int64, input dto.RoleRequest) (*data.User, map[string]string, error) {
+	v := validator.New()
+	v.Check(input.Role != "", "role", "must be provided")
+	v.Check(admin.ID != id, "role", "you can not change your own role")
+	validateReason(v, input.Reason)
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	var user *data.User
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		var err error
+
+		user, err = getForUpdate(uow, id)
+		if err != nil {
+			return err
+		}
+
+		if user.Role == input.Role {
+			return nil
+		}
+
+		if user.IsBuyer() && user.Deposit > 0 {
+			v.AddError("role", "can not change while the buyer holds a deposit")
+			return nil
+		}
+
+		if _, err = uow.Roles().GetByName(input.Role); err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				v.AddError("role", "must be an existing role")
+				return nil
+			}
+
+			return err
+		}
+
+		from := user.Role
+
+		user.Role = input.Role
+		if err = uow.Users().UpdateRole(user); err != nil {
+			return err
+		}
+
+		return uow.Audit().Insert(&data.AuditEntry{
+			ActorID:      admin.ID,
+			Action:       data.AuditUserRoleChanged,
+			TargetUserID: user.ID,
+			Reason:       input.Reason,
+			Details:      map[string]interface{}{"from": from, "to": user.Role},
+		})
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	srv.cache.ForgetUser(user.ID)
+
+	return user, nil, nil
+}
+
+// Suspend blocks the user from logging in and ends their sessions. Suspending
+// a suspended user changes nothing.
+func (srv *adminService) Suspend(admin *data.User, id int64, input dto.SuspendRequest) (*data.User, map[string]string, error) {
+	v := validator.New()
+	v.Check(admin.ID != id, "id", "you can not suspend your own account")
+	validateReason(v, input.Reason)
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	now := time.Now()
+
+	user, changed, err := srv.setSuspendedAt(admin, id, &now, data.AuditUserSuspended, input.Reason)
+	if err != nil || !changed {
+		return user, nil, err
+	}
+
+	for _, scope := range []string{data.TokenScopeRefresh, data.TokenScopeAuthentication} {
+		if err = srv.tokenService.DeleteByUserIdAndScope(user.ID, scope); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return user, nil, nil
+}
+
+// Unsuspend lets a suspended user log in again.
+func (srv *adminService) Unsuspend(admin *data.User, id int64, input dto.SuspendRequest) (*data.User, map[string]string, error) {
+	v := validator.New()
+	if validateReason(v, input.Reason); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	user, _, err := srv.setSuspendedAt(admin, id, nil, data.AuditUserUnsuspended, input.Reason)
+
+	return user, nil, err
+}
+
+func (srv *adminService) setSuspendedAt(admin *data.User, id int64, at *time.Time, action, reason string) (*data.User, bool, error) {
+	var (
+		user    *data.User
+		changed bool
+	)
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		var err error
+
+		user, err = getForUpdate(uow, id)
+		if err != nil {
+			return err
+		}
+
+		if user.IsSuspended() == (at != nil) {
+			return nil
+		}
+
+		user.SuspendedAt = at
+		if err = uow.Users().UpdateSuspendedAt(user); err != nil {
+			return err
+		}
+
+		changed = true
+
+		return uow.Audit().Insert(&data.AuditEntry{
+			ActorID:      admin.ID,
+			Action:       action,
+			TargetUserID: user.ID,
+			Reason:       reason,
+		})
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return user, changed, nil
+}
+
+// AdjustDeposit corrects a buyer's deposit through the ledger, e.g. after a
+// machine kept coins it did not credit. The reason is mandatory and kept in the
+// audit log.
+func (srv *adminService) AdjustDeposit(admin *data.User, id int64, input dto.DepositAdjustmentRequest) (*data.User, *data.LedgerEntry, map[string]string, error) {
+	v := validator.New()
+
+	var (
+		user  *data.User
+		entry *data.LedgerEntry
+	)
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		var err error
+
+		user, err = getForUpdate(uow, id)
+		if err != nil {
+			return err
+		}
+
+		v.Check(user.IsBuyer(), "id", "must be a buyer")
+		data.ValidateDepositAdjustment(v, user.Deposit, input.Amount, input.Reason)
+		if !v.Valid() {
+			return nil
+		}
+
+		// the coins stay in the machine that holds the deposit.
+		machineID := user.DepositMachineID
+		if machineID == 0 {
+			machineID = data.DefaultMachineID
+		}
+
+		from := user.Deposit
+
+		entry, err = srv.ledgerService.Record(uow, user, machineID, data.LedgerEntryAdjustment, input.Amount)
+		if err != nil {
+			return err
+		}
+
+		return uow.Audit().Insert(&data.AuditEntry{
+			ActorID:      admin.ID,
+			Action:       data.AuditDepositAdjusted,
+			TargetUserID: user.ID,
+			Reason:       input.Reason,
+			Details: map[string]interface{}{
+				"amount":          input.Amount,
+				"from":            from,
+				"to":              user.Deposit,
+				"ledger_entry_id": entry.ID,
+			},
+		})
+	})
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, nil, v.Errors, nil
+	}
+
+	return user, entry, nil, nil
+}
+
+func (srv *adminService) ListAudit(filter data.AuditFilter, filters data.Filters) ([]*data.AuditEntry, data.Metadata, error) {
+	return srv.audit.GetAll(filter, filters)
+}
+
+// getForUpdate locks the user an admin acts on. Deleted accounts are only kept
+// for the records that refer to them, so they are not found.
+func getForUpdate(uow repository.UnitOfWork, id int64) (*data.User, error) {
+	user, err := uow.Users().GetForUpdate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsDeleted() {
+		return nil, data.ErrRecordNotFound
+	}
+
+	return user, nil
+}
+
+func validateReason(v *validator.Validator, reason string) {
+	v.Check(len(reason) <= 500, "reason", "must not be more than 500 bytes long")
+}
@@ -0,0 +1,263 @@
+package adminservice
+
+import (
+	"errors"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/internal/service/auth"
+	"github.com/terdia/mvp/internal/service/ledger"
+	repo "github.com/terdia/mvp/mocks/repository"
+	"github.com/terdia/mvp/pkg/dto"
+)
+
+type testRepositories struct {
+	users  *repo.MockUserRepository
+	ledger *repo.MockLedgerRepository
+	roles  *repo.MockRoleRepository
+	audit  *repo.MockAuditRepository
+}
+
+func TestAdminService_AdjustDeposit(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	aService, repos := newTestAdminService(ctrl)
+
+	admin := &data.User{ID: 1, Role: "admin"}
+
+	testCases := map[string]interface{}{
+		"CreditIsAudited": func() bool {
+			// arrange
+			user := &data.User{ID: 2, Role: "buyer", Deposit: 20, DepositMachineID: 3}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+			repos.ledger.EXPECT().Append(gomock.Any()).DoAndReturn(func(entry *data.LedgerEntry) error {
+				if entry.Kind != data.LedgerEntryAdjustment || entry.MachineID != 3 {
+					t.Errorf("want an adjustment in machine 3; got %s in machine %d", entry.Kind, entry.MachineID)
+				}
+				entry.ID = 11
+				entry.BalanceAfter = user.Deposit + entry.Amount
+				return nil
+			})
+			repos.audit.EXPECT().Insert(gomock.Any()).DoAndReturn(func(entry *data.AuditEntry) error {
+				want := map[string]interface{}{"amount": 50, "from": 20, "to": 70, "ledger_entry_id": int64(11)}
+				if diff := cmp.Diff(want, entry.Details); diff != "" || entry.Reason != "coins jammed" || entry.ActorID != admin.ID {
+					t.Errorf("unexpected audit entry %+v: %s", entry, diff)
+				}
+				return nil
+			})
+
+			// act
+			adjusted, entry, validationErrs, err := aService.AdjustDeposit(admin, user.ID, dto.DepositAdjustmentRequest{Amount: 50, Reason: "coins jammed"})
+
+			// assert
+			if err != nil || validationErrs != nil {
+				t.Errorf("unexpected errors: %v %v", err, validationErrs)
+				return false
+			}
+
+			return adjusted.Deposit == 70 && entry.ID == 11
+		},
+		"ReasonAndBalanceValidated": func() bool {
+			// arrange
+			user := &data.User{ID: 2, Role: "buyer", Deposit: 20}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+
+			// act
+			_, _, validationErrs, err := aService.AdjustDeposit(admin, user.ID, dto.DepositAdjustmentRequest{Amount: -25})
+
+			// assert
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return false
+			}
+
+			return validationErrs["amount"] != "" && validationErrs["reason"] != ""
+		},
+		"DeletedAccount": func() bool {
+			// arrange
+			deletedAt := time.Now()
+			user := &data.User{ID: 2, Role: "buyer", DeletedAt: &deletedAt}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+
+			// act
+			_, _, _, err := aService.AdjustDeposit(admin, user.ID, dto.DepositAdjustmentRequest{Amount: 5, Reason: "goodwill"})
+
+			// assert
+			return errors.Is(err, data.ErrRecordNotFound)
+		},
+		"OnlyBuyers": func() bool {
+			// arrange
+			user := &data.User{ID: 2, Role: "seller"}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+
+			// act
+			_, _, validationErrs, err := aService.AdjustDeposit(admin, user.ID, dto.DepositAdjustmentRequest{Amount: 5, Reason: "goodwill"})
+
+			// assert
+			return err == nil && validationErrs["id"] != ""
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
+
+func TestAdminService_ChangeRole(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	aService, repos := newTestAdminService(ctrl)
+
+	admin := &data.User{ID: 1, Role: "admin"}
+
+	testCases := map[string]interface{}{
+		"MovesToRole": func() bool {
+			// arrange
+			user := &data.User{ID: 2, Role: "buyer"}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+			repos.roles.EXPECT().GetByName("seller").Return(&data.Role{ID: 2, Name: "seller"}, nil)
+			repos.users.EXPECT().UpdateRole(user).Return(nil)
+			repos.audit.EXPECT().Insert(gomock.Any()).Return(nil)
+
+			// act
+			changed, validationErrs, err := aService.ChangeRole(admin, user.ID, dto.RoleRequest{Role: "seller"})
+
+			// assert
+			return err == nil && validationErrs == nil && changed.Role == "seller"
+		},
+		"UnknownRole": func() bool {
+			// arrange
+			user := &data.User{ID: 2, Role: "buyer"}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+			repos.roles.EXPECT().GetByName("janitor").Return(nil, data.ErrRecordNotFound)
+
+			// act
+			_, validationErrs, err := aService.ChangeRole(admin, user.ID, dto.RoleRequest{Role: "janitor"})
+
+			// assert
+			return err == nil && validationErrs["role"] != ""
+		},
+		"BuyerWithDeposit": func() bool {
+			// arrange
+			user := &data.User{ID: 2, Role: "buyer", Deposit: 5}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+
+			// act
+			_, validationErrs, err := aService.ChangeRole(admin, user.ID, dto.RoleRequest{Role: "seller"})
+
+			// assert
+			return err == nil && validationErrs["role"] != ""
+		},
+		"DeletedAccount": func() bool {
+			// arrange
+			deletedAt := time.Now()
+			user := &data.User{ID: 2, Role: "buyer", DeletedAt: &deletedAt}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+
+			// act
+			_, _, err := aService.ChangeRole(admin, user.ID, dto.RoleRequest{Role: "seller"})
+
+			// assert
+			return errors.Is(err, data.ErrRecordNotFound)
+		},
+		"OwnRole": func() bool {
+			// act
+			_, validationErrs, err := aService.ChangeRole(admin, admin.ID, dto.RoleRequest{Role: "buyer"})
+
+			// assert
+			return err == nil && validationErrs["role"] != ""
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
+
+func TestAdminService_Suspend(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	aService, repos := newTestAdminService(ctrl)
+
+	admin := &data.User{ID: 1, Role: "admin"}
+
+	testCases := map[string]interface{}{
+		"DeletedAccount": func() bool {
+			// arrange
+			deletedAt := time.Now()
+			user := &data.User{ID: 2, Role: "buyer", DeletedAt: &deletedAt}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+
+			// act
+			_, _, err := aService.Suspend(admin, user.ID, dto.SuspendRequest{Reason: "spam"})
+
+			// assert
+			return errors.Is(err, data.ErrRecordNotFound)
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
+
+func newTestAdminService(ctrl *gomock.Controller) (AdminService, *testRepositories) {
+
+	repos := &testRepositories{
+		users:  repo.NewMockUserRepository(ctrl),
+		ledger: repo.NewMockLedgerRepository(ctrl),
+		roles:  repo.NewMockRoleRepository(ctrl),
+		audit:  repo.NewMockAuditRepository(ctrl),
+	}
+
+	uow := repo.NewMockUnitOfWork(ctrl)
+	uow.EXPECT().Users().Return(repos.users).AnyTimes()
+	uow.EXPECT().Ledger().Return(repos.ledger).AnyTimes()
+	uow.EXPECT().Roles().Return(repos.roles).AnyTimes()
+	uow.EXPECT().Audit().Return(repos.audit).AnyTimes()
+
+	transactor := repo.NewMockTransactor(ctrl)
+	transactor.EXPECT().WithinTransaction(gomock.Any()).DoAndReturn(
+		func(fn func(uow repository.UnitOfWork) error) error {
+			return fn(uow)
+		},
+	).AnyTimes()
+
+	aService := NewAdminService(
+		repos.users,
+		repos.audit,
+		nil,
+		ledger.NewLedgerService(repos.ledger),
+		transactor,
+		auth.NewCache(10, time.Minute),
+	)
+
+	return aService, repos
+}
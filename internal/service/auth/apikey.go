@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// apiKeyPrefixLength is how much of a key is stored in the clear, enough for an
+// operator to tell keys apart without being able to use them.
+const apiKeyPrefixLength = len(data.APIKeyPrefix) + 8
+
+// APIKeyService issues the keys machines authenticate with.
+type APIKeyService interface {
+	Create(machineID int64, input dto.APIKeyRequest) (*data.APIKey, map[string]string, error)
+	List(machineID int64) ([]*data.APIKey, error)
+	Revoke(machineID, id int64) error
+	Authenticate(keyPlainText string) (*data.User, error)
+}
+
+type apiKeyService struct {
+	repo repository.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) APIKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+// Create issues a key for the machine. The returned key is the only one to
+// carry the plaintext.
+func (srv *apiKeyService) Create(machineID int64, input dto.APIKeyRequest) (*data.APIKey, map[string]string, error) {
+	key := &data.APIKey{
+		MachineID: machineID,
+		Name:      input.Name,
+		Scopes:    input.Scopes,
+		Expiry:    input.ExpiresAt,
+	}
+
+	v := validator.New()
+	if key.Validate(v); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	plaintext, hash, err := generateSecret(data.APIKeyPrefix, 20)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key.Plaintext = plaintext
+	key.Prefix = plaintext[:apiKeyPrefixLength]
+	key.Hash = hash
+
+	if err = srv.repo.Insert(key); err != nil {
+		return nil, nil, err
+	}
+
+	return key, nil, nil
+}
+
+func (srv *apiKeyService) List(machineID int64) ([]*data.APIKey, error) {
+	return srv.repo.GetAllForMachine(machineID)
+}
+
+func (srv *apiKeyService) Revoke(machineID, id int64) error {
+	return srv.repo.Delete(machineID, id)
+}
+
+// Authenticate resolves an unexpired key to the machine principal it stands
+// for. Unknown and expired keys return data.ErrRecordNotFound.
+func (srv *apiKeyService) Authenticate(keyPlainText string) (*data.User, error) {
+	key, err := srv.repo.GetForKey(keyPlainText)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.Principal(), nil
+}
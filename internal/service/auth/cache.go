@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/cache"
+)
+
+// cacheStats counts lookups answered from memory and the ones that went to the
+// database, published at /debug/vars.
+var cacheStats = expvar.NewMap("auth_cache")
+
+// Cache keeps the owners of access tokens and the permissions of users in
+// memory, so authenticating a request does not query the database every time.
+// Owners are cached without their deposit, which changes with every purchase.
+// Services call the Forget methods after changing what is cached; entries
+// changed elsewhere, e.g. by another instance, are stale for at most the TTL.
+// It also remembers the address each token was last recorded from, for
+// data.TokenSeenInterval.
+type Cache struct {
+	users       *cache.LRU[string, data.User]
+	permissions *cache.LRU[int64, data.Permissions]
+	seen        *cache.LRU[string, string]
+}
+
+// NewCache holds up to size tokens and size users' permissions. A size or TTL
+// of 0 turns caching off.
+func NewCache(size int, ttl time.Duration) *Cache {
+	seenTTL := data.TokenSeenInterval
+	if ttl <= 0 {
+		seenTTL = 0
+	}
+
+	return &Cache{
+		users:       cache.New[string, data.User](size, ttl),
+		permissions: cache.New[int64, data.Permissions](size, ttl),
+		seen:        cache.New[string, string](size, seenTTL),
+	}
+}
+
+// User returns the owner of the access token. It is a copy, callers may change
+// it freely.
+func (c *Cache) User(token string) (*data.User, bool) {
+	user, ok := c.users.Get(string(HashToken(token)))
+	count("user", ok)
+
+	if !ok {
+		return nil, false
+	}
+
+	return &user, true
+}
+
+// SetUser caches the owner of the token until the token expires at the latest.
+func (c *Cache) SetUser(token string, user *data.User, expiry time.Time) {
+	c.users.SetUntil(string(HashToken(token)), *user, expiry)
+}
+
+// Seen reports whether the token was recorded from ip within
+// data.TokenSeenInterval.
+func (c *Cache) Seen(token, ip string) bool {
+	seenIP, ok := c.seen.Get(string(HashToken(token)))
+
+	return ok && seenIP == ip
+}
+
+// SetSeen notes that the token was just recorded from ip.
+func (c *Cache) SetSeen(token, ip string) {
+	c.seen.Set(string(HashToken(token)), ip)
+}
+
+// Permissions returns the user's permissions, which must not be modified.
+func (c *Cache) Permissions(userID int64) (data.Permissions, bool) {
+	permissions, ok := c.permissions.Get(userID)
+	count("permissions", ok)
+
+	return permissions, ok
+}
+
+func (c *Cache) SetPermissions(userID int64, permissions data.Permissions) {
+	c.permissions.Set(userID, permissions)
+}
+
+// ForgetToken drops the token, e.g. after it was revoked.
+func (c *Cache) ForgetToken(token string) {
+	c.users.Delete(string(HashToken(token)))
+	c.seen.Delete(string(HashToken(token)))
+}
+
+// ForgetUser drops the user's tokens and permissions, e.g. after the user was
+// changed or their tokens deleted.
+func (c *Cache) ForgetUser(userID int64) {
+	c.users.DeleteFunc(func(_ string, user data.User) bool {
+		return user.ID == userID
+	})
+
+	c.permissions.Delete(userID)
+}
+
+// ForgetPermissions drops the permissions of the user.
+func (c *Cache) ForgetPermissions(userID int64) {
+	c.permissions.Delete(userID)
+}
+
+// ForgetAllPermissions drops every user's permissions, e.g. after a role
+// changed.
+func (c *Cache) ForgetAllPermissions() {
+	c.permissions.Purge()
+}
+
+func count(name string, hit bool) {
+	if hit {
+		cacheStats.Add(name+"_hits", 1)
+		return
+	}
+
+	cacheStats.Add(name+"_misses", 1)
+}
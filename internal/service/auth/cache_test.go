@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/terdia/mvp/internal/data"
+)
+
+func TestCache(t *testing.T) {
+
+	const token = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	testCases := map[string]interface{}{
+		"UserIsACopy": func() bool {
+			c := NewCache(10, time.Minute)
+			c.SetUser(token, &data.User{ID: 3, Deposit: 10}, time.Now().Add(time.Hour))
+
+			first, _ := c.User(token)
+			first.Deposit = 0
+
+			second, ok := c.User(token)
+
+			return ok && second.Deposit == 10
+		},
+		"ExpiresWithToken": func() bool {
+			c := NewCache(10, time.Minute)
+			c.SetUser(token, &data.User{ID: 3}, time.Now().Add(-time.Second))
+
+			_, ok := c.User(token)
+
+			return !ok
+		},
+		"ForgetUser": func() bool {
+			c := NewCache(10, time.Minute)
+			c.SetUser(token, &data.User{ID: 3}, time.Now().Add(time.Hour))
+			c.SetUser("ZYXWVUTSRQPONMLKJIHGFEDCBA", &data.User{ID: 4}, time.Now().Add(time.Hour))
+			c.SetPermissions(3, data.Permissions{data.PermissionProductsBuy})
+
+			c.ForgetUser(3)
+
+			_, forgotten := c.User(token)
+			_, permissions := c.Permissions(3)
+			_, kept := c.User("ZYXWVUTSRQPONMLKJIHGFEDCBA")
+
+			return !forgotten && !permissions && kept
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package auth
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base32"
+	"errors"
+	"sort"
 	"time"
 
 	"github.com/terdia/mvp/internal/data"
@@ -12,15 +15,32 @@ import (
 
 type TokenService interface {
 	CreateNew(userId int64, ttl time.Duration, scope string) (*data.Token, error)
+	CreateSession(userId int64) (*data.TokenPair, error)
+	Refresh(refreshPlainText string) (*data.TokenPair, error)
+	ListForUser(userId int64, scope string) ([]*data.Token, error)
+	ListSessions(userId int64, tokenPlainText string) ([]*data.Session, error)
+	Revoke(tokenPlainText string) error
+	RevokeOthers(userId int64, tokenPlainText string) error
 	DeleteByUserIdAndScope(userId int64, scope string) error
+	Touch(tokenPlainText, ip string) error
 }
 
 type tokenService struct {
-	repo repository.TokenRepository
+	repo       repository.TokenRepository
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	cache      *Cache
 }
 
-func NewTokenService(tokenRepository repository.TokenRepository) TokenService {
-	return &tokenService{repo: tokenRepository}
+// NewTokenService returns the token service. Sessions get access tokens that
+// live for accessTTL and refresh tokens that live for refreshTTL. Deleted
+// tokens are dropped from cache.
+func NewTokenService(
+	tokenRepository repository.TokenRepository,
+	accessTTL, refreshTTL time.Duration,
+	cache *Cache,
+) TokenService {
+	return &tokenService{repo: tokenRepository, accessTTL: accessTTL, refreshTTL: refreshTTL, cache: cache}
 }
 
 func (tsrv tokenService) CreateNew(userId int64, ttl time.Duration, scope string) (*data.Token, error) {
@@ -34,8 +54,164 @@ func (tsrv tokenService) CreateNew(userId int64, ttl time.Duration, scope string
 	return token, err
 }
 
+// CreateSession starts a new token family for the user with an access and a
+// refresh token.
+func (tsrv tokenService) CreateSession(userId int64) (*data.TokenPair, error) {
+	return tsrv.issuePair(userId, 0)
+}
+
+// Refresh rotates a refresh token: it can be used once, for a new pair in the
+// same family. Presenting a refresh token that was already rotated means it
+// leaked, so the whole family is revoked and data.ErrTokenReused returned.
+// Unknown and expired tokens return data.ErrRecordNotFound.
+func (tsrv tokenService) Refresh(refreshPlainText string) (*data.TokenPair, error) {
+	hash := HashToken(refreshPlainText)
+
+	rotated, err := tsrv.repo.Rotate(hash)
+	if err != nil {
+		if !errors.Is(err, data.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		reused, err := tsrv.repo.GetRotated(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = tsrv.repo.DeleteFamily(reused.FamilyID); err != nil {
+			return nil, err
+		}
+
+		tsrv.cache.ForgetUser(reused.UserId)
+
+		return nil, data.ErrTokenReused
+	}
+
+	// rotating deleted the family's access tokens.
+	tsrv.cache.ForgetUser(rotated.UserId)
+
+	return tsrv.issuePair(rotated.UserId, rotated.FamilyID)
+}
+
+func (tsrv tokenService) issuePair(userId, familyID int64) (*data.TokenPair, error) {
+	refresh, err := generateToken(userId, tsrv.refreshTTL, data.TokenScopeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh.FamilyID = familyID
+	if err = tsrv.repo.Create(refresh); err != nil {
+		return nil, err
+	}
+
+	access, err := generateToken(userId, tsrv.accessTTL, data.TokenScopeAuthentication)
+	if err != nil {
+		return nil, err
+	}
+
+	access.FamilyID = refresh.FamilyID
+	if err = tsrv.repo.Create(access); err != nil {
+		return nil, err
+	}
+
+	return &data.TokenPair{Access: access, Refresh: refresh}, nil
+}
+
+// ListForUser returns the user's sessions, the unexpired tokens of scope.
+func (tsrv tokenService) ListForUser(userId int64, scope string) ([]*data.Token, error) {
+	return tsrv.repo.GetAllForUser(userId, scope)
+}
+
+// ListSessions returns the user's logins, one per token family that still has
+// a usable refresh token, newest first. The session tokenPlainText belongs to
+// is marked as current.
+func (tsrv tokenService) ListSessions(userId int64, tokenPlainText string) ([]*data.Session, error) {
+	refresh, err := tsrv.repo.GetAllForUser(userId, data.TokenScopeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := tsrv.repo.GetAllForUser(userId, data.TokenScopeAuthentication)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []*data.Session{}
+	families := make(map[int64]*data.Session)
+
+	for _, token := range refresh {
+		if token.RotatedAt != nil {
+			continue
+		}
+
+		session := &data.Session{
+			FamilyID:   token.FamilyID,
+			CreatedAt:  token.CreatedAt,
+			Expiry:     token.Expiry,
+			LastSeenIP: token.LastSeenIP,
+			LastSeenAt: token.LastSeenAt,
+		}
+
+		sessions = append(sessions, session)
+		families[token.FamilyID] = session
+	}
+
+	current := HashToken(tokenPlainText)
+
+	for _, token := range append(refresh, access...) {
+		session, ok := families[token.FamilyID]
+		if !ok {
+			continue
+		}
+
+		if token.CreatedAt.Before(session.CreatedAt) {
+			session.CreatedAt = token.CreatedAt
+		}
+
+		if bytes.Equal(token.Hash, current) {
+			session.Current = true
+		}
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+// Revoke ends the session a token belongs to, deleting every token of its
+// family. An unknown token returns data.ErrRecordNotFound.
+func (tsrv tokenService) Revoke(tokenPlainText string) error {
+	if err := tsrv.repo.DeleteFamilyByHash(HashToken(tokenPlainText)); err != nil {
+		return err
+	}
+
+	tsrv.cache.ForgetToken(tokenPlainText)
+
+	return nil
+}
+
+// RevokeOthers ends every session of the user except the one the token
+// belongs to.
+func (tsrv tokenService) RevokeOthers(userId int64, tokenPlainText string) error {
+	if err := tsrv.repo.DeleteOtherFamilies(userId, HashToken(tokenPlainText)); err != nil {
+		return err
+	}
+
+	tsrv.cache.ForgetUser(userId)
+
+	return nil
+}
+
 func (tsrv tokenService) DeleteByUserIdAndScope(userId int64, scope string) error {
-	return tsrv.repo.DeleteAllForUserByScope(scope, userId)
+	if err := tsrv.repo.DeleteAllForUserByScope(scope, userId); err != nil {
+		return err
+	}
+
+	tsrv.cache.ForgetUser(userId)
+
+	return nil
 }
 
 func generateToken(userId int64, ttl time.Duration, scope string) (*data.Token, error) {
@@ -46,20 +222,55 @@ func generateToken(userId int64, ttl time.Duration, scope string) (*data.Token,
 		Scope:  scope,
 	}
 
-	randomBytes := make([]byte, 16)
+	plaintext, hash, err := generateSecret("", 16)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Plaintext = plaintext
+	token.Hash = hash
+
+	return token, nil
+}
+
+// generateSecret returns prefix followed by n random bytes as unpadded base32,
+// together with the SHA-256 hash of that text, which is all that gets stored.
+func generateSecret(prefix string, n int) (string, []byte, error) {
+
+	randomBytes := make([]byte, n)
 
 	// fill the byte slice with random bytes from your os CSPRNG.
 	_, err := rand.Read(randomBytes)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
-	token.Plaintext = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+	plaintext := prefix + base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
 
-	hash := sha256.Sum256([]byte(token.Plaintext))
+	return plaintext, HashToken(plaintext), nil
+}
 
-	//convert it to a slice using the [:] operator
-	token.Hash = hash[:]
+// Touch records that the token was used from ip. Tokens seen from the same
+// address within data.TokenSeenInterval are skipped without a query.
+func (tsrv tokenService) Touch(tokenPlainText, ip string) error {
+	if tsrv.cache.Seen(tokenPlainText, ip) {
+		return nil
+	}
 
-	return token, nil
+	hash := HashToken(tokenPlainText)
+	if err := tsrv.repo.Touch(hash, ip, time.Now().Add(-data.TokenSeenInterval)); err != nil {
+		return err
+	}
+
+	tsrv.cache.SetSeen(tokenPlainText, ip)
+
+	return nil
+}
+
+// HashToken returns the SHA-256 hash a token or key is stored under.
+func HashToken(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+
+	//convert it to a slice using the [:] operator
+	return hash[:]
 }
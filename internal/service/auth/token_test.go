@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/terdia/mvp/internal/data"
+	repo "github.com/terdia/mvp/mocks/repository"
+)
+
+func TestTokenService_Refresh(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	tokens := repo.NewMockTokenRepository(ctrl)
+	tService := NewTokenService(tokens, 15*time.Minute, 7*24*time.Hour, NewCache(10, time.Minute))
+
+	const refreshToken = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	testCases := map[string]interface{}{
+		"RotatesIntoSameFamily": func() bool {
+			// arrange
+			tokens.EXPECT().Rotate(HashToken(refreshToken)).Return(&data.Token{UserId: 3, FamilyID: 9}, nil)
+			tokens.EXPECT().Create(gomock.Any()).DoAndReturn(func(token *data.Token) error {
+				if token.UserId != 3 || token.FamilyID != 9 {
+					t.Errorf("want token for user 3 in family 9; got user %d family %d", token.UserId, token.FamilyID)
+				}
+				return nil
+			}).Times(2)
+
+			// act
+			pair, err := tService.Refresh(refreshToken)
+
+			// assert
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return false
+			}
+
+			if pair.Access.Scope != data.TokenScopeAuthentication || pair.Refresh.Scope != data.TokenScopeRefresh {
+				t.Errorf("want an access and a refresh token; got %s and %s", pair.Access.Scope, pair.Refresh.Scope)
+				return false
+			}
+
+			if !pair.Access.Expiry.Before(pair.Refresh.Expiry) {
+				t.Errorf("want the access token to expire first; got %v and %v", pair.Access.Expiry, pair.Refresh.Expiry)
+				return false
+			}
+
+			return pair.Refresh.Plaintext != refreshToken
+		},
+		"ReuseRevokesFamily": func() bool {
+			// arrange
+			tokens.EXPECT().Rotate(HashToken(refreshToken)).Return(nil, data.ErrRecordNotFound)
+			tokens.EXPECT().GetRotated(HashToken(refreshToken)).Return(&data.Token{UserId: 3, FamilyID: 9}, nil)
+			tokens.EXPECT().DeleteFamily(int64(9)).Return(nil)
+
+			// act
+			pair, err := tService.Refresh(refreshToken)
+
+			// assert
+			return pair == nil && errors.Is(err, data.ErrTokenReused)
+		},
+		"UnknownToken": func() bool {
+			// arrange
+			tokens.EXPECT().Rotate(HashToken(refreshToken)).Return(nil, data.ErrRecordNotFound)
+			tokens.EXPECT().GetRotated(HashToken(refreshToken)).Return(nil, data.ErrRecordNotFound)
+
+			// act
+			pair, err := tService.Refresh(refreshToken)
+
+			// assert
+			return pair == nil && errors.Is(err, data.ErrRecordNotFound)
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
+
+func TestTokenService_Touch(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	tokens := repo.NewMockTokenRepository(ctrl)
+
+	const accessToken = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	testCases := map[string]interface{}{
+		"SameAddressWritesOnce": func() bool {
+			// arrange
+			tService := NewTokenService(tokens, 15*time.Minute, 7*24*time.Hour, NewCache(10, time.Minute))
+			tokens.EXPECT().Touch(HashToken(accessToken), "10.0.0.1", gomock.Any()).Return(nil)
+
+			// act
+			first := tService.Touch(accessToken, "10.0.0.1")
+			second := tService.Touch(accessToken, "10.0.0.1")
+
+			// assert
+			return first == nil && second == nil
+		},
+		"NewAddressWritesAgain": func() bool {
+			// arrange
+			tService := NewTokenService(tokens, 15*time.Minute, 7*24*time.Hour, NewCache(10, time.Minute))
+			tokens.EXPECT().Touch(HashToken(accessToken), "10.0.0.1", gomock.Any()).Return(nil)
+			tokens.EXPECT().Touch(HashToken(accessToken), "10.0.0.2", gomock.Any()).Return(nil)
+
+			// act
+			first := tService.Touch(accessToken, "10.0.0.1")
+			second := tService.Touch(accessToken, "10.0.0.2")
+
+			// assert
+			return first == nil && second == nil
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
+
+func TestTokenService_ListSessions(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	tokens := repo.NewMockTokenRepository(ctrl)
+	tService := NewTokenService(tokens, 15*time.Minute, 7*24*time.Hour, NewCache(10, time.Minute))
+
+	const accessToken = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	login := time.Now().Add(-2 * time.Hour)
+	rotated := login.Add(time.Hour)
+
+	testCases := map[string]interface{}{
+		"AccessTokenExpiredRefreshTokenLive": func() bool {
+			// arrange
+			tokens.EXPECT().GetAllForUser(int64(3), data.TokenScopeRefresh).Return([]*data.Token{
+				{ID: 12, FamilyID: 9, CreatedAt: rotated, Expiry: rotated.Add(7 * 24 * time.Hour)},
+				{ID: 10, FamilyID: 9, CreatedAt: login, Expiry: login.Add(7 * 24 * time.Hour), RotatedAt: &rotated},
+			}, nil)
+			tokens.EXPECT().GetAllForUser(int64(3), data.TokenScopeAuthentication).Return([]*data.Token{}, nil)
+
+			// act
+			sessions, err := tService.ListSessions(3, accessToken)
+
+			// assert
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return false
+			}
+
+			if len(sessions) != 1 {
+				t.Errorf("want 1 session; got %d", len(sessions))
+				return false
+			}
+
+			session := sessions[0]
+			if session.FamilyID != 9 || !session.CreatedAt.Equal(login) || !session.Expiry.Equal(rotated.Add(7*24*time.Hour)) || session.Current {
+				t.Errorf("want family 9 since the login, expiring with the live refresh token; got %+v", session)
+				return false
+			}
+
+			return true
+		},
+		"OneEntryPerFamily": func() bool {
+			// arrange
+			tokens.EXPECT().GetAllForUser(int64(3), data.TokenScopeRefresh).Return([]*data.Token{
+				{ID: 14, FamilyID: 11, CreatedAt: rotated, Expiry: rotated.Add(time.Hour)},
+				{ID: 12, FamilyID: 9, CreatedAt: login, Expiry: login.Add(time.Hour)},
+			}, nil)
+			tokens.EXPECT().GetAllForUser(int64(3), data.TokenScopeAuthentication).Return([]*data.Token{
+				{ID: 15, FamilyID: 11, Hash: HashToken(accessToken), CreatedAt: rotated},
+				{ID: 13, FamilyID: 9, CreatedAt: login},
+				{ID: 8, FamilyID: 4, CreatedAt: login},
+			}, nil)
+
+			// act
+			sessions, err := tService.ListSessions(3, accessToken)
+
+			// assert
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return false
+			}
+
+			if len(sessions) != 2 {
+				t.Errorf("want 2 sessions; got %d", len(sessions))
+				return false
+			}
+
+			if sessions[0].FamilyID != 11 || !sessions[0].Current || sessions[1].FamilyID != 9 || sessions[1].Current {
+				t.Errorf("want the current family 11 and then family 9; got %+v and %+v", sessions[0], sessions[1])
+				return false
+			}
+
+			return true
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
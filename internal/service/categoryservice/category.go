@@ -0,0 +1,162 @@
+package categoryservice
+
+import (
+	"errors"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// CategoryService manages the category tree products are browsed by.
+type CategoryService interface {
+	List() ([]*data.Category, error)
+	Get(id int64) (*data.Category, error)
+	Create(input dto.CategoryRequest) (*data.Category, map[string]string, error)
+	Update(id int64, input dto.CategoryRequest) (*data.Category, map[string]string, error)
+	Remove(id int64) (map[string]string, error)
+}
+
+type categoryService struct {
+	repo repository.CategoryRepository
+}
+
+func NewCategoryService(repo repository.CategoryRepository) CategoryService {
+	return &categoryService{repo: repo}
+}
+
+func (srv *categoryService) List() ([]*data.Category, error) {
+	return srv.repo.GetAll()
+}
+
+func (srv *categoryService) Get(id int64) (*data.Category, error) {
+	return srv.repo.Get(id)
+}
+
+func (srv *categoryService) Create(input dto.CategoryRequest) (*data.Category, map[string]string, error) {
+	category := &data.Category{}
+	apply(category, input)
+
+	v := validator.New()
+	if category.Validate(v); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	if err := srv.checkParent(v, category); err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return srv.save(v, category, srv.repo.Insert)
+}
+
+// Update applies the fields set in input. Moving a category below one of its
+// own sub categories is rejected.
+func (srv *categoryService) Update(id int64, input dto.CategoryRequest) (*data.Category, map[string]string, error) {
+	category, err := srv.repo.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	apply(category, input)
+
+	v := validator.New()
+	if category.Validate(v); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	if err = srv.checkParent(v, category); err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return srv.save(v, category, srv.repo.Update)
+}
+
+// Remove deletes the category. Products in it are unlinked; categories that
+// still have sub categories can not be removed.
+func (srv *categoryService) Remove(id int64) (map[string]string, error) {
+	err := srv.repo.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrCategoryInUse):
+			v := validator.New()
+			v.AddError("category", "move or remove its sub categories first")
+			return v.Errors, nil
+		default:
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+func (srv *categoryService) checkParent(v *validator.Validator, category *data.Category) error {
+	if category.ParentID == 0 {
+		return nil
+	}
+
+	_, err := srv.repo.Get(category.ParentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("parent_id", "must be an existing category")
+			return nil
+		default:
+			return err
+		}
+	}
+
+	if category.ID == 0 {
+		return nil
+	}
+
+	cycle, err := srv.repo.IsDescendant(category.ParentID, category.ID)
+	if err != nil {
+		return err
+	}
+
+	v.Check(!cycle, "parent_id", "must not be one of the category's own sub categories")
+
+	return nil
+}
+
+func (srv *categoryService) save(
+	v *validator.Validator,
+	category *data.Category,
+	write func(*data.Category) error,
+) (*data.Category, map[string]string, error) {
+
+	if err := write(category); err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateCategory):
+			v.AddError("slug", err.Error())
+			return nil, v.Errors, nil
+		default:
+			return nil, nil, err
+		}
+	}
+
+	return category, nil, nil
+}
+
+func apply(category *data.Category, input dto.CategoryRequest) {
+	if input.Name != nil {
+		category.Name = *input.Name
+	}
+
+	if input.Slug != nil {
+		category.Slug = *input.Slug
+	}
+
+	if input.ParentID != nil {
+		category.ParentID = *input.ParentID
+	}
+}
@@ -0,0 +1,87 @@
+package coinservice
+
+import (
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// CoinService keeps track of the coin float held by each machine.
+type CoinService interface {
+	Inventory(machineID int64) (data.CoinInventory, error)
+	Refill(machineID int64, coin, quantity int) (data.CoinInventory, data.ValidationErrors, error)
+	Insert(uow repository.UnitOfWork, machineID int64, coin int) error
+	Change(uow repository.UnitOfWork, machineID int64, amount int) ([]int, bool, error)
+	PayOut(uow repository.UnitOfWork, machineID int64, coins []int) error
+}
+
+type coinService struct {
+	repo repository.CoinRepository
+}
+
+func NewCoinService(repo repository.CoinRepository) CoinService {
+	return &coinService{repo: repo}
+}
+
+func (c *coinService) Inventory(machineID int64) (data.CoinInventory, error) {
+	return c.repo.GetInventory(machineID)
+}
+
+// Refill tops up the float with coins loaded into the machine by an operator.
+func (c *coinService) Refill(machineID int64, coin, quantity int) (data.CoinInventory, data.ValidationErrors, error) {
+
+	v := validator.New()
+	v.Check(validator.In(coin, data.Coins), "coin", "must be one of 5, 10, 20, 50 or 100")
+	v.Check(quantity > 0, "quantity", "must be greater than zero")
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	if err := c.repo.Adjust(machineID, coin, quantity); err != nil {
+		return nil, nil, err
+	}
+
+	inventory, err := c.repo.GetInventory(machineID)
+
+	return inventory, nil, err
+}
+
+// Insert adds a coin a buyer put into the machine.
+func (c *coinService) Insert(uow repository.UnitOfWork, machineID int64, coin int) error {
+	return uow.Coins().Adjust(machineID, coin, 1)
+}
+
+// Change locks the float and works out how amount can be paid back from it,
+// without taking any coins out.
+func (c *coinService) Change(uow repository.UnitOfWork, machineID int64, amount int) ([]int, bool, error) {
+
+	inventory, err := uow.Coins().GetInventoryForUpdate(machineID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	change, ok := inventory.MakeChange(amount)
+
+	return change, ok, nil
+}
+
+// PayOut takes the given coins out of the float.
+func (c *coinService) PayOut(uow repository.UnitOfWork, machineID int64, coins []int) error {
+
+	count := make(map[int]int)
+	for _, coin := range coins {
+		count[coin]++
+	}
+
+	for _, coin := range data.Coins {
+		if count[coin] == 0 {
+			continue
+		}
+
+		if err := uow.Coins().Adjust(machineID, coin, -count[coin]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package idempotency
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+// keyTTL is how long a stored response is replayed before the key can be reused.
+const keyTTL = 24 * time.Hour
+
+type Service interface {
+	Begin(userID int64, key string, fingerprint []byte) (record *data.IdempotencyKey, replay bool, err error)
+	Complete(record *data.IdempotencyKey) error
+	Release(record *data.IdempotencyKey) error
+	PurgeExpired() (int64, error)
+}
+
+type idempotencyService struct {
+	repo repository.IdempotencyRepository
+}
+
+func NewIdempotencyService(repo repository.IdempotencyRepository) Service {
+	return &idempotencyService{repo: repo}
+}
+
+// Begin claims key for the user. When the key has already completed for the same
+// request the stored record is returned with replay set, a different request
+// fingerprint gives data.ErrIdempotencyKeyMismatch and a request still in flight
+// gives data.ErrIdempotencyKeyInProgress.
+func (srv *idempotencyService) Begin(userID int64, key string, fingerprint []byte) (*data.IdempotencyKey, bool, error) {
+
+	record := &data.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+	}
+
+	err := srv.repo.Insert(record)
+	if err == nil {
+		return record, false, nil
+	}
+
+	if !errors.Is(err, data.ErrDuplicateIdempotencyKey) {
+		return nil, false, err
+	}
+
+	existing, err := srv.repo.Get(userID, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if time.Since(existing.CreatedAt) > keyTTL {
+		if err = srv.repo.Delete(userID, key); err != nil {
+			return nil, false, err
+		}
+
+		return srv.Begin(userID, key, fingerprint)
+	}
+
+	if !bytes.Equal(existing.Fingerprint, fingerprint) {
+		return nil, false, data.ErrIdempotencyKeyMismatch
+	}
+
+	if !existing.Completed() {
+		return nil, false, data.ErrIdempotencyKeyInProgress
+	}
+
+	return existing, true, nil
+}
+
+// Complete stores the response so later retries with the same key replay it.
+func (srv *idempotencyService) Complete(record *data.IdempotencyKey) error {
+	return srv.repo.Update(record)
+}
+
+// Release gives the key up again, used when the request failed in a way the
+// client is expected to retry.
+func (srv *idempotencyService) Release(record *data.IdempotencyKey) error {
+	return srv.repo.Delete(record.UserID, record.Key)
+}
+
+// PurgeExpired deletes the keys that are no longer replayed and returns how
+// many were deleted.
+func (srv *idempotencyService) PurgeExpired() (int64, error) {
+	return srv.repo.DeleteCreatedBefore(time.Now().Add(-keyTTL))
+}
@@ -0,0 +1,50 @@
+package ledger
+
+import (
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+// Service is the only way a user's balance changes. Every movement is written
+// as an entry and users.deposit follows from it.
+type Service interface {
+	Record(uow repository.UnitOfWork, user *data.User, machineID int64, kind string, amount int) (*data.LedgerEntry, error)
+	ListForUser(userID int64, filters data.Filters) ([]*data.LedgerEntry, data.Metadata, error)
+}
+
+type ledgerService struct {
+	repo repository.LedgerRepository
+}
+
+func NewLedgerService(repo repository.LedgerRepository) Service {
+	return &ledgerService{repo: repo}
+}
+
+// Record appends a signed entry for the user inside the given unit of work and
+// refreshes user.Deposit with the resulting balance, which is then held by
+// machineID.
+func (l *ledgerService) Record(uow repository.UnitOfWork, user *data.User, machineID int64, kind string, amount int) (*data.LedgerEntry, error) {
+
+	entry := &data.LedgerEntry{
+		UserID:    user.ID,
+		MachineID: machineID,
+		Kind:      kind,
+		Amount:    amount,
+	}
+
+	if err := uow.Ledger().Append(entry); err != nil {
+		return nil, err
+	}
+
+	user.Deposit = entry.BalanceAfter
+	user.DepositMachineID = machineID
+	if user.Deposit == 0 {
+		user.DepositMachineID = 0
+	}
+
+	return entry, nil
+}
+
+func (l *ledgerService) ListForUser(userID int64, filters data.Filters) ([]*data.LedgerEntry, data.Metadata, error) {
+	return l.repo.GetAllForUser(userID, filters)
+}
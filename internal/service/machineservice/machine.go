@@ -0,0 +1,195 @@
+package machineservice
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// MachineService manages the fleet of machines and how product stock is
+// spread across them.
+type MachineService interface {
+	List() ([]*data.Machine, error)
+	Get(id int64) (*data.Machine, error)
+	Create(input dto.MachineRequest) (*data.Machine, map[string]string, error)
+	Update(id int64, input dto.MachineRequest) (*data.Machine, map[string]string, error)
+	Stock(machineID int64) ([]*data.MachineStock, error)
+	StockForProduct(seller *data.User, productID int64) ([]*data.MachineStock, error)
+	Restock(seller *data.User, machineID, productID int64, quantity int) (*data.MachineStock, map[string]string, error)
+}
+
+type machineService struct {
+	repo       repository.MachineRepository
+	transactor repository.Transactor
+}
+
+func NewMachineService(repo repository.MachineRepository, transactor repository.Transactor) MachineService {
+	return &machineService{repo: repo, transactor: transactor}
+}
+
+func (srv *machineService) List() ([]*data.Machine, error) {
+	return srv.repo.GetAll()
+}
+
+func (srv *machineService) Get(id int64) (*data.Machine, error) {
+	return srv.repo.Get(id)
+}
+
+func (srv *machineService) Create(input dto.MachineRequest) (*data.Machine, map[string]string, error) {
+	machine := &data.Machine{}
+	apply(machine, input)
+
+	return srv.save(machine, srv.repo.Insert)
+}
+
+func (srv *machineService) Update(id int64, input dto.MachineRequest) (*data.Machine, map[string]string, error) {
+	machine, err := srv.repo.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	apply(machine, input)
+
+	return srv.save(machine, srv.repo.Update)
+}
+
+func (srv *machineService) Stock(machineID int64) ([]*data.MachineStock, error) {
+	return srv.repo.GetStock(machineID)
+}
+
+// StockForProduct splits one of the seller's products by machine. Products of
+// other sellers are reported as data.ErrRecordNotFound.
+func (srv *machineService) StockForProduct(seller *data.User, productID int64) ([]*data.MachineStock, error) {
+	var stock []*data.MachineStock
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		product, err := uow.Products().GetIncludingArchived(productID)
+		if err != nil {
+			return err
+		}
+
+		if product.Seller.ID != seller.ID {
+			return data.ErrRecordNotFound
+		}
+
+		stock, err = uow.Machines().GetStockForProduct(product.ID)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return stock, nil
+}
+
+// Restock sets how many units of one of the seller's products the machine
+// holds. The product's amount available moves by the same number of units.
+// The new quantity may not be less than what is already loaded into the
+// machine's slots.
+func (srv *machineService) Restock(seller *data.User, machineID, productID int64, quantity int) (*data.MachineStock, map[string]string, error) {
+
+	v := validator.New()
+	v.Check(quantity >= 0, "quantity", "must not be negative")
+	v.Check(quantity <= 10_000, "quantity", "must not be more than 10000")
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	stock := &data.MachineStock{MachineID: machineID, ProductID: productID, Quantity: quantity}
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		machine, err := uow.Machines().Get(machineID)
+		if err != nil {
+			return err
+		}
+
+		product, err := uow.Products().GetForUpdate(productID)
+		if err != nil {
+			return err
+		}
+
+		if product.Seller.ID != seller.ID {
+			return data.ErrNoPermission
+		}
+
+		current, err := uow.Machines().GetQuantityForUpdate(machine.ID, product.ID)
+		if err != nil {
+			return err
+		}
+
+		slots, err := uow.Slots().GetAllForProductForUpdate(machine.ID, product.ID)
+		if err != nil {
+			return err
+		}
+
+		loaded := 0
+		for _, slot := range slots {
+			loaded = loaded + slot.Quantity
+		}
+
+		v.Check(
+			quantity >= loaded,
+			"quantity",
+			fmt.Sprintf("must not be less than the %d units loaded into the machine's slots", loaded),
+		)
+		if !v.Valid() {
+			return nil
+		}
+
+		stock.MachineName = machine.Name
+		stock.ProductName = product.Name
+		stock.ProductCost = product.Cost
+
+		if quantity == current {
+			return nil
+		}
+
+		return uow.Machines().AdjustStock(machine.ID, product.ID, quantity-current)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return stock, nil, nil
+}
+
+func (srv *machineService) save(machine *data.Machine, write func(*data.Machine) error) (*data.Machine, map[string]string, error) {
+
+	v := validator.New()
+	if machine.Validate(v); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	if err := write(machine); err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateMachine):
+			v.AddError("name", err.Error())
+			return nil, v.Errors, nil
+		default:
+			return nil, nil, err
+		}
+	}
+
+	return machine, nil, nil
+}
+
+func apply(machine *data.Machine, input dto.MachineRequest) {
+	if input.Name != nil {
+		machine.Name = *input.Name
+	}
+
+	if input.Location != nil {
+		machine.Location = *input.Location
+	}
+}
@@ -0,0 +1,443 @@
+package permissionservice
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/internal/service/auth"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// PermissionService manages permission codes, the roles bundling them and the
+// permissions granted to users directly. Every change is written to the audit
+// log in the same transaction as the change itself, and applies to the next
+// request the affected users make.
+type PermissionService interface {
+	List() ([]*data.Permission, error)
+	Create(admin *data.User, input dto.PermissionRequest) (*data.Permission, map[string]string, error)
+	Remove(admin *data.User, code string) (map[string]string, error)
+
+	ListRoles() ([]*data.Role, error)
+	GetRole(name string) (*data.Role, error)
+	CreateRole(admin *data.User, input dto.CreateRoleRequest) (*data.Role, map[string]string, error)
+	SetRolePermissions(admin *data.User, name string, input dto.RolePermissionsRequest) (*data.Role, map[string]string, error)
+	RemoveRole(admin *data.User, name string) (map[string]string, error)
+
+	GetForUser(id int64) (*data.UserPermissions, error)
+	Grant(admin *data.User, id int64, code string) (*data.UserPermissions, map[string]string, error)
+	Revoke(admin *data.User, id int64, code string) (*data.UserPermissions, map[string]string, error)
+}
+
+type permissionService struct {
+	permissions repository.PermissionRepository
+	roles       repository.RoleRepository
+	users       repository.UserRepository
+	transactor  repository.Transactor
+	cache       *auth.Cache
+}
+
+func NewPermissionService(
+	permissions repository.PermissionRepository,
+	roles repository.RoleRepository,
+	users repository.UserRepository,
+	transactor repository.Transactor,
+	cache *auth.Cache,
+) PermissionService {
+	return &permissionService{
+		permissions: permissions,
+		roles:       roles,
+		users:       users,
+		transactor:  transactor,
+		cache:       cache,
+	}
+}
+
+func (srv *permissionService) List() ([]*data.Permission, error) {
+	return srv.permissions.GetAll()
+}
+
+func (srv *permissionService) Create(admin *data.User, input dto.PermissionRequest) (*data.Permission, map[string]string, error) {
+	permission := &data.Permission{
+		Code:        input.Code,
+		Description: input.Description,
+	}
+
+	v := validator.New()
+	if permission.Validate(v); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		if err := uow.Permissions().Insert(permission); err != nil {
+			if errors.Is(err, data.ErrDuplicatePermission) {
+				v.AddError("code", err.Error())
+				return nil
+			}
+
+			return err
+		}
+
+		return uow.Audit().Insert(&data.AuditEntry{
+			ActorID: admin.ID,
+			Action:  data.AuditPermissionCreated,
+			Details: map[string]interface{}{"code": permission.Code},
+		})
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return permission, nil, nil
+}
+
+// Remove deletes the permission and takes it away from every role and user
+// holding it. The permissions the API checks itself can not be removed.
+func (srv *permissionService) Remove(admin *data.User, code string) (map[string]string, error) {
+	v := validator.New()
+	if v.Check(!data.BuiltinPermissions.Includes(code), "code", "is built in and can not be removed"); !v.Valid() {
+		return v.Errors, nil
+	}
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		permission, err := uow.Permissions().GetByCode(code)
+		if err != nil {
+			return err
+		}
+
+		if err = uow.Permissions().Delete(permission.ID); err != nil {
+			return err
+		}
+
+		return uow.Audit().Insert(&data.AuditEntry{
+			ActorID: admin.ID,
+			Action:  data.AuditPermissionDeleted,
+			Details: map[string]interface{}{"code": permission.Code},
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	srv.cache.ForgetAllPermissions()
+
+	return nil, nil
+}
+
+func (srv *permissionService) ListRoles() ([]*data.Role, error) {
+	return srv.roles.GetAll()
+}
+
+func (srv *permissionService) GetRole(name string) (*data.Role, error) {
+	return srv.roles.GetByName(name)
+}
+
+func (srv *permissionService) CreateRole(admin *data.User, input dto.CreateRoleRequest) (*data.Role, map[string]string, error) {
+	role := &data.Role{
+		Name:        input.Name,
+		Permissions: input.Permissions,
+	}
+
+	if role.Permissions == nil {
+		role.Permissions = data.Permissions{}
+	}
+
+	v := validator.New()
+	if role.Validate(v); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		if err := checkCodes(uow, v, role.Permissions); err != nil || !v.Valid() {
+			return err
+		}
+
+		if err := uow.Roles().Insert(role); err != nil {
+			if errors.Is(err, data.ErrDuplicateRole) {
+				v.AddError("name", err.Error())
+				return nil
+			}
+
+			return err
+		}
+
+		if err := uow.Roles().SetPermissions(role); err != nil {
+			return err
+		}
+
+		return uow.Audit().Insert(&data.AuditEntry{
+			ActorID: admin.ID,
+			Action:  data.AuditRoleCreated,
+			Details: map[string]interface{}{"role": role.Name, "permissions": role.Permissions},
+		})
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return role, nil, nil
+}
+
+// SetRolePermissions replaces the permissions of the role for every user
+// holding it. An admin can not take permissions:write away from their own role,
+// they would lock themselves out of undoing it.
+func (srv *permissionService) SetRolePermissions(
+	admin *data.User,
+	name string,
+	input dto.RolePermissionsRequest,
+) (*data.Role, map[string]string, error) {
+
+	codes := data.Permissions(input.Permissions)
+	if codes == nil {
+		codes = data.Permissions{}
+	}
+
+	v := validator.New()
+	data.ValidatePermissionCodes(v, codes)
+	v.Check(
+		name != admin.Role || codes.Includes(data.PermissionPermissionsWrite),
+		"permissions",
+		fmt.Sprintf("must keep %s on your own role", data.PermissionPermissionsWrite),
+	)
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	var role *data.Role
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		var err error
+
+		role, err = uow.Roles().GetByName(name)
+		if err != nil {
+			return err
+		}
+
+		if err = checkCodes(uow, v, codes); err != nil || !v.Valid() {
+			return err
+		}
+
+		from := role.Permissions
+
+		role.Permissions = codes
+		if err = uow.Roles().SetPermissions(role); err != nil {
+			return err
+		}
+
+		return uow.Audit().Insert(&data.AuditEntry{
+			ActorID: admin.ID,
+			Action:  data.AuditRoleChanged,
+			Details: map[string]interface{}{"role": role.Name, "from": from, "to": role.Permissions},
+		})
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	srv.cache.ForgetAllPermissions()
+
+	return role, nil, nil
+}
+
+// RemoveRole deletes a role nobody holds any more. The built in roles can not
+// be removed.
+func (srv *permissionService) RemoveRole(admin *data.User, name string) (map[string]string, error) {
+	v := validator.New()
+	if v.Check(!data.IsBuiltinRole(name), "role", "is built in and can not be removed"); !v.Valid() {
+		return v.Errors, nil
+	}
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		role, err := uow.Roles().GetByName(name)
+		if err != nil {
+			return err
+		}
+
+		if err = uow.Roles().Delete(role.ID); err != nil {
+			if errors.Is(err, data.ErrRoleInUse) {
+				v.AddError("role", "is still held by users, give them another role first")
+				return nil
+			}
+
+			return err
+		}
+
+		return uow.Audit().Insert(&data.AuditEntry{
+			ActorID: admin.ID,
+			Action:  data.AuditRoleDeleted,
+			Details: map[string]interface{}{"role": role.Name, "permissions": role.Permissions},
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !v.Valid() {
+		return v.Errors, nil
+	}
+
+	return nil, nil
+}
+
+func (srv *permissionService) GetForUser(id int64) (*data.UserPermissions, error) {
+	user, err := srv.users.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return getForUser(srv.roles, srv.permissions, user)
+}
+
+// Grant gives the user a permission on top of the ones of their role.
+func (srv *permissionService) Grant(admin *data.User, id int64, code string) (*data.UserPermissions, map[string]string, error) {
+	return srv.changeGrant(admin, id, code, true)
+}
+
+// Revoke takes a directly granted permission away. Permissions that come with
+// the user's role stay until the role or the user's role is changed.
+func (srv *permissionService) Revoke(admin *data.User, id int64, code string) (*data.UserPermissions, map[string]string, error) {
+	return srv.changeGrant(admin, id, code, false)
+}
+
+func (srv *permissionService) changeGrant(admin *data.User, id int64, code string, grant bool) (*data.UserPermissions, map[string]string, error) {
+	v := validator.New()
+	if v.Check(admin.ID != id, "id", "you can not change your own permissions"); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	var permissions *data.UserPermissions
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		user, err := uow.Users().GetForUpdate(id)
+		if err != nil {
+			return err
+		}
+
+		if _, err = uow.Permissions().GetByCode(code); err != nil {
+			return err
+		}
+
+		permissions, err = getForUser(uow.Roles(), uow.Permissions(), user)
+		if err != nil {
+			return err
+		}
+
+		if permissions.Granted.Includes(code) == grant {
+			if !grant {
+				v.Check(!permissions.Role.Permissions.Includes(code), "code", "comes with the user's role, change the role instead")
+			}
+			return nil
+		}
+
+		action := data.AuditPermissionGranted
+
+		if grant {
+			err = uow.Permissions().AddForUser(user.ID, code)
+			permissions.Granted = append(permissions.Granted, code)
+		} else {
+			action = data.AuditPermissionRevoked
+			err = uow.Permissions().RemoveForUser(user.ID, code)
+			permissions.Granted = remove(permissions.Granted, code)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return uow.Audit().Insert(&data.AuditEntry{
+			ActorID:      admin.ID,
+			Action:       action,
+			TargetUserID: user.ID,
+			Details:      map[string]interface{}{"code": code},
+		})
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	srv.cache.ForgetPermissions(id)
+
+	return permissions, nil, nil
+}
+
+func getForUser(
+	roles repository.RoleRepository,
+	permissions repository.PermissionRepository,
+	user *data.User,
+) (*data.UserPermissions, error) {
+
+	role, err := roles.GetByName(user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := permissions.GetGrantedForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if granted == nil {
+		granted = data.Permissions{}
+	}
+
+	return &data.UserPermissions{UserID: user.ID, Role: role, Granted: granted}, nil
+}
+
+// checkCodes adds a validation error for codes that are not permissions.
+func checkCodes(uow repository.UnitOfWork, v *validator.Validator, codes data.Permissions) error {
+	if len(codes) == 0 {
+		return nil
+	}
+
+	existing, err := uow.Permissions().GetAll()
+	if err != nil {
+		return err
+	}
+
+	known := make(data.Permissions, 0, len(existing))
+	for _, permission := range existing {
+		known = append(known, permission.Code)
+	}
+
+	for _, code := range codes {
+		v.Check(known.Includes(code), "permissions", fmt.Sprintf("%s is not a permission", code))
+	}
+
+	return nil
+}
+
+func remove(codes data.Permissions, code string) data.Permissions {
+	kept := data.Permissions{}
+	for _, c := range codes {
+		if c != code {
+			kept = append(kept, c)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,175 @@
+package permissionservice
+
+import (
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/internal/service/auth"
+	repo "github.com/terdia/mvp/mocks/repository"
+	"github.com/terdia/mvp/pkg/dto"
+)
+
+func TestPermissionService_GrantAndRevoke(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+
+	users := repo.NewMockUserRepository(ctrl)
+	permissions := repo.NewMockPermissionRepository(ctrl)
+	roles := repo.NewMockRoleRepository(ctrl)
+	audit := repo.NewMockAuditRepository(ctrl)
+	pService := NewPermissionService(
+		permissions,
+		roles,
+		users,
+		newTestTransactor(ctrl, users, permissions, roles, audit),
+		auth.NewCache(10, time.Minute),
+	)
+
+	admin := &data.User{ID: 1, Role: "admin"}
+	buyer := &data.User{ID: 2, Role: "buyer"}
+	buyerRole := &data.Role{ID: 1, Name: "buyer", Permissions: data.Permissions{data.PermissionProductsRead, data.PermissionProductsBuy}}
+
+	testCases := map[string]interface{}{
+		"GrantIsAudited": func() bool {
+			// arrange
+			users.EXPECT().GetForUpdate(buyer.ID).Return(buyer, nil)
+			permissions.EXPECT().GetByCode(data.PermissionRefundsManage).Return(&data.Permission{ID: 4, Code: data.PermissionRefundsManage}, nil)
+			roles.EXPECT().GetByName("buyer").Return(buyerRole, nil)
+			permissions.EXPECT().GetGrantedForUser(buyer.ID).Return(nil, nil)
+			permissions.EXPECT().AddForUser(buyer.ID, data.PermissionRefundsManage).Return(nil)
+			audit.EXPECT().Insert(gomock.Any()).DoAndReturn(func(entry *data.AuditEntry) error {
+				if entry.Action != data.AuditPermissionGranted || entry.TargetUserID != buyer.ID {
+					t.Errorf("want a grant to user %d; got %s to user %d", buyer.ID, entry.Action, entry.TargetUserID)
+				}
+				return nil
+			})
+
+			// act
+			held, validationErrs, err := pService.Grant(admin, buyer.ID, data.PermissionRefundsManage)
+
+			// assert
+			if err != nil || validationErrs != nil {
+				t.Errorf("unexpected errors: %v %v", err, validationErrs)
+				return false
+			}
+
+			want := data.Permissions{data.PermissionProductsRead, data.PermissionProductsBuy, data.PermissionRefundsManage}
+			if diff := cmp.Diff(want, held.All()); diff != "" {
+				t.Errorf("unexpected permissions: %s", diff)
+				return false
+			}
+
+			return true
+		},
+		"RevokeRolePermission": func() bool {
+			// arrange
+			users.EXPECT().GetForUpdate(buyer.ID).Return(buyer, nil)
+			permissions.EXPECT().GetByCode(data.PermissionProductsBuy).Return(&data.Permission{ID: 2, Code: data.PermissionProductsBuy}, nil)
+			roles.EXPECT().GetByName("buyer").Return(buyerRole, nil)
+			permissions.EXPECT().GetGrantedForUser(buyer.ID).Return(nil, nil)
+
+			// act
+			_, validationErrs, err := pService.Revoke(admin, buyer.ID, data.PermissionProductsBuy)
+
+			// assert
+			return err == nil && validationErrs["code"] != ""
+		},
+		"OwnPermissions": func() bool {
+			// act
+			_, validationErrs, err := pService.Grant(admin, admin.ID, data.PermissionRefundsManage)
+
+			// assert
+			return err == nil && validationErrs["id"] != ""
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
+
+func TestPermissionService_SetRolePermissions(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+
+	users := repo.NewMockUserRepository(ctrl)
+	permissions := repo.NewMockPermissionRepository(ctrl)
+	roles := repo.NewMockRoleRepository(ctrl)
+	audit := repo.NewMockAuditRepository(ctrl)
+	pService := NewPermissionService(
+		permissions,
+		roles,
+		users,
+		newTestTransactor(ctrl, users, permissions, roles, audit),
+		auth.NewCache(10, time.Minute),
+	)
+
+	admin := &data.User{ID: 1, Role: "admin"}
+
+	testCases := map[string]interface{}{
+		"UnknownPermission": func() bool {
+			// arrange
+			roles.EXPECT().GetByName("seller").Return(&data.Role{ID: 2, Name: "seller"}, nil)
+			permissions.EXPECT().GetAll().Return([]*data.Permission{{Code: data.PermissionProductsRead}}, nil)
+
+			// act
+			_, validationErrs, err := pService.SetRolePermissions(admin, "seller", dto.RolePermissionsRequest{
+				Permissions: []string{data.PermissionProductsRead, "reports:read"},
+			})
+
+			// assert
+			return err == nil && validationErrs["permissions"] != ""
+		},
+		"LockOut": func() bool {
+			// act
+			_, validationErrs, err := pService.SetRolePermissions(admin, "admin", dto.RolePermissionsRequest{
+				Permissions: []string{data.PermissionUsersRead},
+			})
+
+			// assert
+			return err == nil && validationErrs["permissions"] != ""
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
+
+func newTestTransactor(
+	ctrl *gomock.Controller,
+	users repository.UserRepository,
+	permissions repository.PermissionRepository,
+	roles repository.RoleRepository,
+	audit repository.AuditRepository,
+) repository.Transactor {
+
+	uow := repo.NewMockUnitOfWork(ctrl)
+	uow.EXPECT().Users().Return(users).AnyTimes()
+	uow.EXPECT().Permissions().Return(permissions).AnyTimes()
+	uow.EXPECT().Roles().Return(roles).AnyTimes()
+	uow.EXPECT().Audit().Return(audit).AnyTimes()
+
+	transactor := repo.NewMockTransactor(ctrl)
+	transactor.EXPECT().WithinTransaction(gomock.Any()).DoAndReturn(
+		func(fn func(uow repository.UnitOfWork) error) error {
+			return fn(uow)
+		},
+	).AnyTimes()
+
+	return transactor
+}
@@ -2,6 +2,7 @@ package productservice
 
 import (
 	"errors"
+	"time"
 
 	"github.com/terdia/mvp/internal/data"
 	"github.com/terdia/mvp/internal/repository"
@@ -10,11 +11,14 @@ import (
 )
 
 type productService struct {
-	repo repository.ProductRepository
+	repo         repository.ProductRepository
+	cursorSecret []byte
 }
 
-func NewProductService(repo repository.ProductRepository) ProductService {
-	return &productService{repo: repo}
+// NewProductService returns the product service. cursorSecret signs the
+// pagination cursors handed to clients.
+func NewProductService(repo repository.ProductRepository, cursorSecret []byte) ProductService {
+	return &productService{repo: repo, cursorSecret: cursorSecret}
 }
 
 func (p *productService) Create(product *data.Product) (map[string]string, error) {
@@ -39,11 +43,103 @@ func (p *productService) Create(product *data.Product) (map[string]string, error
 }
 
 func (p *productService) List(r dto.ListProductRequest) ([]*data.Product, data.Metadata, error) {
-	return p.repo.GetAll(r)
+	products, metadata, err := p.repo.GetAll(r)
+	if err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	if err = p.repo.LoadLabels(products); err != nil {
+		return nil, data.Metadata{}, err
+	}
+
+	return products, metadata, nil
+}
+
+// ListByCursor returns one page of products after (or before) the given cursor.
+// An empty cursor starts at the first page. Cursors are only valid for the sort
+// they were issued with.
+func (p *productService) ListByCursor(r dto.ListProductRequest, token string) ([]*data.Product, data.CursorMetadata, map[string]string, error) {
+
+	metadata := data.CursorMetadata{PageSize: r.Filters.PageSize}
+
+	var cursor data.Cursor
+	if token != "" {
+		var err error
+
+		v := validator.New()
+		cursor, err = data.DecodeCursor(p.cursorSecret, token)
+		v.Check(err == nil, "cursor", "is invalid")
+		if err == nil {
+			v.Check(cursor.Sort == r.Filters.Sort, "cursor", "was issued for a different sort")
+		}
+
+		if !v.Valid() {
+			return nil, metadata, v.Errors, nil
+		}
+	}
+
+	// one extra row tells whether there is another page in this direction.
+	products, err := p.repo.GetAllByCursor(r, cursor, r.Filters.PageSize+1)
+	if err != nil {
+		return nil, metadata, nil, err
+	}
+
+	more := len(products) > r.Filters.PageSize
+	if more {
+		if cursor.Before {
+			products = products[1:]
+		} else {
+			products = products[:r.Filters.PageSize]
+		}
+	}
+
+	if len(products) == 0 {
+		return products, metadata, nil, nil
+	}
+
+	if err = p.repo.LoadLabels(products); err != nil {
+		return nil, metadata, nil, err
+	}
+
+	hasNext := more || cursor.Before
+	hasPrev := (more && cursor.Before) || (!cursor.Before && !cursor.IsStart())
+
+	column := r.Filters.SortColumn()
+
+	if hasNext {
+		last := products[len(products)-1]
+		metadata.NextCursor, err = data.EncodeCursor(p.cursorSecret, data.Cursor{
+			Sort:  r.Filters.Sort,
+			Value: last.SortKey(column),
+			ID:    last.ID,
+		})
+		if err != nil {
+			return nil, metadata, nil, err
+		}
+	}
+
+	if hasPrev {
+		first := products[0]
+		metadata.PrevCursor, err = data.EncodeCursor(p.cursorSecret, data.Cursor{
+			Sort:   r.Filters.Sort,
+			Value:  first.SortKey(column),
+			ID:     first.ID,
+			Before: true,
+		})
+		if err != nil {
+			return nil, metadata, nil, err
+		}
+	}
+
+	return products, metadata, nil, nil
 }
 
 func (p *productService) GetOne(id int64) (*data.Product, error) {
-	return p.repo.Get(id)
+	return p.withLabels(p.repo.Get(id))
+}
+
+func (p *productService) GetOneIncludingArchived(id int64) (*data.Product, error) {
+	return p.withLabels(p.repo.GetIncludingArchived(id))
 }
 
 func (p *productService) Update(request data.Product) (*data.Product, map[string]string, error) {
@@ -58,17 +154,62 @@ func (p *productService) Update(request data.Product) (*data.Product, map[string
 		return nil, nil, err
 	}
 
-	if err = p.repo.Update(product); err != nil {
+	fromQuantity := product.AmountAvailable
+
+	product.Name = request.Name
+	product.Cost = request.Cost
+	product.AmountAvailable = request.AmountAvailable
+
+	return p.save(v, product, fromQuantity)
+}
+
+// Patch applies only the fields set in input. A non-zero version must match the
+// stored product, otherwise data.ErrEditConflict is returned.
+func (p *productService) Patch(id int64, seller data.User, version int32, input dto.UpdateProductRequest) (*data.Product, map[string]string, error) {
+	product, err := p.getForUser(data.Product{ID: id, Seller: seller, Version: version})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fromQuantity := product.AmountAvailable
+
+	if input.Name != nil {
+		product.Name = *input.Name
+	}
+
+	if input.Cost != nil {
+		product.Cost = *input.Cost
+	}
+
+	if input.Quantity != nil {
+		product.AmountAvailable = *input.Quantity
+	}
+
+	v := validator.New()
+	if product.Validate(v); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return p.save(v, product, fromQuantity)
+}
+
+func (p *productService) save(v *validator.Validator, product *data.Product, fromQuantity int) (*data.Product, map[string]string, error) {
+	if err := p.repo.Update(product, fromQuantity); err != nil {
 		switch {
 		case errors.Is(err, data.ErrDuplicateProductName):
 			v.AddError("name", err.Error())
 			return nil, v.Errors, nil
+		case errors.Is(err, data.ErrMachineStock):
+			v.AddError("amount_available", "must not be less than the units stocked in other machines or loaded into slots")
+			return nil, v.Errors, nil
 		default:
 			return nil, nil, err
 		}
 	}
 
-	return product, nil, nil
+	product, err := p.withLabels(product, nil)
+
+	return product, nil, err
 }
 
 func (p *productService) Remove(request data.Product) error {
@@ -80,6 +221,107 @@ func (p *productService) Remove(request data.Product) error {
 	return p.repo.Delete(product.ID)
 }
 
+// Restore un-archives one of the seller's products. Restoring a product that is
+// not archived leaves it as it is.
+func (p *productService) Restore(request data.Product) (*data.Product, map[string]string, error) {
+	product, err := p.GetOneIncludingArchived(request.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if product.Seller.ID != request.Seller.ID {
+		return nil, nil, data.ErrNoPermission
+	}
+
+	if !product.IsArchived() {
+		return product, nil, nil
+	}
+
+	if err = p.repo.Restore(product); err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateProductName):
+			v := validator.New()
+			v.AddError("name", err.Error())
+			return nil, v.Errors, nil
+		default:
+			return nil, nil, err
+		}
+	}
+
+	return product, nil, nil
+}
+
+// PurgeArchived permanently removes products that have been archived for longer
+// than retention.
+func (p *productService) PurgeArchived(retention time.Duration) (int64, error) {
+	return p.repo.PurgeArchived(time.Now().Add(-retention))
+}
+
+// SetCategories replaces the categories of one of the seller's products.
+func (p *productService) SetCategories(request data.Product, categoryIDs []int64) (*data.Product, map[string]string, error) {
+	v := validator.New()
+	if data.ValidateCategoryIDs(v, categoryIDs); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	product, err := p.getForUser(request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = p.repo.SetCategories(product.ID, categoryIDs); err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("category_ids", "must only contain existing categories")
+			return nil, v.Errors, nil
+		default:
+			return nil, nil, err
+		}
+	}
+
+	product, err = p.withLabels(product, nil)
+
+	return product, nil, err
+}
+
+// SetTags replaces the tags of one of the seller's products. Tags are
+// lowercased before they are stored.
+func (p *productService) SetTags(request data.Product, tags []string) (*data.Product, map[string]string, error) {
+	tags = data.NormaliseTags(tags)
+
+	v := validator.New()
+	if data.ValidateTags(v, tags); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	product, err := p.getForUser(request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = p.repo.SetTags(product.ID, tags); err != nil {
+		return nil, nil, err
+	}
+
+	product, err = p.withLabels(product, nil)
+
+	return product, nil, err
+}
+
+// withLabels loads the categories and tags of product, passing err through so
+// it can wrap a repository call.
+func (p *productService) withLabels(product *data.Product, err error) (*data.Product, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.repo.LoadLabels([]*data.Product{product}); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
 func (p *productService) getForUser(request data.Product) (*data.Product, error) {
 	product, err := p.GetOne(request.ID)
 	if err != nil {
@@ -90,9 +332,9 @@ func (p *productService) getForUser(request data.Product) (*data.Product, error)
 		return nil, data.ErrNoPermission
 	}
 
-	product.Name = request.Name
-	product.Cost = request.Cost
-	product.AmountAvailable = request.AmountAvailable
+	if request.Version != 0 && request.Version != product.Version {
+		return nil, data.ErrEditConflict
+	}
 
 	return product, nil
 }
@@ -1,6 +1,8 @@
 package productservice
 
 import (
+	"time"
+
 	"github.com/terdia/mvp/internal/data"
 	"github.com/terdia/mvp/pkg/dto"
 )
@@ -8,7 +10,14 @@ import (
 type ProductService interface {
 	Create(*data.Product) (map[string]string, error)
 	GetOne(int64) (*data.Product, error)
+	GetOneIncludingArchived(int64) (*data.Product, error)
 	Update(data.Product) (*data.Product, map[string]string, error)
+	Patch(id int64, seller data.User, version int32, input dto.UpdateProductRequest) (*data.Product, map[string]string, error)
 	Remove(data.Product) error
+	Restore(data.Product) (*data.Product, map[string]string, error)
+	SetCategories(request data.Product, categoryIDs []int64) (*data.Product, map[string]string, error)
+	SetTags(request data.Product, tags []string) (*data.Product, map[string]string, error)
+	PurgeArchived(retention time.Duration) (int64, error)
 	List(dto.ListProductRequest) ([]*data.Product, data.Metadata, error)
+	ListByCursor(r dto.ListProductRequest, cursor string) ([]*data.Product, data.CursorMetadata, map[string]string, error)
 }
@@ -0,0 +1,39 @@
+package purchaseservice
+
+import (
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+// PurchaseService reads back the receipts written by the transaction service.
+type PurchaseService interface {
+	ListForBuyer(buyerID int64, filters data.Filters) ([]*data.Purchase, data.Metadata, error)
+	GetForBuyer(buyerID, id int64) (*data.Purchase, error)
+}
+
+type purchaseService struct {
+	repo repository.PurchaseRepository
+}
+
+func NewPurchaseService(repo repository.PurchaseRepository) PurchaseService {
+	return &purchaseService{repo: repo}
+}
+
+func (p *purchaseService) ListForBuyer(buyerID int64, filters data.Filters) ([]*data.Purchase, data.Metadata, error) {
+	return p.repo.GetAllForBuyer(buyerID, filters)
+}
+
+// GetForBuyer returns data.ErrRecordNotFound for purchases made by someone
+// else, so receipt ids can not be probed.
+func (p *purchaseService) GetForBuyer(buyerID, id int64) (*data.Purchase, error) {
+	purchase, err := p.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if purchase.BuyerID != buyerID {
+		return nil, data.ErrRecordNotFound
+	}
+
+	return purchase, nil
+}
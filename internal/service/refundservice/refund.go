@@ -0,0 +1,46 @@
+package refundservice
+
+import (
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+)
+
+// RefundService reads back refunds; they are opened and resolved through the
+// transaction service.
+type RefundService interface {
+	List(filter data.RefundFilter, filters data.Filters) ([]*data.Refund, data.Metadata, error)
+	GetForUser(userID int64, manager bool, id int64) (*data.Refund, []*data.RefundEvent, error)
+}
+
+type refundService struct {
+	repo repository.RefundRepository
+}
+
+func NewRefundService(repo repository.RefundRepository) RefundService {
+	return &refundService{repo: repo}
+}
+
+func (srv *refundService) List(filter data.RefundFilter, filters data.Filters) ([]*data.Refund, data.Metadata, error) {
+	return srv.repo.GetAll(filter, filters)
+}
+
+// GetForUser returns the refund with its history. Unless manager is set,
+// refunds the user is neither the buyer nor the seller of are reported as
+// data.ErrRecordNotFound.
+func (srv *refundService) GetForUser(userID int64, manager bool, id int64) (*data.Refund, []*data.RefundEvent, error) {
+	refund, err := srv.repo.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !manager && refund.BuyerID != userID && refund.SellerID != userID {
+		return nil, nil, data.ErrRecordNotFound
+	}
+
+	events, err := srv.repo.GetEvents(refund.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return refund, events, nil
+}
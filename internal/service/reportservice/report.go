@@ -0,0 +1,45 @@
+package reportservice
+
+import (
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// ReportService builds the sales figures a seller sees on their dashboard.
+type ReportService interface {
+	ProductSales(sellerID int64) ([]*data.ProductSales, error)
+	Revenue(sellerID int64, period string, dateRange data.ReportRange) ([]*data.PeriodRevenue, data.ValidationErrors, error)
+}
+
+type reportService struct {
+	repo repository.ReportRepository
+}
+
+func NewReportService(repo repository.ReportRepository) ReportService {
+	return &reportService{repo: repo}
+}
+
+func (srv *reportService) ProductSales(sellerID int64) ([]*data.ProductSales, error) {
+	return srv.repo.ProductSales(sellerID)
+}
+
+func (srv *reportService) Revenue(
+	sellerID int64,
+	period string,
+	dateRange data.ReportRange,
+) ([]*data.PeriodRevenue, data.ValidationErrors, error) {
+
+	v := validator.New()
+	v.Check(validator.In(period, data.ReportPeriods), "period", "must be day, week or month")
+	if !dateRange.From.IsZero() && !dateRange.To.IsZero() {
+		v.Check(dateRange.From.Before(dateRange.To), "from", "must be before to")
+	}
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	revenue, err := srv.repo.RevenueByPeriod(sellerID, period, dateRange)
+
+	return revenue, nil, err
+}
@@ -0,0 +1,170 @@
+package slotservice
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/pkg/dto"
+	"github.com/terdia/mvp/pkg/validator"
+)
+
+// SlotService maps products onto the physical slots of a machine. Sellers
+// load their own products into free slots; operators may load any product and
+// change slot capacities.
+type SlotService interface {
+	List(machineID int64) ([]*data.Slot, error)
+	Get(machineID int64, code string) (*data.Slot, error)
+	Assign(actor *data.User, operator bool, machineID int64, code string, input dto.SlotRequest) (*data.Slot, map[string]string, error)
+	Clear(actor *data.User, operator bool, machineID int64, code string) (*data.Slot, error)
+}
+
+type slotService struct {
+	repo       repository.SlotRepository
+	transactor repository.Transactor
+}
+
+func NewSlotService(repo repository.SlotRepository, transactor repository.Transactor) SlotService {
+	return &slotService{repo: repo, transactor: transactor}
+}
+
+func (srv *slotService) List(machineID int64) ([]*data.Slot, error) {
+	return srv.repo.GetAll(machineID)
+}
+
+func (srv *slotService) Get(machineID int64, code string) (*data.Slot, error) {
+	return srv.repo.GetByCode(machineID, code)
+}
+
+// Assign loads input.Quantity units of a product into the slot. A slot still
+// holding units of another product must be cleared first, and the units loaded
+// across all of a product's slots in the machine may not exceed the stock the
+// machine holds.
+func (srv *slotService) Assign(actor *data.User, operator bool, machineID int64, code string, input dto.SlotRequest) (*data.Slot, map[string]string, error) {
+
+	v := validator.New()
+	v.Check(input.ProductID > 0, "product_id", "must be provided")
+	v.Check(operator || input.Capacity == nil, "capacity", "only machine operators can change the slot capacity")
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	var slot *data.Slot
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+
+		// lock the product before its slots, in the same order as a purchase.
+		product, err := uow.Products().GetForUpdate(input.ProductID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				v.AddError("product_id", "must be an existing product")
+				return nil
+			default:
+				return err
+			}
+		}
+
+		if !operator && product.Seller.ID != actor.ID {
+			return data.ErrNoPermission
+		}
+
+		inMachine, err := uow.Machines().GetQuantityForUpdate(machineID, product.ID)
+		if err != nil {
+			return err
+		}
+
+		loaded, err := uow.Slots().GetAllForProductForUpdate(machineID, product.ID)
+		if err != nil {
+			return err
+		}
+
+		slot, err = uow.Slots().GetByCodeForUpdate(machineID, code)
+		if err != nil {
+			return err
+		}
+
+		v.Check(
+			slot.ProductID == 0 || slot.ProductID == product.ID || slot.Quantity == 0,
+			"slot",
+			fmt.Sprintf("slot %s still holds another product, clear it first", slot.Code),
+		)
+
+		slot.ProductID = product.ID
+		slot.Quantity = input.Quantity
+		if input.Capacity != nil {
+			slot.Capacity = *input.Capacity
+		}
+
+		if slot.Validate(v); !v.Valid() {
+			return nil
+		}
+
+		total := slot.Quantity
+		for _, other := range loaded {
+			if other.ID != slot.ID {
+				total = total + other.Quantity
+			}
+		}
+
+		v.Check(
+			total <= inMachine,
+			"quantity",
+			fmt.Sprintf("the machine holds only %d units of the product across all of its slots", inMachine),
+		)
+		if !v.Valid() {
+			return nil
+		}
+
+		return uow.Slots().Update(slot)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return slot, nil, nil
+}
+
+// Clear empties the slot. Sellers may only clear slots holding their own
+// products.
+func (srv *slotService) Clear(actor *data.User, operator bool, machineID int64, code string) (*data.Slot, error) {
+
+	var slot *data.Slot
+
+	err := srv.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		var err error
+
+		slot, err = uow.Slots().GetByCodeForUpdate(machineID, code)
+		if err != nil {
+			return err
+		}
+
+		if slot.ProductID != 0 && !operator {
+			product, err := uow.Products().GetIncludingArchived(slot.ProductID)
+			if err != nil {
+				return err
+			}
+
+			if product.Seller.ID != actor.ID {
+				return data.ErrNoPermission
+			}
+		}
+
+		slot.ProductID = 0
+		slot.Quantity = 0
+
+		return uow.Slots().Update(slot)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return slot, nil
+}
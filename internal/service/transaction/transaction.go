@@ -1,83 +1,193 @@
 package transaction
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/terdia/mvp/internal/data"
-	"github.com/terdia/mvp/internal/service/productservice"
-	"github.com/terdia/mvp/internal/service/userservice"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/internal/service/coinservice"
+	"github.com/terdia/mvp/internal/service/ledger"
 	"github.com/terdia/mvp/pkg/dto"
 	"github.com/terdia/mvp/pkg/validator"
 )
 
 type Service interface {
-	BuyProduct(*data.User, *data.Product, int) (*dto.BuyProductResponse, data.ValidationErrors, error)
-	DepositCoin(*data.User, int) (data.ValidationErrors, error)
-	DepositReset(*data.User) (data.ValidationErrors, error)
+	BuyProduct(user *data.User, machineID int64, product *data.Product, quantity int, slotCode string) (*dto.BuyProductResponse, data.ValidationErrors, error)
+	DepositCoin(user *data.User, machineID int64, coin int) (data.ValidationErrors, error)
+	DepositReset(*data.User) ([]int, data.ValidationErrors, error)
+	ReturnCoins(user *data.User, machineID int64) ([]int, data.ValidationErrors, error)
+	Checkout(user *data.User, machineID int64, lines []dto.CartLine) (*dto.CheckoutResponse, data.ValidationErrors, error)
+	RequestRefund(buyer *data.User, purchaseID int64, reason string) (*data.Refund, data.ValidationErrors, error)
+	ApproveRefund(actor *data.User, manager bool, refundID int64, note string) (*data.Refund, data.ValidationErrors, error)
+	RejectRefund(actor *data.User, manager bool, refundID int64, note string) (*data.Refund, data.ValidationErrors, error)
 }
 
+// maxCartLines caps how many distinct products a single checkout may hold.
+const maxCartLines = 50
+
 type transactionService struct {
-	userService    userservice.UserService
-	productService productservice.ProductService
+	transactor    repository.Transactor
+	ledgerService ledger.Service
+	coinService   coinservice.CoinService
 }
 
-func NewTransactionService(userService userservice.UserService, productService productservice.ProductService) Service {
+func NewTransactionService(
+	transactor repository.Transactor,
+	ledgerService ledger.Service,
+	coinService coinservice.CoinService,
+) Service {
 
 	return &transactionService{
-		userService:    userService,
-		productService: productService,
+		transactor:    transactor,
+		ledgerService: ledgerService,
+		coinService:   coinService,
 	}
 }
 
-func (t *transactionService) BuyProduct(user *data.User, product *data.Product, quantity int) (*dto.BuyProductResponse, data.ValidationErrors, error) {
+// BuyProduct debits the buyer and decrements the product stock of the machine
+// in a single database transaction. Both rows are re-read with FOR UPDATE so
+// the balance and stock checks are made against the locked values rather than
+// the caller's copies. The units are dispensed from slotCode, or from the
+// product's fullest slot in the machine when slotCode is empty. The remaining
+// balance is paid out as change, leaving the deposit empty.
+func (t *transactionService) BuyProduct(user *data.User, machineID int64, product *data.Product, quantity int, slotCode string) (*dto.BuyProductResponse, data.ValidationErrors, error) {
 
 	v := validator.New()
 
 	// check quantity
 	v.Check(quantity > 0, "product", "purchase quantity must be greater zero")
-	v.Check(
-		product.AmountAvailable >= quantity,
-		"product",
-		fmt.Sprintf("not enough quantity only %d remaining", product.AmountAvailable),
-	)
-	// check if user has enough money for this transaction
-	v.Check(user.Deposit >= (product.Cost*quantity), "product", "you do not have sufficient balance")
+	if slotCode != "" {
+		data.ValidateSlotCode(v, "slot", slotCode)
+	}
+
 	if !v.Valid() {
 		return nil, v.Errors, nil
 	}
 
-	//reduce product quantity
-	product.AmountAvailable = product.AmountAvailable - quantity
-	updatedProduct, validationErrs, err := t.productService.Update(*product)
-	if validationErrs != nil || err != nil {
-		return nil, validationErrs, err
-	}
+	var purchase *dto.BuyProductResponse
+
+	err := t.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+
+		// always lock the buyer, then the product, then its machine stock and
+		// slots so concurrent purchases acquire row locks in the same order.
+		buyer, err := uow.Users().GetForUpdate(user.ID)
+		if err != nil {
+			return err
+		}
+
+		stock, err := uow.Products().GetForUpdate(product.ID)
+		if err != nil {
+			return err
+		}
+
+		inMachine, err := uow.Machines().GetQuantityForUpdate(machineID, stock.ID)
+		if err != nil {
+			return err
+		}
+
+		cost := stock.Cost * quantity
+
+		checkDepositMachine(v, buyer, machineID)
+		v.Check(
+			inMachine >= quantity,
+			"product",
+			fmt.Sprintf("not enough quantity only %d remaining", inMachine),
+		)
+
+		slot, err := pickSlot(uow, v, "slot", machineID, stock, quantity, slotCode)
+		if err != nil {
+			return err
+		}
+
+		// check if user has enough money for this transaction
+		v.Check(buyer.Deposit >= cost, "product", "you do not have sufficient balance")
+		if !v.Valid() {
+			return nil
+		}
+
+		change, ok, err := t.coinService.Change(uow, machineID, buyer.Deposit-cost)
+		if err != nil {
+			return err
+		}
+
+		if v.Check(ok, "change", "the machine cannot give exact change for your remaining balance"); !v.Valid() {
+			return nil
+		}
+
+		//reduce product quantity
+		if err = takeStock(uow, machineID, stock, quantity); err != nil {
+			return err
+		}
+
+		if err = dispense(uow, slot, quantity); err != nil {
+			return err
+		}
+
+		//spent
+		if _, err = t.ledgerService.Record(uow, buyer, machineID, data.LedgerEntryPurchase, -cost); err != nil {
+			return err
+		}
+
+		paid, err := t.payOut(uow, buyer, machineID, change)
+		if err != nil {
+			return err
+		}
+
+		receipt := &data.Purchase{
+			BuyerID:     buyer.ID,
+			SellerID:    stock.Seller.ID,
+			MachineID:   machineID,
+			ProductID:   stock.ID,
+			ProductName: stock.Name,
+			ProductCost: stock.Cost,
+			Quantity:    quantity,
+			AmountSpent: cost,
+			Change:      paid,
+			SlotCode:    slotCodeOf(slot),
+		}
+		if err = uow.Purchases().Insert(receipt); err != nil {
+			return err
+		}
+
+		*user = *buyer
+		*product = *stock
+
+		purchase = &dto.BuyProductResponse{
+			PurchaseID:  receipt.ID,
+			AmountSpent: cost,
+			Product: struct {
+				Name     string `json:"name"`
+				Cost     int    `json:"cost"`
+				Quantity int    `json:"quantity_purchased"`
+			}{
+				Name:     stock.Name,
+				Cost:     stock.Cost,
+				Quantity: quantity,
+			},
+			Change: paid,
+			Slot:   receipt.SlotCode,
+		}
 
-	//spent
-	cost := product.Cost * quantity
-	user.Deposit = user.Deposit - cost
-	if err = t.userService.UpdateUser(user); err != nil {
+		return nil
+	})
+
+	if err != nil {
 		return nil, nil, err
 	}
 
-	purchase := &dto.BuyProductResponse{
-		AmountSpent: cost,
-		Product: struct {
-			Name     string `json:"name"`
-			Cost     int    `json:"cost"`
-			Quantity int    `json:"quantity_purchased"`
-		}{
-			Name:     updatedProduct.Name,
-			Cost:     updatedProduct.Cost,
-			Quantity: quantity,
-		},
-		Change: getChange(user.Deposit),
+	if !v.Valid() {
+		return nil, v.Errors, nil
 	}
 
 	return purchase, nil, nil
 }
 
-func (t *transactionService) DepositCoin(user *data.User, deposit int) (data.ValidationErrors, error) {
+// DepositCoin credits a coin inserted into the machine. A balance can only be
+// held by one machine at a time, so buyers with credit in another machine have
+// to spend or withdraw it there first.
+func (t *transactionService) DepositCoin(user *data.User, machineID int64, deposit int) (data.ValidationErrors, error) {
 
 	v := validator.New()
 	v.Check(deposit > 0, "deposit", "must be greater than zero")
@@ -85,48 +195,663 @@ func (t *transactionService) DepositCoin(user *data.User, deposit int) (data.Val
 		return v.Errors, nil
 	}
 
-	user.Deposit = user.Deposit + deposit
+	err := t.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		buyer, err := uow.Users().GetForUpdate(user.ID)
+		if err != nil {
+			return err
+		}
+
+		if checkDepositMachine(v, buyer, machineID); !v.Valid() {
+			return nil
+		}
+
+		v.Check(
+			buyer.Deposit+deposit <= data.MaxDeposit,
+			"deposit",
+			fmt.Sprintf("your balance can not be more than %d", data.MaxDeposit),
+		)
+		if !v.Valid() {
+			return nil
+		}
+
+		if err = t.coinService.Insert(uow, machineID, deposit); err != nil {
+			return err
+		}
+
+		if _, err = t.ledgerService.Record(uow, buyer, machineID, data.LedgerEntryDeposit, deposit); err != nil {
+			return err
+		}
+
+		*user = *buyer
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !v.Valid() {
+		return v.Errors, nil
+	}
 
-	return nil, t.userService.UpdateUser(user)
+	return nil, nil
 }
 
-func (t *transactionService) DepositReset(user *data.User) (data.ValidationErrors, error) {
+// DepositReset pays out the buyer's whole balance like ReturnCoins, from the
+// machine holding it. Resetting an empty deposit pays out nothing.
+func (t *transactionService) DepositReset(user *data.User) ([]int, data.ValidationErrors, error) {
 
-	user.Deposit = 0
 	v := validator.New()
-	if user.Validate(v); !v.Valid() {
-		return v.Errors, nil
+
+	coins := []int{}
+
+	err := t.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		buyer, err := uow.Users().GetForUpdate(user.ID)
+		if err != nil {
+			return err
+		}
+
+		if buyer.Deposit > 0 {
+			if coins, err = t.withdraw(uow, v, buyer, buyer.DepositMachineID); err != nil || !v.Valid() {
+				return err
+			}
+		}
+
+		*user = *buyer
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return coins, nil, nil
+}
+
+// ReturnCoins pays the buyer's whole balance back as coins from the float of
+// the machine holding it and zeroes the deposit in the same transaction. The
+// payout is recorded in the ledger as a change entry.
+func (t *transactionService) ReturnCoins(user *data.User, machineID int64) ([]int, data.ValidationErrors, error) {
+
+	v := validator.New()
+
+	var coins []int
+
+	err := t.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		buyer, err := uow.Users().GetForUpdate(user.ID)
+		if err != nil {
+			return err
+		}
+
+		v.Check(buyer.Deposit > 0, "deposit", "you have no balance to return")
+		if checkDepositMachine(v, buyer, machineID); !v.Valid() {
+			return nil
+		}
+
+		if coins, err = t.withdraw(uow, v, buyer, machineID); err != nil || !v.Valid() {
+			return err
+		}
+
+		*user = *buyer
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return coins, nil, nil
+}
+
+// withdraw pays the buyer's whole balance out of the float of machineID. It
+// adds a validation error when the float can not cover it.
+func (t *transactionService) withdraw(uow repository.UnitOfWork, v *validator.Validator, buyer *data.User, machineID int64) ([]int, error) {
+
+	change, ok, err := t.coinService.Change(uow, machineID, buyer.Deposit)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Check(ok, "change", "the machine cannot pay out your balance with the coins it holds"); !v.Valid() {
+		return nil, nil
+	}
+
+	return t.payOut(uow, buyer, machineID, change)
+}
+
+// payOut takes coins, worth the buyer's remaining balance, out of the float and
+// records the payout as a change entry, which empties the deposit. It returns
+// the coins paid out, none when the deposit was already empty.
+func (t *transactionService) payOut(uow repository.UnitOfWork, buyer *data.User, machineID int64, coins []int) ([]int, error) {
+
+	if buyer.Deposit == 0 {
+		return []int{}, nil
+	}
+
+	if err := t.coinService.PayOut(uow, machineID, coins); err != nil {
+		return nil, err
+	}
+
+	if _, err := t.ledgerService.Record(uow, buyer, machineID, data.LedgerEntryChange, -buyer.Deposit); err != nil {
+		return nil, err
+	}
+
+	return coins, nil
+}
+
+// Checkout buys every line of the cart in one transaction: either all stock and
+// the deposit are decremented together or nothing changes. Lines for the same
+// product are merged and products are locked in id order to avoid deadlocks
+// between concurrent carts. Merged lines must not ask for different slots.
+// Every line is dispensed by the same machine, which then pays out the
+// remaining balance as change.
+func (t *transactionService) Checkout(user *data.User, machineID int64, lines []dto.CartLine) (*dto.CheckoutResponse, data.ValidationErrors, error) {
+
+	v := validator.New()
+	v.Check(len(lines) > 0, "items", "must contain at least one product")
+	v.Check(len(lines) <= maxCartLines, "items", fmt.Sprintf("must not contain more than %d products", maxCartLines))
+
+	quantities := make(map[int64]int)
+	slotCodes := make(map[int64]string)
+	var productIDs []int64
+
+	for i, line := range lines {
+		key := fmt.Sprintf("items[%d]", i)
+		v.Check(line.ProductID > 0, key, "product_id must be provided")
+		v.Check(line.Quantity > 0, key, "quantity must be greater than zero")
+
+		if line.Slot != "" {
+			data.ValidateSlotCode(v, key, line.Slot)
+
+			code, chosen := slotCodes[line.ProductID]
+			v.Check(!chosen || code == line.Slot, key, "the same product must be dispensed from a single slot")
+			slotCodes[line.ProductID] = line.Slot
+		}
+
+		if _, seen := quantities[line.ProductID]; !seen {
+			productIDs = append(productIDs, line.ProductID)
+		}
+		quantities[line.ProductID] += line.Quantity
 	}
-	return nil, t.userService.UpdateUser(user)
-}
-
-func getChange(balance int) (change []int) {
-
-	for balance > 0 {
-		if balance >= data.CoinHundredCent {
-			change = append(change, data.CoinHundredCent)
-			balance = balance - data.CoinHundredCent
-			continue
-		} else if balance >= data.CoinFiftyCent {
-			change = append(change, data.CoinFiftyCent)
-			balance = balance - data.CoinFiftyCent
-			continue
-		} else if balance >= data.CoinTwentyCent {
-			change = append(change, data.CoinTwentyCent)
-			balance = balance - data.CoinTwentyCent
-			continue
-		} else if balance >= data.CoinTenCent {
-			change = append(change, data.CoinTenCent)
-			balance = balance - data.CoinTenCent
-			continue
-		} else if balance >= data.CoinFiveCent {
-			change = append(change, data.CoinFiveCent)
-			balance = balance - data.CoinFiveCent
-			continue
-		} else {
-			panic(fmt.Sprintf("system error balance %d is not valid", balance))
-		}
-	}
-
-	return
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+	var receipt *dto.CheckoutResponse
+
+	err := t.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+
+		buyer, err := uow.Users().GetForUpdate(user.ID)
+		if err != nil {
+			return err
+		}
+
+		checkDepositMachine(v, buyer, machineID)
+
+		var products []*data.Product
+		slots := make(map[int64]*data.Slot)
+		total := 0
+
+		for _, id := range productIDs {
+			product, err := uow.Products().GetForUpdate(id)
+			if err != nil {
+				return err
+			}
+
+			inMachine, err := uow.Machines().GetQuantityForUpdate(machineID, id)
+			if err != nil {
+				return err
+			}
+
+			quantity := quantities[id]
+			v.Check(
+				inMachine >= quantity,
+				fmt.Sprintf("items.%d", id),
+				fmt.Sprintf("not enough quantity only %d remaining", inMachine),
+			)
+
+			slot, err := pickSlot(uow, v, fmt.Sprintf("items.%d", id), machineID, product, quantity, slotCodes[id])
+			if err != nil {
+				return err
+			}
+			slots[id] = slot
+
+			total = total + product.Cost*quantity
+			products = append(products, product)
+		}
+
+		v.Check(buyer.Deposit >= total, "items", "you do not have sufficient balance")
+		if !v.Valid() {
+			return nil
+		}
+
+		change, ok, err := t.coinService.Change(uow, machineID, buyer.Deposit-total)
+		if err != nil {
+			return err
+		}
+
+		if v.Check(ok, "change", "the machine cannot give exact change for your remaining balance"); !v.Valid() {
+			return nil
+		}
+
+		receipt = &dto.CheckoutResponse{
+			Lines:       []dto.ReceiptLine{},
+			AmountSpent: total,
+		}
+
+		for i, product := range products {
+			quantity := quantities[product.ID]
+
+			if err = takeStock(uow, machineID, product, quantity); err != nil {
+				return err
+			}
+
+			if err = dispense(uow, slots[product.ID], quantity); err != nil {
+				return err
+			}
+
+			if _, err = t.ledgerService.Record(uow, buyer, machineID, data.LedgerEntryPurchase, -product.Cost*quantity); err != nil {
+				return err
+			}
+
+			purchase := &data.Purchase{
+				BuyerID:     buyer.ID,
+				SellerID:    product.Seller.ID,
+				MachineID:   machineID,
+				ProductID:   product.ID,
+				ProductName: product.Name,
+				ProductCost: product.Cost,
+				Quantity:    quantity,
+				AmountSpent: product.Cost * quantity,
+				SlotCode:    slotCodeOf(slots[product.ID]),
+			}
+
+			// change is paid out once the whole cart is paid for, it is kept on
+			// the last line.
+			if i == len(products)-1 {
+				if purchase.Change, err = t.payOut(uow, buyer, machineID, change); err != nil {
+					return err
+				}
+
+				receipt.Change = purchase.Change
+			}
+
+			if err = uow.Purchases().Insert(purchase); err != nil {
+				return err
+			}
+
+			receipt.Lines = append(receipt.Lines, dto.ReceiptLine{
+				PurchaseID: purchase.ID,
+				ProductID:  product.ID,
+				Name:       product.Name,
+				Cost:       product.Cost,
+				Quantity:   quantity,
+				Total:      product.Cost * quantity,
+				Slot:       purchase.SlotCode,
+			})
+		}
+
+		*user = *buyer
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return receipt, nil, nil
+}
+
+// RequestRefund opens a refund for the whole of one of the buyer's purchases.
+// Purchases made by someone else are reported as data.ErrRecordNotFound.
+func (t *transactionService) RequestRefund(buyer *data.User, purchaseID int64, reason string) (*data.Refund, data.ValidationErrors, error) {
+
+	v := validator.New()
+	if data.ValidateRefundReason(v, reason); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	var refund *data.Refund
+
+	err := t.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		purchase, err := uow.Purchases().Get(purchaseID)
+		if err != nil {
+			return err
+		}
+
+		if purchase.BuyerID != buyer.ID {
+			return data.ErrRecordNotFound
+		}
+
+		refund = &data.Refund{
+			PurchaseID: purchase.ID,
+			BuyerID:    purchase.BuyerID,
+			SellerID:   purchase.SellerID,
+			ProductID:  purchase.ProductID,
+			Quantity:   purchase.Quantity,
+			Amount:     purchase.AmountSpent,
+			Reason:     reason,
+			Status:     data.RefundRequested,
+		}
+
+		err = uow.Refunds().Insert(refund)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrDuplicateRefund):
+				v.AddError("purchase", "a refund has already been requested for this purchase")
+				return nil
+			default:
+				return err
+			}
+		}
+
+		return uow.Refunds().AddEvent(&data.RefundEvent{
+			RefundID: refund.ID,
+			ToStatus: data.RefundRequested,
+			ActorID:  buyer.ID,
+			Note:     reason,
+		})
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return refund, nil, nil
+}
+
+// ApproveRefund accepts a requested refund and pays it out in the same
+// transaction: the purchased quantity goes back into the stock of the machine
+// it was bought from, the amount is credited to the buyer's deposit and the
+// refund ends up refunded. Only the
+// seller of the purchase, or a manager, may approve it.
+func (t *transactionService) ApproveRefund(actor *data.User, manager bool, refundID int64, note string) (*data.Refund, data.ValidationErrors, error) {
+
+	v := validator.New()
+	if data.ValidateRefundNote(v, note); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	var refund *data.Refund
+
+	err := t.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		var err error
+
+		refund, err = uow.Refunds().GetForUpdate(refundID)
+		if err != nil {
+			return err
+		}
+
+		if !manager && refund.SellerID != actor.ID {
+			return data.ErrNoPermission
+		}
+
+		if err = t.transitionRefund(uow, v, refund, data.RefundApproved, actor.ID, note); err != nil || !v.Valid() {
+			return err
+		}
+
+		purchase, err := uow.Purchases().Get(refund.PurchaseID)
+		if err != nil {
+			return err
+		}
+
+		machineID := purchase.MachineID
+		if machineID == 0 {
+			machineID = data.DefaultMachineID
+		}
+
+		// lock order matches purchases: buyer first, then the product and its slot.
+		buyer, err := uow.Users().GetForUpdate(refund.BuyerID)
+		if err != nil {
+			return err
+		}
+
+		// nothing to restock when the product has been removed since the sale.
+		if refund.ProductID > 0 {
+			product, err := uow.Products().GetForUpdate(refund.ProductID)
+			switch {
+			case err == nil:
+				// every unit goes back into the machine's stock; the ones that no
+				// longer fit into their slot are stocked outside the slots, the way
+				// a restock beyond the slots' capacity is.
+				if err = uow.Machines().AdjustStock(machineID, product.ID, refund.Quantity); err != nil {
+					return err
+				}
+
+				if _, err = refill(uow, machineID, product.ID, purchase.SlotCode, refund.Quantity); err != nil {
+					return err
+				}
+			case !errors.Is(err, data.ErrRecordNotFound):
+				return err
+			}
+		}
+
+		// credit stays with the machine already holding the buyer's balance.
+		if buyer.Deposit > 0 {
+			machineID = buyer.DepositMachineID
+		}
+
+		if _, err = t.ledgerService.Record(uow, buyer, machineID, data.LedgerEntryRefund, refund.Amount); err != nil {
+			return err
+		}
+
+		return t.transitionRefund(uow, v, refund, data.RefundRefunded, actor.ID, "")
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return refund, nil, nil
+}
+
+// RejectRefund closes a requested refund without moving stock or money.
+func (t *transactionService) RejectRefund(actor *data.User, manager bool, refundID int64, note string) (*data.Refund, data.ValidationErrors, error) {
+
+	v := validator.New()
+	if data.ValidateRefundNote(v, note); !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	var refund *data.Refund
+
+	err := t.transactor.WithinTransaction(func(uow repository.UnitOfWork) error {
+		var err error
+
+		refund, err = uow.Refunds().GetForUpdate(refundID)
+		if err != nil {
+			return err
+		}
+
+		if !manager && refund.SellerID != actor.ID {
+			return data.ErrNoPermission
+		}
+
+		return t.transitionRefund(uow, v, refund, data.RefundRejected, actor.ID, note)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, v.Errors, nil
+	}
+
+	return refund, nil, nil
+}
+
+// transitionRefund moves the refund to the given status and records the change.
+// A move the state machine does not allow is reported on v and leaves the refund
+// untouched.
+func (t *transactionService) transitionRefund(
+	uow repository.UnitOfWork,
+	v *validator.Validator,
+	refund *data.Refund,
+	to string,
+	actorID int64,
+	note string,
+) error {
+
+	if v.Check(refund.CanTransition(to), "status", fmt.Sprintf("a %s refund can not be %s", refund.Status, to)); !v.Valid() {
+		return nil
+	}
+
+	from := refund.Status
+	refund.Status = to
+
+	if err := uow.Refunds().Update(refund); err != nil {
+		return err
+	}
+
+	return uow.Refunds().AddEvent(&data.RefundEvent{
+		RefundID:   refund.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Note:       note,
+	})
+}
+
+// checkDepositMachine makes sure a buyer's balance is only used in the machine
+// holding the coins behind it.
+func checkDepositMachine(v *validator.Validator, buyer *data.User, machineID int64) {
+	v.Check(
+		buyer.Deposit == 0 || buyer.DepositMachineID == machineID,
+		"deposit",
+		fmt.Sprintf("your balance is held by machine %d, spend or withdraw it there first", buyer.DepositMachineID),
+	)
+}
+
+// takeStock removes quantity units of product from the machine and the
+// product total, and mirrors the change on the caller's copy.
+func takeStock(uow repository.UnitOfWork, machineID int64, product *data.Product, quantity int) error {
+	if err := uow.Machines().AdjustStock(machineID, product.ID, -quantity); err != nil {
+		return err
+	}
+
+	product.AmountAvailable = product.AmountAvailable - quantity
+
+	return nil
+}
+
+// pickSlot locks the machine's slots holding product and chooses the one to
+// dispense quantity units from: the slot named by code, otherwise the fullest
+// one. Products that were never loaded into a slot are sold without one, so a
+// nil slot with no validation error is a valid result.
+func pickSlot(uow repository.UnitOfWork, v *validator.Validator, key string, machineID int64, product *data.Product, quantity int, code string) (*data.Slot, error) {
+
+	slots, err := uow.Slots().GetAllForProductForUpdate(machineID, product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(slots) == 0 {
+		v.Check(code == "", key, fmt.Sprintf("slot %s does not hold this product", code))
+		return nil, nil
+	}
+
+	slot := slots[0]
+	if code != "" {
+		slot = nil
+		for _, s := range slots {
+			if s.Code == code {
+				slot = s
+			}
+		}
+
+		if slot == nil {
+			v.AddError(key, fmt.Sprintf("slot %s does not hold this product", code))
+			return nil, nil
+		}
+	}
+
+	v.Check(
+		slot.Quantity >= quantity,
+		key,
+		fmt.Sprintf("not enough quantity only %d remaining in slot %s", slot.Quantity, slot.Code),
+	)
+
+	return slot, nil
+}
+
+// dispense takes quantity units out of slot, a nil slot is a no-op.
+func dispense(uow repository.UnitOfWork, slot *data.Slot, quantity int) error {
+	if slot == nil {
+		return nil
+	}
+
+	slot.Quantity = slot.Quantity - quantity
+
+	return uow.Slots().Update(slot)
+}
+
+// refill puts up to quantity refunded units of the product back into the slot
+// they were sold from and returns how many it placed. A slot that has since
+// been emptied or loaded with another product takes none, a full one only what
+// fits.
+func refill(uow repository.UnitOfWork, machineID, productID int64, code string, quantity int) (int, error) {
+	if code == "" {
+		return 0, nil
+	}
+
+	slot, err := uow.Slots().GetByCodeForUpdate(machineID, code)
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+
+	if slot.ProductID != productID {
+		return 0, nil
+	}
+
+	placed := quantity
+	if room := slot.Capacity - slot.Quantity; placed > room {
+		placed = room
+	}
+
+	if placed <= 0 {
+		return 0, nil
+	}
+
+	slot.Quantity = slot.Quantity + placed
+
+	return placed, uow.Slots().Update(slot)
+}
+
+func slotCodeOf(slot *data.Slot) string {
+	if slot == nil {
+		return ""
+	}
+
+	return slot.Code
 }
@@ -10,8 +10,9 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/terdia/mvp/internal/data"
-	"github.com/terdia/mvp/internal/service/productservice"
-	"github.com/terdia/mvp/internal/service/userservice"
+	"github.com/terdia/mvp/internal/repository"
+	"github.com/terdia/mvp/internal/service/coinservice"
+	"github.com/terdia/mvp/internal/service/ledger"
 	repo "github.com/terdia/mvp/mocks/repository"
 	"github.com/terdia/mvp/pkg/dto"
 )
@@ -19,24 +20,19 @@ import (
 func TestTransactionService_BuyProduct(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
-	productRepo := repo.NewMockProductRepository(ctrl)
-	userRepo := repo.NewMockUserRepository(ctrl)
-	permissionRepo := repo.NewMockPermissionRepository(ctrl)
-
-	newUserService := userservice.NewUserService(userRepo, nil, permissionRepo)
-	newProductService := productservice.NewProductService(productRepo)
-	tService := NewTransactionService(newUserService, newProductService)
+	tService, repos := newTestTransactionService(ctrl)
 
 	testCases := map[string]interface{}{
 		"BuyProductSuccessful": func() bool {
 			// arrange
 			user := &data.User{
-				ID:        1,
-				Role:      "buyer",
-				Deposit:   475,
-				Username:  "tester",
-				Password:  data.Password{Hash: []byte("password")},
-				CreatedAt: time.Now(),
+				ID:               1,
+				Role:             "buyer",
+				Deposit:          475,
+				DepositMachineID: data.DefaultMachineID,
+				Username:         "tester",
+				Password:         data.Password{Hash: []byte("password")},
+				CreatedAt:        time.Now(),
 			}
 
 			product := &data.Product{
@@ -48,11 +44,27 @@ func TestTransactionService_BuyProduct(t *testing.T) {
 				AmountAvailable: 20,
 			}
 
-			userRepo.EXPECT().Update(gomock.Any()).Return(nil)
-			productRepo.EXPECT().Get(gomock.Any()).Return(product, nil)
-			productRepo.EXPECT().Update(gomock.Any()).Return(nil)
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+			repos.products.EXPECT().GetForUpdate(product.ID).Return(product, nil)
+			repos.machines.EXPECT().GetQuantityForUpdate(data.DefaultMachineID, product.ID).Return(20, nil)
+			repos.slots.EXPECT().GetAllForProductForUpdate(data.DefaultMachineID, product.ID).Return([]*data.Slot{}, nil)
+			repos.coins.EXPECT().GetInventoryForUpdate(data.DefaultMachineID).Return(data.CoinInventory{100: 10, 50: 10, 20: 10, 10: 10, 5: 10}, nil)
+			repos.machines.EXPECT().AdjustStock(data.DefaultMachineID, product.ID, -2).Return(nil)
+			repos.ledger.EXPECT().Append(gomock.Any()).DoAndReturn(appendTo(user)).Times(2)
+			repos.coins.EXPECT().Adjust(data.DefaultMachineID, 100, -2).Return(nil)
+			repos.coins.EXPECT().Adjust(data.DefaultMachineID, 50, -1).Return(nil)
+			repos.coins.EXPECT().Adjust(data.DefaultMachineID, 20, -1).Return(nil)
+			repos.coins.EXPECT().Adjust(data.DefaultMachineID, 5, -1).Return(nil)
+			repos.purchases.EXPECT().Insert(gomock.Any()).DoAndReturn(func(purchase *data.Purchase) error {
+				if !cmp.Equal([]int{100, 100, 50, 20, 5}, purchase.Change) {
+					t.Errorf("expected the paid out coins on the receipt; got: %+v", purchase.Change)
+				}
+				purchase.ID = 7
+				return nil
+			})
 
 			expectedResponse := dto.BuyProductResponse{
+				PurchaseID:  7,
 				AmountSpent: 200,
 				Product: struct {
 					Name     string `json:"name"`
@@ -67,7 +79,7 @@ func TestTransactionService_BuyProduct(t *testing.T) {
 			}
 
 			// act
-			purchase, validationErrs, err := tService.BuyProduct(user, product, 2)
+			purchase, validationErrs, err := tService.BuyProduct(user, data.DefaultMachineID, product, 2, "")
 
 			//assert
 			if validationErrs != nil {
@@ -85,6 +97,11 @@ func TestTransactionService_BuyProduct(t *testing.T) {
 				return false
 			}
 
+			if user.Deposit != 0 {
+				t.Errorf("expected: %+v; got: %+v", 0, user.Deposit)
+				return false
+			}
+
 			return true
 		},
 		"BuyProductValidationErrors": func() bool {
@@ -93,8 +110,13 @@ func TestTransactionService_BuyProduct(t *testing.T) {
 
 			product := &data.Product{Cost: 100, AmountAvailable: 1}
 
+			repos.users.EXPECT().GetForUpdate(gomock.Any()).Return(user, nil)
+			repos.products.EXPECT().GetForUpdate(gomock.Any()).Return(product, nil)
+			repos.machines.EXPECT().GetQuantityForUpdate(gomock.Any(), gomock.Any()).Return(1, nil)
+			repos.slots.EXPECT().GetAllForProductForUpdate(gomock.Any(), gomock.Any()).Return([]*data.Slot{}, nil)
+
 			// act
-			_, validationErrs, err := tService.BuyProduct(user, product, 2)
+			_, validationErrs, err := tService.BuyProduct(user, data.DefaultMachineID, product, 2, "")
 
 			//assert
 			if validationErrs == nil {
@@ -109,6 +131,123 @@ func TestTransactionService_BuyProduct(t *testing.T) {
 
 			return true
 		},
+		"BuyProductCannotMakeChange": func() bool {
+			// arrange
+			user := &data.User{ID: 1, Deposit: 150, DepositMachineID: data.DefaultMachineID}
+			product := &data.Product{ID: 1, Cost: 65, AmountAvailable: 3}
+
+			repos.users.EXPECT().GetForUpdate(gomock.Any()).Return(user, nil)
+			repos.products.EXPECT().GetForUpdate(gomock.Any()).Return(product, nil)
+			repos.machines.EXPECT().GetQuantityForUpdate(gomock.Any(), gomock.Any()).Return(3, nil)
+			repos.slots.EXPECT().GetAllForProductForUpdate(gomock.Any(), gomock.Any()).Return([]*data.Slot{}, nil)
+			repos.coins.EXPECT().GetInventoryForUpdate(data.DefaultMachineID).Return(data.CoinInventory{100: 5, 50: 5, 20: 5}, nil)
+
+			// act
+			_, validationErrs, err := tService.BuyProduct(user, data.DefaultMachineID, product, 1, "")
+
+			//assert
+			if _, ok := validationErrs["change"]; !ok {
+				t.Errorf("expected change validation error, got: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			return true
+		},
+		"BuyProductFromSlot": func() bool {
+			// arrange
+			user := &data.User{ID: 1, Deposit: 100, DepositMachineID: data.DefaultMachineID}
+			product := &data.Product{ID: 1, Cost: 50, Name: "Lemonade", Seller: data.User{ID: 2}, AmountAvailable: 10}
+			fullest := &data.Slot{ID: 1, Code: "A1", Capacity: 10, ProductID: 1, Quantity: 6}
+			chosen := &data.Slot{ID: 2, Code: "B3", Capacity: 10, ProductID: 1, Quantity: 4}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+			repos.products.EXPECT().GetForUpdate(product.ID).Return(product, nil)
+			repos.machines.EXPECT().GetQuantityForUpdate(data.DefaultMachineID, product.ID).Return(10, nil)
+			repos.slots.EXPECT().GetAllForProductForUpdate(data.DefaultMachineID, product.ID).Return([]*data.Slot{fullest, chosen}, nil)
+			repos.coins.EXPECT().GetInventoryForUpdate(data.DefaultMachineID).Return(data.CoinInventory{}, nil)
+			repos.machines.EXPECT().AdjustStock(data.DefaultMachineID, product.ID, -2).Return(nil)
+			repos.slots.EXPECT().Update(chosen).Return(nil)
+			repos.ledger.EXPECT().Append(gomock.Any()).DoAndReturn(appendTo(user))
+			repos.purchases.EXPECT().Insert(gomock.Any()).DoAndReturn(func(purchase *data.Purchase) error {
+				if purchase.SlotCode != "B3" {
+					t.Errorf("expected purchase from slot B3; got: %q", purchase.SlotCode)
+				}
+				return nil
+			})
+
+			// act
+			purchase, validationErrs, err := tService.BuyProduct(user, data.DefaultMachineID, product, 2, "B3")
+
+			//assert
+			if validationErrs != nil {
+				t.Errorf("unexpected validation errors: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			if purchase.Slot != "B3" {
+				t.Errorf("expected: %q; got: %q", "B3", purchase.Slot)
+				return false
+			}
+
+			if chosen.Quantity != 2 || fullest.Quantity != 6 {
+				t.Errorf("expected slot quantities 6 and 2; got: %d and %d", fullest.Quantity, chosen.Quantity)
+				return false
+			}
+
+			if product.AmountAvailable != 8 {
+				t.Errorf("expected: %d; got: %d", 8, product.AmountAvailable)
+				return false
+			}
+
+			return true
+		},
+		"BuyProductSlotValidationErrors": func() bool {
+			// arrange
+			user := &data.User{ID: 1, Deposit: 500, DepositMachineID: data.DefaultMachineID}
+			product := &data.Product{ID: 1, Cost: 50, AmountAvailable: 10}
+			slot := &data.Slot{ID: 1, Code: "A1", Capacity: 10, ProductID: 1, Quantity: 1}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil).Times(2)
+			repos.products.EXPECT().GetForUpdate(product.ID).Return(product, nil).Times(2)
+			repos.machines.EXPECT().GetQuantityForUpdate(data.DefaultMachineID, product.ID).Return(10, nil).Times(2)
+			repos.slots.EXPECT().GetAllForProductForUpdate(data.DefaultMachineID, product.ID).Return([]*data.Slot{slot}, nil).Times(2)
+
+			// act
+			_, wrongSlot, err := tService.BuyProduct(user, data.DefaultMachineID, product, 1, "C2")
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			_, emptySlot, err := tService.BuyProduct(user, data.DefaultMachineID, product, 2, "")
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			//assert
+			if _, ok := wrongSlot["slot"]; !ok {
+				t.Errorf("expected slot validation error, got: %+v", wrongSlot)
+				return false
+			}
+
+			if _, ok := emptySlot["slot"]; !ok {
+				t.Errorf("expected slot validation error, got: %+v", emptySlot)
+				return false
+			}
+
+			return true
+		},
 		"BuyProductDatabaseErrors": func() bool {
 			// arrange
 			user := &data.User{Deposit: 200}
@@ -121,10 +260,11 @@ func TestTransactionService_BuyProduct(t *testing.T) {
 				AmountAvailable: 3,
 			}
 
-			productRepo.EXPECT().Get(gomock.Any()).Return(nil, errors.New("database error"))
+			repos.users.EXPECT().GetForUpdate(gomock.Any()).Return(user, nil)
+			repos.products.EXPECT().GetForUpdate(gomock.Any()).Return(nil, errors.New("database error"))
 
 			// act
-			_, validationErrs, err := tService.BuyProduct(user, product, 2)
+			_, validationErrs, err := tService.BuyProduct(user, data.DefaultMachineID, product, 2, "")
 
 			//assert
 			if validationErrs != nil {
@@ -153,13 +293,7 @@ func TestTransactionService_BuyProduct(t *testing.T) {
 func TestTransactionService_DepositCoin(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
-	productRepo := repo.NewMockProductRepository(ctrl)
-	userRepo := repo.NewMockUserRepository(ctrl)
-	permissionRepo := repo.NewMockPermissionRepository(ctrl)
-
-	newUserService := userservice.NewUserService(userRepo, nil, permissionRepo)
-	newProductService := productservice.NewProductService(productRepo)
-	tService := NewTransactionService(newUserService, newProductService)
+	tService, repos := newTestTransactionService(ctrl)
 
 	testCases := map[string]interface{}{
 		"DepositSuccessful": func() bool {
@@ -173,10 +307,12 @@ func TestTransactionService_DepositCoin(t *testing.T) {
 				CreatedAt: time.Now(),
 			}
 
-			userRepo.EXPECT().Update(gomock.Any()).Return(nil)
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+			repos.coins.EXPECT().Adjust(data.DefaultMachineID, 100, 1).Return(nil)
+			repos.ledger.EXPECT().Append(gomock.Any()).DoAndReturn(appendTo(user))
 
 			// act
-			validationErrs, err := tService.DepositCoin(user, 100)
+			validationErrs, err := tService.DepositCoin(user, data.DefaultMachineID, 100)
 
 			//assert
 			if validationErrs != nil {
@@ -197,12 +333,272 @@ func TestTransactionService_DepositCoin(t *testing.T) {
 
 			return true
 		},
+		"DepositHeldByAnotherMachine": func() bool {
+			// arrange
+			user := &data.User{ID: 1, Deposit: 50, DepositMachineID: 2}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+
+			// act
+			validationErrs, err := tService.DepositCoin(user, data.DefaultMachineID, 100)
+
+			//assert
+			if _, ok := validationErrs["deposit"]; !ok {
+				t.Errorf("expected deposit validation error, got: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			return true
+		},
 		"DepositValidationErrors": func() bool {
 			// arrange
 			user := &data.User{Deposit: 0}
 
 			// act
-			validationErrs, err := tService.DepositCoin(user, 560)
+			validationErrs, err := tService.DepositCoin(user, data.DefaultMachineID, 560)
+
+			//assert
+			if validationErrs == nil {
+				t.Errorf("expected validation errors, got: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			return true
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+
+}
+
+func TestTransactionService_ReturnCoins(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	tService, repos := newTestTransactionService(ctrl)
+
+	testCases := map[string]interface{}{
+		"ReturnCoinsSuccessful": func() bool {
+			// arrange
+			user := &data.User{ID: 1, Deposit: 85, DepositMachineID: 2}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+			repos.coins.EXPECT().GetInventoryForUpdate(int64(2)).Return(data.CoinInventory{50: 1, 20: 1, 10: 2, 5: 1}, nil)
+			repos.coins.EXPECT().Adjust(int64(2), 50, -1).Return(nil)
+			repos.coins.EXPECT().Adjust(int64(2), 20, -1).Return(nil)
+			repos.coins.EXPECT().Adjust(int64(2), 10, -1).Return(nil)
+			repos.coins.EXPECT().Adjust(int64(2), 5, -1).Return(nil)
+			repos.ledger.EXPECT().Append(gomock.Any()).DoAndReturn(appendTo(user))
+
+			// act
+			coins, validationErrs, err := tService.ReturnCoins(user, 2)
+
+			//assert
+			if validationErrs != nil {
+				t.Errorf("unexpected validation errors: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			expectedCoins := []int{50, 20, 10, 5}
+			if !cmp.Equal(expectedCoins, coins) {
+				t.Errorf("expected: %+v; got: %+v", expectedCoins, coins)
+				return false
+			}
+
+			if user.Deposit != 0 {
+				t.Errorf("expected: %+v; got: %+v", 0, user.Deposit)
+				return false
+			}
+
+			return true
+		},
+		"DepositResetPaysOut": func() bool {
+			// arrange
+			user := &data.User{ID: 1, Deposit: 70, DepositMachineID: 2}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+			repos.coins.EXPECT().GetInventoryForUpdate(int64(2)).Return(data.CoinInventory{50: 1, 20: 1}, nil)
+			repos.coins.EXPECT().Adjust(int64(2), 50, -1).Return(nil)
+			repos.coins.EXPECT().Adjust(int64(2), 20, -1).Return(nil)
+			repos.ledger.EXPECT().Append(gomock.Any()).DoAndReturn(func(entry *data.LedgerEntry) error {
+				if entry.Kind != data.LedgerEntryChange || entry.Amount != -70 {
+					t.Errorf("expected a change entry of -70; got: %s of %d", entry.Kind, entry.Amount)
+				}
+				return appendTo(user)(entry)
+			})
+
+			// act
+			coins, validationErrs, err := tService.DepositReset(user)
+
+			//assert
+			if validationErrs != nil || err != nil {
+				t.Errorf("unexpected errors: %+v %v", validationErrs, err)
+				return false
+			}
+
+			return cmp.Equal([]int{50, 20}, coins) && user.Deposit == 0
+		},
+		"ReturnCoinsNoBalance": func() bool {
+			// arrange
+			user := &data.User{ID: 1, Deposit: 0}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+
+			// act
+			_, validationErrs, err := tService.ReturnCoins(user, data.DefaultMachineID)
+
+			//assert
+			if validationErrs == nil {
+				t.Errorf("expected validation errors, got: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			return true
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Checkout(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	tService, repos := newTestTransactionService(ctrl)
+
+	testCases := map[string]interface{}{
+		"CheckoutSuccessful": func() bool {
+			// arrange
+			user := &data.User{ID: 1, Deposit: 300, DepositMachineID: data.DefaultMachineID}
+			lemonade := &data.Product{ID: 1, Cost: 100, Name: "Lemonade", AmountAvailable: 5}
+			crisps := &data.Product{ID: 2, Cost: 35, Name: "Crisps", AmountAvailable: 5}
+
+			slot := &data.Slot{ID: 1, Code: "A1", Capacity: 10, ProductID: 1, Quantity: 3}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+			gomock.InOrder(
+				repos.products.EXPECT().GetForUpdate(int64(1)).Return(lemonade, nil),
+				repos.products.EXPECT().GetForUpdate(int64(2)).Return(crisps, nil),
+			)
+			repos.machines.EXPECT().GetQuantityForUpdate(data.DefaultMachineID, int64(1)).Return(5, nil)
+			repos.machines.EXPECT().GetQuantityForUpdate(data.DefaultMachineID, int64(2)).Return(5, nil)
+			repos.slots.EXPECT().GetAllForProductForUpdate(data.DefaultMachineID, int64(1)).Return([]*data.Slot{slot}, nil)
+			repos.slots.EXPECT().GetAllForProductForUpdate(data.DefaultMachineID, int64(2)).Return([]*data.Slot{}, nil)
+			repos.slots.EXPECT().Update(slot).Return(nil)
+			repos.coins.EXPECT().GetInventoryForUpdate(data.DefaultMachineID).Return(data.CoinInventory{20: 5, 10: 5}, nil)
+			repos.machines.EXPECT().AdjustStock(data.DefaultMachineID, int64(1), -2).Return(nil)
+			repos.machines.EXPECT().AdjustStock(data.DefaultMachineID, int64(2), -2).Return(nil)
+			repos.ledger.EXPECT().Append(gomock.Any()).DoAndReturn(appendTo(user)).Times(3)
+			repos.coins.EXPECT().Adjust(data.DefaultMachineID, 20, -1).Return(nil)
+			repos.coins.EXPECT().Adjust(data.DefaultMachineID, 10, -1).Return(nil)
+			repos.purchases.EXPECT().Insert(gomock.Any()).DoAndReturn(func(purchase *data.Purchase) error {
+				purchase.ID = purchase.ProductID + 10
+				return nil
+			}).Times(2)
+
+			expectedReceipt := dto.CheckoutResponse{
+				Lines: []dto.ReceiptLine{
+					{PurchaseID: 11, ProductID: 1, Name: "Lemonade", Cost: 100, Quantity: 2, Total: 200, Slot: "A1"},
+					{PurchaseID: 12, ProductID: 2, Name: "Crisps", Cost: 35, Quantity: 2, Total: 70},
+				},
+				AmountSpent: 270,
+				Change:      []int{20, 10},
+			}
+
+			// act
+			receipt, validationErrs, err := tService.Checkout(user, data.DefaultMachineID, []dto.CartLine{
+				{ProductID: 2, Quantity: 1},
+				{ProductID: 1, Quantity: 2, Slot: "A1"},
+				{ProductID: 2, Quantity: 1},
+			})
+
+			//assert
+			if validationErrs != nil {
+				t.Errorf("unexpected validation errors: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			if !cmp.Equal(expectedReceipt, *receipt) {
+				t.Errorf("expected: %+v; got: %+v", expectedReceipt, *receipt)
+				return false
+			}
+
+			if slot.Quantity != 1 {
+				t.Errorf("expected: %+v; got: %+v", 1, slot.Quantity)
+				return false
+			}
+
+			if user.Deposit != 0 {
+				t.Errorf("expected: %+v; got: %+v", 0, user.Deposit)
+				return false
+			}
+
+			return true
+		},
+		"CheckoutNotEnoughStock": func() bool {
+			// arrange
+			user := &data.User{ID: 1, Deposit: 500, DepositMachineID: data.DefaultMachineID}
+			lemonade := &data.Product{ID: 1, Cost: 100, Name: "Lemonade", AmountAvailable: 1}
+
+			repos.users.EXPECT().GetForUpdate(user.ID).Return(user, nil)
+			repos.products.EXPECT().GetForUpdate(int64(1)).Return(lemonade, nil)
+			repos.machines.EXPECT().GetQuantityForUpdate(data.DefaultMachineID, int64(1)).Return(1, nil)
+			repos.slots.EXPECT().GetAllForProductForUpdate(data.DefaultMachineID, int64(1)).Return([]*data.Slot{}, nil)
+
+			// act
+			_, validationErrs, err := tService.Checkout(user, data.DefaultMachineID, []dto.CartLine{{ProductID: 1, Quantity: 2}})
+
+			//assert
+			if _, ok := validationErrs["items.1"]; !ok {
+				t.Errorf("expected stock validation error, got: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			return true
+		},
+		"CheckoutEmptyCart": func() bool {
+			// act
+			_, validationErrs, err := tService.Checkout(&data.User{ID: 1}, data.DefaultMachineID, nil)
 
 			//assert
 			if validationErrs == nil {
@@ -226,5 +622,303 @@ func TestTransactionService_DepositCoin(t *testing.T) {
 			}
 		})
 	}
+}
+
+func TestTransactionService_Refunds(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+	tService, repos := newTestTransactionService(ctrl)
 
+	testCases := map[string]interface{}{
+		"RequestRefundSuccessful": func() bool {
+			// arrange
+			buyer := &data.User{ID: 1}
+			purchase := &data.Purchase{ID: 3, BuyerID: 1, SellerID: 2, ProductID: 4, Quantity: 2, AmountSpent: 130}
+
+			repos.purchases.EXPECT().Get(purchase.ID).Return(purchase, nil)
+			repos.refunds.EXPECT().Insert(gomock.Any()).DoAndReturn(func(refund *data.Refund) error {
+				refund.ID = 9
+				return nil
+			})
+			repos.refunds.EXPECT().AddEvent(gomock.Any()).Return(nil)
+
+			// act
+			refund, validationErrs, err := tService.RequestRefund(buyer, purchase.ID, "nothing came out")
+
+			//assert
+			if validationErrs != nil {
+				t.Errorf("unexpected validation errors: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			expected := data.Refund{
+				ID:         9,
+				PurchaseID: 3,
+				BuyerID:    1,
+				SellerID:   2,
+				ProductID:  4,
+				Quantity:   2,
+				Amount:     130,
+				Reason:     "nothing came out",
+				Status:     data.RefundRequested,
+			}
+			if !cmp.Equal(expected, *refund) {
+				t.Errorf("expected: %+v; got: %+v", expected, *refund)
+				return false
+			}
+
+			return true
+		},
+		"RequestRefundForSomeoneElsesPurchase": func() bool {
+			// arrange
+			buyer := &data.User{ID: 1}
+
+			repos.purchases.EXPECT().Get(int64(3)).Return(&data.Purchase{ID: 3, BuyerID: 5}, nil)
+
+			// act
+			_, _, err := tService.RequestRefund(buyer, 3, "nothing came out")
+
+			//assert
+			if !errors.Is(err, data.ErrRecordNotFound) {
+				t.Errorf("expected: %v; got: %v", data.ErrRecordNotFound, err)
+				return false
+			}
+
+			return true
+		},
+		"ApproveRefundSuccessful": func() bool {
+			// arrange
+			seller := &data.User{ID: 2}
+			buyer := &data.User{ID: 1, Deposit: 20, DepositMachineID: data.DefaultMachineID}
+			product := &data.Product{ID: 4, AmountAvailable: 1}
+			refund := &data.Refund{ID: 9, PurchaseID: 3, BuyerID: 1, SellerID: 2, ProductID: 4, Quantity: 2, Amount: 130, Status: data.RefundRequested}
+
+			repos.refunds.EXPECT().GetForUpdate(refund.ID).Return(refund, nil)
+			repos.purchases.EXPECT().Get(refund.PurchaseID).Return(&data.Purchase{ID: 3, MachineID: 2}, nil)
+			repos.users.EXPECT().GetForUpdate(buyer.ID).Return(buyer, nil)
+			repos.products.EXPECT().GetForUpdate(product.ID).Return(product, nil)
+			repos.machines.EXPECT().AdjustStock(int64(2), product.ID, 2).Return(nil)
+			repos.ledger.EXPECT().Append(gomock.Any()).DoAndReturn(appendTo(buyer))
+			repos.refunds.EXPECT().Update(refund).Return(nil).Times(2)
+			repos.refunds.EXPECT().AddEvent(gomock.Any()).Return(nil).Times(2)
+
+			// act
+			_, validationErrs, err := tService.ApproveRefund(seller, false, refund.ID, "")
+
+			//assert
+			if validationErrs != nil {
+				t.Errorf("unexpected validation errors: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			if refund.Status != data.RefundRefunded {
+				t.Errorf("expected: %+v; got: %+v", data.RefundRefunded, refund.Status)
+				return false
+			}
+
+			if buyer.DepositMachineID != data.DefaultMachineID {
+				t.Errorf("expected credit to stay in machine %d; got: %d", data.DefaultMachineID, buyer.DepositMachineID)
+				return false
+			}
+
+			if buyer.Deposit != 150 {
+				t.Errorf("expected: %+v; got: %+v", 150, buyer.Deposit)
+				return false
+			}
+
+			return true
+		},
+		"ApproveRefundRefillsSlot": func() bool {
+			// arrange
+			seller := &data.User{ID: 2}
+			buyer := &data.User{ID: 1}
+			product := &data.Product{ID: 4}
+			slot := &data.Slot{ID: 7, MachineID: 2, Code: "B3", Capacity: 10, ProductID: 4, Quantity: 9}
+			refund := &data.Refund{ID: 9, PurchaseID: 3, BuyerID: 1, SellerID: 2, ProductID: 4, Quantity: 2, Amount: 130, Status: data.RefundRequested}
+
+			repos.refunds.EXPECT().GetForUpdate(refund.ID).Return(refund, nil)
+			repos.purchases.EXPECT().Get(refund.PurchaseID).Return(&data.Purchase{ID: 3, MachineID: 2, SlotCode: "B3"}, nil)
+			repos.users.EXPECT().GetForUpdate(buyer.ID).Return(buyer, nil)
+			repos.products.EXPECT().GetForUpdate(product.ID).Return(product, nil)
+			repos.machines.EXPECT().AdjustStock(int64(2), product.ID, 2).Return(nil)
+			repos.slots.EXPECT().GetByCodeForUpdate(int64(2), "B3").Return(slot, nil)
+			repos.slots.EXPECT().Update(slot).Return(nil)
+			repos.ledger.EXPECT().Append(gomock.Any()).DoAndReturn(appendTo(buyer))
+			repos.refunds.EXPECT().Update(refund).Return(nil).Times(2)
+			repos.refunds.EXPECT().AddEvent(gomock.Any()).Return(nil).Times(2)
+
+			// act
+			_, validationErrs, err := tService.ApproveRefund(seller, false, refund.ID, "")
+
+			//assert
+			if validationErrs != nil {
+				t.Errorf("unexpected validation errors: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			if slot.Quantity != 10 {
+				t.Errorf("expected the slot to be refilled to its capacity %d; got: %d", 10, slot.Quantity)
+				return false
+			}
+
+			return true
+		},
+		"ApproveRefundFullSlotStocksOutsideSlots": func() bool {
+			// arrange
+			seller := &data.User{ID: 2}
+			buyer := &data.User{ID: 1}
+			product := &data.Product{ID: 4}
+			slot := &data.Slot{ID: 7, MachineID: 2, Code: "B3", Capacity: 10, ProductID: 4, Quantity: 10}
+			refund := &data.Refund{ID: 9, PurchaseID: 3, BuyerID: 1, SellerID: 2, ProductID: 4, Quantity: 2, Amount: 130, Status: data.RefundRequested}
+
+			repos.refunds.EXPECT().GetForUpdate(refund.ID).Return(refund, nil)
+			repos.purchases.EXPECT().Get(refund.PurchaseID).Return(&data.Purchase{ID: 3, MachineID: 2, SlotCode: "B3"}, nil)
+			repos.users.EXPECT().GetForUpdate(buyer.ID).Return(buyer, nil)
+			repos.products.EXPECT().GetForUpdate(product.ID).Return(product, nil)
+			repos.machines.EXPECT().AdjustStock(int64(2), product.ID, 2).Return(nil)
+			repos.slots.EXPECT().GetByCodeForUpdate(int64(2), "B3").Return(slot, nil)
+			repos.ledger.EXPECT().Append(gomock.Any()).DoAndReturn(appendTo(buyer))
+			repos.refunds.EXPECT().Update(refund).Return(nil).Times(2)
+			repos.refunds.EXPECT().AddEvent(gomock.Any()).Return(nil).Times(2)
+
+			// act
+			_, validationErrs, err := tService.ApproveRefund(seller, false, refund.ID, "")
+
+			//assert
+			if validationErrs != nil || err != nil {
+				t.Errorf("unexpected errors: %v %v", validationErrs, err)
+				return false
+			}
+
+			if slot.Quantity != 10 {
+				t.Errorf("expected the full slot to stay at %d; got: %d", 10, slot.Quantity)
+				return false
+			}
+
+			return true
+		},
+		"ApproveRefundNotTheSeller": func() bool {
+			// arrange
+			refund := &data.Refund{ID: 9, BuyerID: 1, SellerID: 2, Status: data.RefundRequested}
+
+			repos.refunds.EXPECT().GetForUpdate(refund.ID).Return(refund, nil)
+
+			// act
+			_, _, err := tService.ApproveRefund(&data.User{ID: 3}, false, refund.ID, "")
+
+			//assert
+			if !errors.Is(err, data.ErrNoPermission) {
+				t.Errorf("expected: %v; got: %v", data.ErrNoPermission, err)
+				return false
+			}
+
+			return true
+		},
+		"RejectRefundAlreadyRefunded": func() bool {
+			// arrange
+			refund := &data.Refund{ID: 9, BuyerID: 1, SellerID: 2, Status: data.RefundRefunded}
+
+			repos.refunds.EXPECT().GetForUpdate(refund.ID).Return(refund, nil)
+
+			// act
+			_, validationErrs, err := tService.RejectRefund(&data.User{ID: 3}, true, refund.ID, "")
+
+			//assert
+			if _, ok := validationErrs["status"]; !ok {
+				t.Errorf("expected status validation error, got: %+v", validationErrs)
+				return false
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return false
+			}
+
+			return true
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
+
+// testRepositories holds the repository mocks handed out by the test unit of work.
+type testRepositories struct {
+	users     *repo.MockUserRepository
+	products  *repo.MockProductRepository
+	ledger    *repo.MockLedgerRepository
+	coins     *repo.MockCoinRepository
+	purchases *repo.MockPurchaseRepository
+	refunds   *repo.MockRefundRepository
+	slots     *repo.MockSlotRepository
+	machines  *repo.MockMachineRepository
+}
+
+// newTestTransactionService returns a transaction service whose transactor runs
+// the callback straight away against a unit of work backed by repository mocks.
+func newTestTransactionService(ctrl *gomock.Controller) (Service, *testRepositories) {
+
+	repos := &testRepositories{
+		users:     repo.NewMockUserRepository(ctrl),
+		products:  repo.NewMockProductRepository(ctrl),
+		ledger:    repo.NewMockLedgerRepository(ctrl),
+		coins:     repo.NewMockCoinRepository(ctrl),
+		purchases: repo.NewMockPurchaseRepository(ctrl),
+		refunds:   repo.NewMockRefundRepository(ctrl),
+		slots:     repo.NewMockSlotRepository(ctrl),
+		machines:  repo.NewMockMachineRepository(ctrl),
+	}
+
+	uow := repo.NewMockUnitOfWork(ctrl)
+	uow.EXPECT().Users().Return(repos.users).AnyTimes()
+	uow.EXPECT().Products().Return(repos.products).AnyTimes()
+	uow.EXPECT().Ledger().Return(repos.ledger).AnyTimes()
+	uow.EXPECT().Coins().Return(repos.coins).AnyTimes()
+	uow.EXPECT().Purchases().Return(repos.purchases).AnyTimes()
+	uow.EXPECT().Refunds().Return(repos.refunds).AnyTimes()
+	uow.EXPECT().Slots().Return(repos.slots).AnyTimes()
+	uow.EXPECT().Machines().Return(repos.machines).AnyTimes()
+
+	transactor := repo.NewMockTransactor(ctrl)
+	transactor.EXPECT().WithinTransaction(gomock.Any()).DoAndReturn(
+		func(fn func(uow repository.UnitOfWork) error) error {
+			return fn(uow)
+		},
+	).AnyTimes()
+
+	tService := NewTransactionService(
+		transactor,
+		ledger.NewLedgerService(repos.ledger),
+		coinservice.NewCoinService(repos.coins),
+	)
+
+	return tService, repos
+}
+
+// appendTo fakes LedgerRepository.Append by moving the balance of user.
+func appendTo(user *data.User) func(entry *data.LedgerEntry) error {
+	return func(entry *data.LedgerEntry) error {
+		entry.BalanceAfter = user.Deposit + entry.Amount
+		return nil
+	}
 }
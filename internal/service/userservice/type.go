@@ -10,11 +10,18 @@ import (
 type UserService interface {
 	Create(request dto.CreateUserRequest) (*data.User, data.ValidationErrors, error)
 	GetPermissions(userID int64) (data.Permissions, error)
-	GetUserByToken(tokenPlainText, scope string) (*data.User, error)
+	GetUserByToken(tokenPlainText, scope, ip string) (*data.User, error)
 	CreateAuthenticationToken(
-		request dto.AuthTokenRequest, scope string,
-	) (*data.Token, data.ValidationErrors, error)
+		request dto.AuthTokenRequest,
+	) (*data.TokenPair, data.ValidationErrors, error)
 	UpdateUser(*data.User) error
+	GetUser(id int64) (*data.User, error)
+	UpdateProfile(user *data.User, request dto.UpdateUserRequest) (data.ValidationErrors, error)
+	ChangePassword(
+		user *data.User,
+		request dto.ChangePasswordRequest,
+		tokenPlainText string,
+	) (data.ValidationErrors, error)
 }
 
 type (
@@ -22,5 +29,6 @@ type (
 		repo           repository.UserRepository
 		tokenService   auth.TokenService
 		permissionRepo repository.PermissionRepository
+		cache          *auth.Cache
 	}
 )
@@ -2,7 +2,6 @@ package userservice
 
 import (
 	"errors"
-	"time"
 
 	"github.com/terdia/mvp/internal/data"
 	"github.com/terdia/mvp/internal/repository"
@@ -15,11 +14,13 @@ func NewUserService(
 	repo repository.UserRepository,
 	tokenService auth.TokenService,
 	permissionRepo repository.PermissionRepository,
+	cache *auth.Cache,
 ) UserService {
 	return &userService{
 		repo:           repo,
 		tokenService:   tokenService,
 		permissionRepo: permissionRepo,
+		cache:          cache,
 	}
 }
 
@@ -51,17 +52,14 @@ func (srv *userService) Create(request dto.CreateUserRequest) (*data.User, data.
 		return nil, nil, err
 	}
 
-	if err = srv.permissionRepo.AddForUser(user.ID, user.GetRolePermissions()...); err != nil {
-		return nil, nil, err
-	}
-
 	return user, nil, nil
 }
 
+// CreateAuthenticationToken checks the credentials and starts a session with an
+// access and a refresh token.
 func (srv *userService) CreateAuthenticationToken(
 	request dto.AuthTokenRequest,
-	scope string,
-) (*data.Token, data.ValidationErrors, error) {
+) (*data.TokenPair, data.ValidationErrors, error) {
 
 	v := validator.New()
 	v.Check(len(request.Username) > 0, "username", "must not be empty")
@@ -84,19 +82,157 @@ func (srv *userService) CreateAuthenticationToken(
 		return nil, nil, data.ErrInvalidCredentials
 	}
 
-	token, err := srv.tokenService.CreateNew(user.ID, 24*time.Hour, scope)
+	if user.IsSuspended() {
+		return nil, nil, data.ErrAccountSuspended
+	}
+
+	tokens, err := srv.tokenService.CreateSession(user.ID)
 
-	return token, nil, err
+	return tokens, nil, err
 }
 
+// GetPermissions returns what the user may do. The result is shared with other
+// callers through the cache and must not be modified.
 func (srv *userService) GetPermissions(userID int64) (data.Permissions, error) {
-	return srv.permissionRepo.GetAllForUser(userID)
+	if permissions, ok := srv.cache.Permissions(userID); ok {
+		return permissions, nil
+	}
+
+	permissions, err := srv.permissionRepo.GetAllForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.cache.SetPermissions(userID, permissions)
+
+	return permissions, nil
 }
 
-func (srv *userService) GetUserByToken(tokenPlainText, scope string) (*data.User, error) {
-	return srv.repo.GetForToken(tokenPlainText, scope)
+// GetUserByToken resolves a token to its owner; ip is recorded as where the
+// token was last seen, also when the owner comes from cache. Access tokens are
+// answered from cache when possible. The owner's Deposit and DepositMachineID
+// are not set, services read them from the database when they need them.
+func (srv *userService) GetUserByToken(tokenPlainText, scope, ip string) (*data.User, error) {
+	cacheable := scope == data.TokenScopeAuthentication
+
+	var user *data.User
+	var ok bool
+
+	if cacheable {
+		user, ok = srv.cache.User(tokenPlainText)
+	}
+
+	if !ok {
+		var token *data.Token
+		var err error
+
+		user, token, err = srv.repo.GetForToken(tokenPlainText, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		if cacheable {
+			srv.cache.SetUser(tokenPlainText, user, token.Expiry)
+		}
+	}
+
+	if err := srv.tokenService.Touch(tokenPlainText, ip); err != nil {
+		return nil, err
+	}
+
+	return user, nil
 }
 
 func (srv *userService) UpdateUser(user *data.User) error {
-	return srv.repo.Update(user)
+	if err := srv.repo.Update(user); err != nil {
+		return err
+	}
+
+	srv.cache.ForgetUser(user.ID)
+
+	return nil
+}
+
+func (srv *userService) GetUser(id int64) (*data.User, error) {
+	return srv.repo.GetByID(id)
+}
+
+// UpdateProfile changes the fields of the request that are set and copies the
+// result into user.
+func (srv *userService) UpdateProfile(user *data.User, request dto.UpdateUserRequest) (data.ValidationErrors, error) {
+
+	current, err := srv.repo.GetByID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Username != nil {
+		current.Username = *request.Username
+	}
+
+	v := validator.New()
+	if data.ValidateUsername(v, current.Username); !v.Valid() {
+		return v.Errors, nil
+	}
+
+	if err = srv.UpdateUser(current); err != nil {
+		if errors.Is(err, data.ErrDuplicateUsername) {
+			v.AddError("username", "a user with this username already exists")
+			return v.Errors, nil
+		}
+
+		return nil, err
+	}
+
+	*user = *current
+
+	return nil, nil
+}
+
+// ChangePassword sets a new password once the current one is confirmed, then
+// ends every other session of the user; the session of tokenPlainText is kept.
+func (srv *userService) ChangePassword(
+	user *data.User,
+	request dto.ChangePasswordRequest,
+	tokenPlainText string,
+) (data.ValidationErrors, error) {
+
+	v := validator.New()
+	v.Check(request.CurrentPassword != "", "current_password", "must be provided")
+	data.ValidatePasswordPlaintext(v, "new_password", request.NewPassword)
+	v.Check(request.NewPassword != request.CurrentPassword, "new_password", "must differ from the current password")
+	if !v.Valid() {
+		return v.Errors, nil
+	}
+
+	current, err := srv.repo.GetByID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	matchPassword, err := current.Password.Matches(request.CurrentPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchPassword {
+		v.AddError("current_password", "is incorrect")
+		return v.Errors, nil
+	}
+
+	if err = current.Password.Set(request.NewPassword); err != nil {
+		return nil, err
+	}
+
+	if err = srv.UpdateUser(current); err != nil {
+		return nil, err
+	}
+
+	if err = srv.tokenService.RevokeOthers(user.ID, tokenPlainText); err != nil {
+		return nil, err
+	}
+
+	*user = *current
+
+	return nil, nil
 }
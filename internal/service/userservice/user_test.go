@@ -0,0 +1,97 @@
+package userservice
+
+import (
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/internal/service/auth"
+	repo "github.com/terdia/mvp/mocks/repository"
+	"github.com/terdia/mvp/pkg/dto"
+)
+
+func TestUserService_ChangePassword(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+
+	users := repo.NewMockUserRepository(ctrl)
+	uService := NewUserService(users, nil, nil, auth.NewCache(10, time.Minute))
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("pa55word"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buyer := &data.User{ID: 2, Role: "buyer", Username: "buyer", Password: data.Password{Hash: hash}}
+
+	testCases := map[string]interface{}{
+		"WrongCurrentPassword": func() bool {
+			// arrange
+			users.EXPECT().GetByID(buyer.ID).Return(buyer, nil)
+
+			// act
+			validationErrs, err := uService.ChangePassword(buyer, dto.ChangePasswordRequest{
+				CurrentPassword: "password",
+				NewPassword:     "n3wpa55word",
+			}, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+
+			// assert
+			return err == nil && validationErrs["current_password"] != ""
+		},
+		"SamePassword": func() bool {
+			// act
+			validationErrs, err := uService.ChangePassword(buyer, dto.ChangePasswordRequest{
+				CurrentPassword: "pa55word",
+				NewPassword:     "pa55word",
+			}, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+
+			// assert
+			return err == nil && validationErrs["new_password"] != ""
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
+
+func TestUserService_UpdateProfile(t *testing.T) {
+
+	ctrl := gomock.NewController(t)
+
+	users := repo.NewMockUserRepository(ctrl)
+	uService := NewUserService(users, nil, nil, auth.NewCache(10, time.Minute))
+
+	testCases := map[string]interface{}{
+		"DuplicateUsername": func() bool {
+			// arrange
+			username := "taken"
+			users.EXPECT().GetByID(int64(2)).Return(&data.User{ID: 2, Username: "buyer"}, nil)
+			users.EXPECT().Update(gomock.Any()).Return(data.ErrDuplicateUsername)
+
+			user := &data.User{ID: 2, Username: "buyer"}
+
+			// act
+			validationErrs, err := uService.UpdateProfile(user, dto.UpdateUserRequest{Username: &username})
+
+			// assert
+			return err == nil && validationErrs["username"] != "" && user.Username == "buyer"
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS users_deposit_matches_ledger ON users;
+DROP FUNCTION IF EXISTS users_deposit_matches_ledger();
+ALTER TABLE users DROP CONSTRAINT IF EXISTS deposit_check;
+DROP TABLE IF EXISTS ledger_entries;
+DROP FUNCTION IF EXISTS ledger_entries_append_only();
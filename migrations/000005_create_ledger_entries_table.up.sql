@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS ledger_entries (
+     id bigserial PRIMARY KEY,
+     user_id bigint NOT NULL REFERENCES users ON DELETE RESTRICT,
+     kind varchar(20) NOT NULL,
+     amount numeric NOT NULL,
+     balance_after numeric NOT NULL,
+     created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_kind_check
+    CHECK (kind in ('opening_balance', 'deposit', 'purchase', 'change', 'reset'));
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_amount_check CHECK (amount <> 0);
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_balance_after_check CHECK (balance_after >= 0);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_user_id_idx ON ledger_entries (user_id, id);
+
+ALTER TABLE users ADD CONSTRAINT deposit_check CHECK (deposit >= 0);
+
+-- carry existing balances over so the ledger and users.deposit agree from the start.
+INSERT INTO ledger_entries (user_id, kind, amount, balance_after)
+SELECT id, 'opening_balance', deposit, deposit FROM users WHERE deposit <> 0;
+
+-- entries are never changed once written, corrections are new entries.
+CREATE OR REPLACE FUNCTION ledger_entries_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_entries_append_only();
+
+-- users.deposit is a projection of the ledger, checked when the transaction commits.
+CREATE OR REPLACE FUNCTION users_deposit_matches_ledger() RETURNS trigger AS $$
+BEGIN
+    IF NEW.deposit <> (SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE user_id = NEW.id) THEN
+        RAISE EXCEPTION 'deposit of user % does not match its ledger', NEW.id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER users_deposit_matches_ledger
+    AFTER INSERT OR UPDATE OF deposit ON users
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION users_deposit_matches_ledger();
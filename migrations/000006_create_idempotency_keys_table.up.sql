@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+     user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+     key varchar(255) NOT NULL,
+     fingerprint bytea NOT NULL,
+     status_code integer,
+     response jsonb,
+     created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+     PRIMARY KEY (user_id, key)
+);
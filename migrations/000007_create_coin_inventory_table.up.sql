@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS coin_inventory (
+     coin integer PRIMARY KEY,
+     quantity integer NOT NULL DEFAULT 0
+);
+
+ALTER TABLE coin_inventory ADD CONSTRAINT coin_inventory_coin_check CHECK (coin in (5, 10, 20, 50, 100));
+ALTER TABLE coin_inventory ADD CONSTRAINT coin_inventory_quantity_check CHECK (quantity >= 0);
+
+INSERT INTO coin_inventory (coin)
+VALUES (5), (10), (20), (50), (100);
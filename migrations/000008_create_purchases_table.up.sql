@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS purchases (
+     id bigserial PRIMARY KEY,
+     buyer_id bigint NOT NULL REFERENCES users ON DELETE RESTRICT,
+     seller_id bigint NOT NULL REFERENCES users ON DELETE RESTRICT,
+     product_id bigint REFERENCES products ON DELETE SET NULL,
+     product_name varchar NOT NULL,
+     product_cost numeric NOT NULL,
+     quantity integer NOT NULL,
+     amount_spent numeric NOT NULL,
+     change integer[] NOT NULL DEFAULT '{}',
+     created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE purchases ADD CONSTRAINT purchases_quantity_check CHECK (quantity > 0);
+
+CREATE INDEX IF NOT EXISTS purchases_buyer_id_idx ON purchases (buyer_id, created_at);
+CREATE INDEX IF NOT EXISTS purchases_seller_id_idx ON purchases (seller_id, created_at);
@@ -0,0 +1,8 @@
+DELETE FROM permissions WHERE code = 'refunds:manage';
+
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_kind_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_kind_check
+    CHECK (kind in ('opening_balance', 'deposit', 'purchase', 'change', 'reset'));
+
+DROP TABLE IF EXISTS refund_events;
+DROP TABLE IF EXISTS refunds;
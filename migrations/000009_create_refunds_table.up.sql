@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS refunds (
+     id bigserial PRIMARY KEY,
+     purchase_id bigint NOT NULL REFERENCES purchases ON DELETE RESTRICT,
+     buyer_id bigint NOT NULL REFERENCES users ON DELETE RESTRICT,
+     seller_id bigint NOT NULL REFERENCES users ON DELETE RESTRICT,
+     product_id bigint REFERENCES products ON DELETE SET NULL,
+     quantity integer NOT NULL,
+     amount numeric NOT NULL,
+     reason text NOT NULL,
+     status varchar(20) NOT NULL DEFAULT 'requested',
+     created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+     updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE refunds ADD CONSTRAINT refunds_status_check
+    CHECK (status in ('requested', 'approved', 'rejected', 'refunded'));
+
+-- a purchase can only be refunded once, a rejected request may be opened again.
+CREATE UNIQUE INDEX IF NOT EXISTS refunds_purchase_id_key ON refunds (purchase_id) WHERE status <> 'rejected';
+CREATE INDEX IF NOT EXISTS refunds_buyer_id_idx ON refunds (buyer_id, created_at);
+CREATE INDEX IF NOT EXISTS refunds_seller_id_idx ON refunds (seller_id, created_at);
+
+CREATE TABLE IF NOT EXISTS refund_events (
+     id bigserial PRIMARY KEY,
+     refund_id bigint NOT NULL REFERENCES refunds ON DELETE CASCADE,
+     from_status varchar(20),
+     to_status varchar(20) NOT NULL,
+     actor_id bigint NOT NULL REFERENCES users ON DELETE RESTRICT,
+     note text NOT NULL DEFAULT '',
+     created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS refund_events_refund_id_idx ON refund_events (refund_id, id);
+
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_kind_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_kind_check
+    CHECK (kind in ('opening_balance', 'deposit', 'purchase', 'change', 'reset', 'refund'));
+
+-- held by admins, lets them resolve refunds for any seller.
+INSERT INTO permissions (code) VALUES ('refunds:manage');
@@ -0,0 +1,7 @@
+DELETE FROM products WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS products_deleted_at_idx;
+DROP INDEX IF EXISTS products_name_seller_id_key;
+ALTER TABLE products ADD CONSTRAINT products_name_seller_id_key UNIQUE (name, seller_id);
+
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
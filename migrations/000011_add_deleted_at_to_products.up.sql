@@ -0,0 +1,8 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at timestamp(0) with time zone;
+
+-- names only have to be unique among a seller's live products, an archived
+-- product keeps its name until it is restored.
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_name_seller_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS products_name_seller_id_key ON products (name, seller_id) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS products_deleted_at_idx ON products (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE code = 'categories:write';
+
+DROP TABLE IF EXISTS products_tags;
+DROP TABLE IF EXISTS products_categories;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS categories (
+     id bigserial PRIMARY KEY,
+     name varchar(100) NOT NULL,
+     slug varchar(100) NOT NULL,
+     parent_id bigint REFERENCES categories ON DELETE RESTRICT,
+     created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+     CONSTRAINT categories_slug_key UNIQUE (slug),
+     CONSTRAINT categories_parent_check CHECK (parent_id <> id)
+);
+
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id);
+
+CREATE TABLE IF NOT EXISTS products_categories (
+     product_id bigint NOT NULL REFERENCES products ON DELETE CASCADE,
+     category_id bigint NOT NULL REFERENCES categories ON DELETE CASCADE,
+     PRIMARY KEY (product_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS products_categories_category_id_idx ON products_categories (category_id);
+
+CREATE TABLE IF NOT EXISTS products_tags (
+     product_id bigint NOT NULL REFERENCES products ON DELETE CASCADE,
+     tag varchar(50) NOT NULL,
+     PRIMARY KEY (product_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS products_tags_tag_idx ON products_tags (tag);
+
+INSERT INTO categories (name, slug)
+VALUES
+    ('Snacks', 'snacks'),
+    ('Drinks', 'drinks'),
+    ('Healthy', 'healthy');
+
+INSERT INTO permissions (code) VALUES ('categories:write');
@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE code = 'slots:write';
+
+ALTER TABLE purchases DROP COLUMN IF EXISTS slot_code;
+
+DROP TABLE IF EXISTS slots;
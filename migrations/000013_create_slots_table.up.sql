@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS slots (
+     id bigserial PRIMARY KEY,
+     code varchar(2) NOT NULL,
+     capacity integer NOT NULL DEFAULT 10,
+     product_id bigint REFERENCES products ON DELETE SET NULL,
+     quantity integer NOT NULL DEFAULT 0,
+     updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+     CONSTRAINT slots_code_key UNIQUE (code)
+);
+
+ALTER TABLE slots ADD CONSTRAINT slots_code_check CHECK (code ~ '^[A-F][1-8]$');
+ALTER TABLE slots ADD CONSTRAINT slots_capacity_check CHECK (capacity > 0);
+ALTER TABLE slots ADD CONSTRAINT slots_quantity_check CHECK (quantity >= 0 AND quantity <= capacity);
+
+CREATE INDEX IF NOT EXISTS slots_product_id_idx ON slots (product_id);
+
+-- six rows of eight spirals, A1 to F8.
+INSERT INTO slots (code)
+SELECT chr(64 + r) || c
+FROM generate_series(1, 6) AS r, generate_series(1, 8) AS c;
+
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS slot_code varchar(2);
+
+-- held by machine operators, lets them load any product into any slot.
+INSERT INTO permissions (code) VALUES ('slots:write');
@@ -0,0 +1,18 @@
+DELETE FROM permissions WHERE code = 'machines:write';
+
+ALTER TABLE users DROP COLUMN IF EXISTS deposit_machine_id;
+ALTER TABLE purchases DROP COLUMN IF EXISTS machine_id;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS machine_id;
+
+DELETE FROM slots WHERE machine_id <> 1;
+ALTER TABLE slots DROP CONSTRAINT slots_machine_id_code_key;
+ALTER TABLE slots DROP COLUMN IF EXISTS machine_id;
+ALTER TABLE slots ADD CONSTRAINT slots_code_key UNIQUE (code);
+
+DELETE FROM coin_inventory WHERE machine_id <> 1;
+ALTER TABLE coin_inventory DROP CONSTRAINT coin_inventory_pkey;
+ALTER TABLE coin_inventory DROP COLUMN IF EXISTS machine_id;
+ALTER TABLE coin_inventory ADD PRIMARY KEY (coin);
+
+DROP TABLE IF EXISTS machine_products;
+DROP TABLE IF EXISTS machines;
@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS machines (
+     id bigserial PRIMARY KEY,
+     name text NOT NULL,
+     location text NOT NULL DEFAULT '',
+     created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+     CONSTRAINT machines_name_key UNIQUE (name)
+);
+
+-- everything recorded so far happened in the one machine the service used to model.
+INSERT INTO machines (id, name) VALUES (1, 'default');
+SELECT setval('machines_id_seq', 1);
+
+-- products.quantity stays the total across the fleet, machine_products splits it by machine.
+CREATE TABLE IF NOT EXISTS machine_products (
+     machine_id bigint NOT NULL REFERENCES machines ON DELETE CASCADE,
+     product_id bigint NOT NULL REFERENCES products ON DELETE CASCADE,
+     quantity integer NOT NULL DEFAULT 0,
+     PRIMARY KEY (machine_id, product_id)
+);
+
+ALTER TABLE machine_products ADD CONSTRAINT machine_products_quantity_check CHECK (quantity >= 0);
+
+CREATE INDEX IF NOT EXISTS machine_products_product_id_idx ON machine_products (product_id);
+
+INSERT INTO machine_products (machine_id, product_id, quantity)
+SELECT 1, id, quantity FROM products;
+
+ALTER TABLE coin_inventory ADD COLUMN IF NOT EXISTS machine_id bigint NOT NULL DEFAULT 1 REFERENCES machines ON DELETE CASCADE;
+ALTER TABLE coin_inventory DROP CONSTRAINT coin_inventory_pkey;
+ALTER TABLE coin_inventory ADD PRIMARY KEY (machine_id, coin);
+
+ALTER TABLE slots ADD COLUMN IF NOT EXISTS machine_id bigint NOT NULL DEFAULT 1 REFERENCES machines ON DELETE CASCADE;
+ALTER TABLE slots DROP CONSTRAINT slots_code_key;
+ALTER TABLE slots ADD CONSTRAINT slots_machine_id_code_key UNIQUE (machine_id, code);
+
+-- ledger entries are append-only, so older entries keep a NULL machine.
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS machine_id bigint REFERENCES machines ON DELETE RESTRICT;
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS machine_id bigint REFERENCES machines ON DELETE SET NULL;
+UPDATE purchases SET machine_id = 1;
+
+-- the machine holding the coins behind a user's deposit, NULL while the deposit is 0.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deposit_machine_id bigint REFERENCES machines ON DELETE SET NULL;
+UPDATE users SET deposit_machine_id = 1 WHERE deposit > 0;
+
+INSERT INTO permissions (code) VALUES ('machines:write');
@@ -0,0 +1,15 @@
+-- only the sha256 hash of a key is stored, prefix is kept in the clear so a key can be recognised in listings.
+CREATE TABLE IF NOT EXISTS api_keys (
+     id bigserial PRIMARY KEY,
+     machine_id bigint NOT NULL REFERENCES machines ON DELETE CASCADE,
+     name text NOT NULL,
+     prefix text NOT NULL,
+     hash bytea NOT NULL,
+     scopes text[] NOT NULL DEFAULT '{}',
+     expiry timestamp(0) with time zone,
+     last_used_at timestamp(0) with time zone,
+     created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+     CONSTRAINT api_keys_hash_key UNIQUE (hash)
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_machine_id_idx ON api_keys (machine_id);
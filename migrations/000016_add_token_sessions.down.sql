@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS tokens_user_id_idx;
+
+ALTER TABLE tokens DROP COLUMN IF EXISTS last_seen_at;
+ALTER TABLE tokens DROP COLUMN IF EXISTS last_seen_ip;
+ALTER TABLE tokens DROP COLUMN IF EXISTS created_at;
+ALTER TABLE tokens DROP CONSTRAINT IF EXISTS tokens_id_key;
+ALTER TABLE tokens DROP COLUMN IF EXISTS id;
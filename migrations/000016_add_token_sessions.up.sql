@@ -0,0 +1,8 @@
+-- tokens double as sessions, the id lets a session be referred to without exposing its hash.
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS id bigserial;
+ALTER TABLE tokens ADD CONSTRAINT tokens_id_key UNIQUE (id);
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS created_at timestamp(0) with time zone NOT NULL DEFAULT NOW();
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS last_seen_ip text NOT NULL DEFAULT '';
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS last_seen_at timestamp(0) with time zone;
+
+CREATE INDEX IF NOT EXISTS tokens_user_id_idx ON tokens (user_id);
@@ -0,0 +1,8 @@
+DELETE FROM tokens WHERE scope = 'refresh';
+
+DROP INDEX IF EXISTS tokens_family_id_idx;
+
+ALTER TABLE tokens DROP COLUMN IF EXISTS rotated_at;
+ALTER TABLE tokens DROP COLUMN IF EXISTS family_id;
+
+DROP SEQUENCE IF EXISTS tokens_family_id_seq;
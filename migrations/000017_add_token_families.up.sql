@@ -0,0 +1,10 @@
+-- the tokens handed out by one login share a family, every refresh stays in it.
+-- rotated refresh tokens are kept until they expire so that reuse can be detected.
+CREATE SEQUENCE IF NOT EXISTS tokens_family_id_seq;
+
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS family_id bigint NOT NULL DEFAULT nextval('tokens_family_id_seq');
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS rotated_at timestamp(0) with time zone;
+
+ALTER SEQUENCE tokens_family_id_seq OWNED BY tokens.family_id;
+
+CREATE INDEX IF NOT EXISTS tokens_family_id_idx ON tokens (family_id);
@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
+DROP TABLE IF EXISTS audit_log;
+
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_kind_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_kind_check
+    CHECK (kind in ('opening_balance', 'deposit', 'purchase', 'change', 'reset', 'refund'));
+
+DELETE FROM permissions WHERE code IN ('users:read', 'users:write', 'deposits:adjust');
+
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
+
+UPDATE users SET role = 'buyer' WHERE role = 'admin';
+ALTER TABLE users DROP CONSTRAINT role_check;
+ALTER TABLE users ADD CONSTRAINT role_check CHECK (role in ('seller', 'buyer'));
@@ -0,0 +1,44 @@
+ALTER TABLE users DROP CONSTRAINT role_check;
+ALTER TABLE users ADD CONSTRAINT role_check CHECK (role in ('seller', 'buyer', 'admin'));
+
+-- suspended users can not log in, their tokens are deleted when they are suspended.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at timestamp(0) with time zone;
+
+INSERT INTO permissions (code)
+VALUES
+    ('users:read'),
+    ('users:write'),
+    ('deposits:adjust');
+
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_kind_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_kind_check
+    CHECK (kind in ('opening_balance', 'deposit', 'purchase', 'change', 'reset', 'refund', 'adjustment'));
+
+-- every admin action, written in the same transaction as the change it records.
+CREATE TABLE IF NOT EXISTS audit_log (
+     id bigserial PRIMARY KEY,
+     actor_id bigint NOT NULL REFERENCES users ON DELETE RESTRICT,
+     action text NOT NULL,
+     target_user_id bigint REFERENCES users ON DELETE RESTRICT,
+     reason text NOT NULL DEFAULT '',
+     details jsonb NOT NULL DEFAULT '{}',
+     created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_target_user_id_idx ON audit_log (target_user_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_actor_id_idx ON audit_log (actor_id, id);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- there is no way to register as an admin, the first one is promoted by hand:
+-- UPDATE users SET role = 'admin' WHERE username = '...';
+-- INSERT INTO users_permissions SELECT users.id, permissions.id FROM users, permissions
+-- WHERE users.username = '...' AND permissions.code IN ('users:read', 'users:write', 'deposits:adjust');
@@ -0,0 +1,20 @@
+-- hand the role permissions back to the users as direct grants.
+INSERT INTO users_permissions
+SELECT users.id, roles_permissions.permission_id
+FROM users
+INNER JOIN roles ON roles.name = users.role
+INNER JOIN roles_permissions ON roles_permissions.role_id = roles.id
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_fkey;
+UPDATE users SET role = 'buyer' WHERE role NOT IN ('seller', 'buyer', 'admin');
+ALTER TABLE users ADD CONSTRAINT role_check CHECK (role in ('seller', 'buyer', 'admin'));
+
+DROP TABLE IF EXISTS roles_permissions;
+DROP TABLE IF EXISTS roles;
+
+DELETE FROM permissions WHERE code = 'permissions:write';
+
+ALTER TABLE permissions DROP CONSTRAINT IF EXISTS permissions_code_key;
+ALTER TABLE permissions DROP COLUMN IF EXISTS created_at;
+ALTER TABLE permissions DROP COLUMN IF EXISTS description;
@@ -0,0 +1,44 @@
+ALTER TABLE permissions ADD COLUMN IF NOT EXISTS description text NOT NULL DEFAULT '';
+ALTER TABLE permissions ADD COLUMN IF NOT EXISTS created_at timestamp(0) with time zone NOT NULL DEFAULT NOW();
+ALTER TABLE permissions ADD CONSTRAINT permissions_code_key UNIQUE (code);
+
+INSERT INTO permissions (code) VALUES ('permissions:write');
+
+-- a role is a named bundle of permissions. users hold the permissions of their
+-- role plus the ones granted to them directly in users_permissions.
+CREATE TABLE IF NOT EXISTS roles (
+     id bigserial PRIMARY KEY,
+     name varchar(15) NOT NULL,
+     created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+     CONSTRAINT roles_name_key UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS roles_permissions (
+     role_id bigint NOT NULL REFERENCES roles ON DELETE CASCADE,
+     permission_id bigint NOT NULL REFERENCES permissions ON DELETE CASCADE,
+     PRIMARY KEY (role_id, permission_id)
+);
+
+INSERT INTO roles (name) VALUES ('buyer'), ('seller'), ('admin');
+
+INSERT INTO roles_permissions
+SELECT roles.id, permissions.id FROM roles, permissions
+WHERE (roles.name = 'buyer' AND permissions.code IN ('products:read', 'products:buy'))
+OR (roles.name = 'seller' AND permissions.code IN ('products:read', 'products:write'))
+OR (roles.name = 'admin' AND permissions.code IN (
+    'products:read', 'users:read', 'users:write', 'deposits:adjust', 'permissions:write',
+    'refunds:manage', 'categories:write', 'machines:write', 'slots:write'
+));
+
+-- the grants copied from the role at registration now come from the role itself.
+DELETE FROM users_permissions
+USING users, roles, roles_permissions
+WHERE users_permissions.user_id = users.id
+AND roles.name = users.role
+AND roles_permissions.role_id = roles.id
+AND roles_permissions.permission_id = users_permissions.permission_id;
+
+ALTER TABLE users DROP CONSTRAINT role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES roles (name) ON DELETE RESTRICT;
+
+-- promoting the first admin now only takes: UPDATE users SET role = 'admin' WHERE username = '...';
@@ -0,0 +1,5 @@
+INSERT INTO permissions (code, description) VALUES ('metrics:read', 'read the counters at /debug/vars');
+
+INSERT INTO roles_permissions
+SELECT roles.id, permissions.id FROM roles, permissions
+WHERE roles.name = 'admin' AND permissions.code = 'metrics:read';
@@ -0,0 +1,3 @@
+-- deleted accounts are anonymised rather than removed, the purchases, ledger
+-- entries and audit log referencing them are kept.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at timestamp(0) with time zone;
@@ -0,0 +1,4 @@
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS headers jsonb NOT NULL DEFAULT '{}';
+
+-- expired keys are purged in the background.
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at);
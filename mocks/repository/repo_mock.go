@@ -5,13 +5,313 @@
 package mocks
 
 import (
+	context "context"
+	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	data "github.com/terdia/mvp/internal/data"
+	repository "github.com/terdia/mvp/internal/repository"
 	dto "github.com/terdia/mvp/pkg/dto"
 )
 
+// MockDBTX is a mock of DBTX interface.
+type MockDBTX struct {
+	ctrl     *gomock.Controller
+	recorder *MockDBTXMockRecorder
+}
+
+// MockDBTXMockRecorder is the mock recorder for MockDBTX.
+type MockDBTXMockRecorder struct {
+	mock *MockDBTX
+}
+
+// NewMockDBTX creates a new mock instance.
+func NewMockDBTX(ctrl *gomock.Controller) *MockDBTX {
+	mock := &MockDBTX{ctrl: ctrl}
+	mock.recorder = &MockDBTXMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDBTX) EXPECT() *MockDBTXMockRecorder {
+	return m.recorder
+}
+
+// ExecContext mocks base method.
+func (m *MockDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecContext", varargs...)
+	ret0, _ := ret[0].(sql.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecContext indicates an expected call of ExecContext.
+func (mr *MockDBTXMockRecorder) ExecContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecContext", reflect.TypeOf((*MockDBTX)(nil).ExecContext), varargs...)
+}
+
+// QueryContext mocks base method.
+func (m *MockDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryContext", varargs...)
+	ret0, _ := ret[0].(*sql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryContext indicates an expected call of QueryContext.
+func (mr *MockDBTXMockRecorder) QueryContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryContext", reflect.TypeOf((*MockDBTX)(nil).QueryContext), varargs...)
+}
+
+// QueryRowContext mocks base method.
+func (m *MockDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRowContext", varargs...)
+	ret0, _ := ret[0].(*sql.Row)
+	return ret0
+}
+
+// QueryRowContext indicates an expected call of QueryRowContext.
+func (mr *MockDBTXMockRecorder) QueryRowContext(ctx, query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRowContext", reflect.TypeOf((*MockDBTX)(nil).QueryRowContext), varargs...)
+}
+
+// MockUnitOfWork is a mock of UnitOfWork interface.
+type MockUnitOfWork struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnitOfWorkMockRecorder
+}
+
+// MockUnitOfWorkMockRecorder is the mock recorder for MockUnitOfWork.
+type MockUnitOfWorkMockRecorder struct {
+	mock *MockUnitOfWork
+}
+
+// NewMockUnitOfWork creates a new mock instance.
+func NewMockUnitOfWork(ctrl *gomock.Controller) *MockUnitOfWork {
+	mock := &MockUnitOfWork{ctrl: ctrl}
+	mock.recorder = &MockUnitOfWorkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnitOfWork) EXPECT() *MockUnitOfWorkMockRecorder {
+	return m.recorder
+}
+
+// Audit mocks base method.
+func (m *MockUnitOfWork) Audit() repository.AuditRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Audit")
+	ret0, _ := ret[0].(repository.AuditRepository)
+	return ret0
+}
+
+// Audit indicates an expected call of Audit.
+func (mr *MockUnitOfWorkMockRecorder) Audit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Audit", reflect.TypeOf((*MockUnitOfWork)(nil).Audit))
+}
+
+// Coins mocks base method.
+func (m *MockUnitOfWork) Coins() repository.CoinRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Coins")
+	ret0, _ := ret[0].(repository.CoinRepository)
+	return ret0
+}
+
+// Coins indicates an expected call of Coins.
+func (mr *MockUnitOfWorkMockRecorder) Coins() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Coins", reflect.TypeOf((*MockUnitOfWork)(nil).Coins))
+}
+
+// Ledger mocks base method.
+func (m *MockUnitOfWork) Ledger() repository.LedgerRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ledger")
+	ret0, _ := ret[0].(repository.LedgerRepository)
+	return ret0
+}
+
+// Ledger indicates an expected call of Ledger.
+func (mr *MockUnitOfWorkMockRecorder) Ledger() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ledger", reflect.TypeOf((*MockUnitOfWork)(nil).Ledger))
+}
+
+// Machines mocks base method.
+func (m *MockUnitOfWork) Machines() repository.MachineRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Machines")
+	ret0, _ := ret[0].(repository.MachineRepository)
+	return ret0
+}
+
+// Machines indicates an expected call of Machines.
+func (mr *MockUnitOfWorkMockRecorder) Machines() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Machines", reflect.TypeOf((*MockUnitOfWork)(nil).Machines))
+}
+
+// Permissions mocks base method.
+func (m *MockUnitOfWork) Permissions() repository.PermissionRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Permissions")
+	ret0, _ := ret[0].(repository.PermissionRepository)
+	return ret0
+}
+
+// Permissions indicates an expected call of Permissions.
+func (mr *MockUnitOfWorkMockRecorder) Permissions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Permissions", reflect.TypeOf((*MockUnitOfWork)(nil).Permissions))
+}
+
+// Products mocks base method.
+func (m *MockUnitOfWork) Products() repository.ProductRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Products")
+	ret0, _ := ret[0].(repository.ProductRepository)
+	return ret0
+}
+
+// Products indicates an expected call of Products.
+func (mr *MockUnitOfWorkMockRecorder) Products() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Products", reflect.TypeOf((*MockUnitOfWork)(nil).Products))
+}
+
+// Purchases mocks base method.
+func (m *MockUnitOfWork) Purchases() repository.PurchaseRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purchases")
+	ret0, _ := ret[0].(repository.PurchaseRepository)
+	return ret0
+}
+
+// Purchases indicates an expected call of Purchases.
+func (mr *MockUnitOfWorkMockRecorder) Purchases() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purchases", reflect.TypeOf((*MockUnitOfWork)(nil).Purchases))
+}
+
+// Refunds mocks base method.
+func (m *MockUnitOfWork) Refunds() repository.RefundRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refunds")
+	ret0, _ := ret[0].(repository.RefundRepository)
+	return ret0
+}
+
+// Refunds indicates an expected call of Refunds.
+func (mr *MockUnitOfWorkMockRecorder) Refunds() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refunds", reflect.TypeOf((*MockUnitOfWork)(nil).Refunds))
+}
+
+// Roles mocks base method.
+func (m *MockUnitOfWork) Roles() repository.RoleRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Roles")
+	ret0, _ := ret[0].(repository.RoleRepository)
+	return ret0
+}
+
+// Roles indicates an expected call of Roles.
+func (mr *MockUnitOfWorkMockRecorder) Roles() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Roles", reflect.TypeOf((*MockUnitOfWork)(nil).Roles))
+}
+
+// Slots mocks base method.
+func (m *MockUnitOfWork) Slots() repository.SlotRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Slots")
+	ret0, _ := ret[0].(repository.SlotRepository)
+	return ret0
+}
+
+// Slots indicates an expected call of Slots.
+func (mr *MockUnitOfWorkMockRecorder) Slots() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Slots", reflect.TypeOf((*MockUnitOfWork)(nil).Slots))
+}
+
+// Users mocks base method.
+func (m *MockUnitOfWork) Users() repository.UserRepository {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Users")
+	ret0, _ := ret[0].(repository.UserRepository)
+	return ret0
+}
+
+// Users indicates an expected call of Users.
+func (mr *MockUnitOfWorkMockRecorder) Users() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Users", reflect.TypeOf((*MockUnitOfWork)(nil).Users))
+}
+
+// MockTransactor is a mock of Transactor interface.
+type MockTransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactorMockRecorder
+}
+
+// MockTransactorMockRecorder is the mock recorder for MockTransactor.
+type MockTransactorMockRecorder struct {
+	mock *MockTransactor
+}
+
+// NewMockTransactor creates a new mock instance.
+func NewMockTransactor(ctrl *gomock.Controller) *MockTransactor {
+	mock := &MockTransactor{ctrl: ctrl}
+	mock.recorder = &MockTransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactor) EXPECT() *MockTransactorMockRecorder {
+	return m.recorder
+}
+
+// WithinTransaction mocks base method.
+func (m *MockTransactor) WithinTransaction(fn func(repository.UnitOfWork) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTransaction", fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTransaction indicates an expected call of WithinTransaction.
+func (mr *MockTransactorMockRecorder) WithinTransaction(fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTransaction", reflect.TypeOf((*MockTransactor)(nil).WithinTransaction), fn)
+}
+
 // MockRepository is a mock of Repository interface.
 type MockRepository struct {
 	ctrl     *gomock.Controller
@@ -101,21 +401,68 @@ func (mr *MockUserRepositoryMockRecorder) Get(username interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserRepository)(nil).Get), username)
 }
 
-// GetForToken mocks base method.
-func (m *MockUserRepository) GetForToken(tokenPlainText, scope string) (*data.User, error) {
+// GetAll mocks base method.
+func (m *MockUserRepository) GetAll(filter data.UserFilter, filters data.Filters) ([]*data.User, data.Metadata, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetForToken", tokenPlainText, scope)
+	ret := m.ctrl.Call(m, "GetAll", filter, filters)
+	ret0, _ := ret[0].([]*data.User)
+	ret1, _ := ret[1].(data.Metadata)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockUserRepositoryMockRecorder) GetAll(filter, filters interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockUserRepository)(nil).GetAll), filter, filters)
+}
+
+// GetByID mocks base method.
+func (m *MockUserRepository) GetByID(id int64) (*data.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", id)
 	ret0, _ := ret[0].(*data.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// GetByID indicates an expected call of GetByID.
+func (mr *MockUserRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), id)
+}
+
+// GetForToken mocks base method.
+func (m *MockUserRepository) GetForToken(tokenPlainText, scope string) (*data.User, *data.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForToken", tokenPlainText, scope)
+	ret0, _ := ret[0].(*data.User)
+	ret1, _ := ret[1].(*data.Token)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // GetForToken indicates an expected call of GetForToken.
 func (mr *MockUserRepositoryMockRecorder) GetForToken(tokenPlainText, scope interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForToken", reflect.TypeOf((*MockUserRepository)(nil).GetForToken), tokenPlainText, scope)
 }
 
+// GetForUpdate mocks base method.
+func (m *MockUserRepository) GetForUpdate(id int64) (*data.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForUpdate", id)
+	ret0, _ := ret[0].(*data.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForUpdate indicates an expected call of GetForUpdate.
+func (mr *MockUserRepositoryMockRecorder) GetForUpdate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForUpdate", reflect.TypeOf((*MockUserRepository)(nil).GetForUpdate), id)
+}
+
 // Insert mocks base method.
 func (m *MockUserRepository) Insert(user *data.User) error {
 	m.ctrl.T.Helper()
@@ -144,6 +491,34 @@ func (mr *MockUserRepositoryMockRecorder) Update(user interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepository)(nil).Update), user)
 }
 
+// UpdateRole mocks base method.
+func (m *MockUserRepository) UpdateRole(user *data.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockUserRepositoryMockRecorder) UpdateRole(user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockUserRepository)(nil).UpdateRole), user)
+}
+
+// UpdateSuspendedAt mocks base method.
+func (m *MockUserRepository) UpdateSuspendedAt(user *data.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSuspendedAt", user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSuspendedAt indicates an expected call of UpdateSuspendedAt.
+func (mr *MockUserRepositoryMockRecorder) UpdateSuspendedAt(user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSuspendedAt", reflect.TypeOf((*MockUserRepository)(nil).UpdateSuspendedAt), user)
+}
+
 // MockProductRepository is a mock of ProductRepository interface.
 type MockProductRepository struct {
 	ctrl     *gomock.Controller
@@ -212,6 +587,51 @@ func (mr *MockProductRepositoryMockRecorder) GetAll(request interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockProductRepository)(nil).GetAll), request)
 }
 
+// GetAllByCursor mocks base method.
+func (m *MockProductRepository) GetAllByCursor(request dto.ListProductRequest, cursor data.Cursor, limit int) ([]*data.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllByCursor", request, cursor, limit)
+	ret0, _ := ret[0].([]*data.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllByCursor indicates an expected call of GetAllByCursor.
+func (mr *MockProductRepositoryMockRecorder) GetAllByCursor(request, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByCursor", reflect.TypeOf((*MockProductRepository)(nil).GetAllByCursor), request, cursor, limit)
+}
+
+// GetForUpdate mocks base method.
+func (m *MockProductRepository) GetForUpdate(id int64) (*data.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForUpdate", id)
+	ret0, _ := ret[0].(*data.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForUpdate indicates an expected call of GetForUpdate.
+func (mr *MockProductRepositoryMockRecorder) GetForUpdate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForUpdate", reflect.TypeOf((*MockProductRepository)(nil).GetForUpdate), id)
+}
+
+// GetIncludingArchived mocks base method.
+func (m *MockProductRepository) GetIncludingArchived(id int64) (*data.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIncludingArchived", id)
+	ret0, _ := ret[0].(*data.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIncludingArchived indicates an expected call of GetIncludingArchived.
+func (mr *MockProductRepositoryMockRecorder) GetIncludingArchived(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIncludingArchived", reflect.TypeOf((*MockProductRepository)(nil).GetIncludingArchived), id)
+}
+
 // Insert mocks base method.
 func (m *MockProductRepository) Insert(product *data.Product) error {
 	m.ctrl.T.Helper()
@@ -226,75 +646,1195 @@ func (mr *MockProductRepositoryMockRecorder) Insert(product interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockProductRepository)(nil).Insert), product)
 }
 
-// Update mocks base method.
-func (m *MockProductRepository) Update(product *data.Product) error {
+// LoadLabels mocks base method.
+func (m *MockProductRepository) LoadLabels(products []*data.Product) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", product)
+	ret := m.ctrl.Call(m, "LoadLabels", products)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Update indicates an expected call of Update.
-func (mr *MockProductRepositoryMockRecorder) Update(product interface{}) *gomock.Call {
+// LoadLabels indicates an expected call of LoadLabels.
+func (mr *MockProductRepositoryMockRecorder) LoadLabels(products interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProductRepository)(nil).Update), product)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadLabels", reflect.TypeOf((*MockProductRepository)(nil).LoadLabels), products)
 }
 
-// MockPermissionRepository is a mock of PermissionRepository interface.
-type MockPermissionRepository struct {
-	ctrl     *gomock.Controller
-	recorder *MockPermissionRepositoryMockRecorder
+// PurgeArchived mocks base method.
+func (m *MockProductRepository) PurgeArchived(before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeArchived", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// MockPermissionRepositoryMockRecorder is the mock recorder for MockPermissionRepository.
-type MockPermissionRepositoryMockRecorder struct {
-	mock *MockPermissionRepository
+// PurgeArchived indicates an expected call of PurgeArchived.
+func (mr *MockProductRepositoryMockRecorder) PurgeArchived(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeArchived", reflect.TypeOf((*MockProductRepository)(nil).PurgeArchived), before)
 }
 
-// NewMockPermissionRepository creates a new mock instance.
-func NewMockPermissionRepository(ctrl *gomock.Controller) *MockPermissionRepository {
-	mock := &MockPermissionRepository{ctrl: ctrl}
-	mock.recorder = &MockPermissionRepositoryMockRecorder{mock}
-	return mock
+// Restore mocks base method.
+func (m *MockProductRepository) Restore(product *data.Product) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", product)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockPermissionRepository) EXPECT() *MockPermissionRepositoryMockRecorder {
-	return m.recorder
+// Restore indicates an expected call of Restore.
+func (mr *MockProductRepositoryMockRecorder) Restore(product interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockProductRepository)(nil).Restore), product)
 }
 
-// AddForUser mocks base method.
-func (m *MockPermissionRepository) AddForUser(userID int64, codes ...string) error {
+// SetCategories mocks base method.
+func (m *MockProductRepository) SetCategories(productID int64, categoryIDs []int64) error {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{userID}
-	for _, a := range codes {
-		varargs = append(varargs, a)
+	ret := m.ctrl.Call(m, "SetCategories", productID, categoryIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCategories indicates an expected call of SetCategories.
+func (mr *MockProductRepositoryMockRecorder) SetCategories(productID, categoryIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCategories", reflect.TypeOf((*MockProductRepository)(nil).SetCategories), productID, categoryIDs)
+}
+
+// SetTags mocks base method.
+func (m *MockProductRepository) SetTags(productID int64, tags []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTags", productID, tags)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTags indicates an expected call of SetTags.
+func (mr *MockProductRepositoryMockRecorder) SetTags(productID, tags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTags", reflect.TypeOf((*MockProductRepository)(nil).SetTags), productID, tags)
+}
+
+// Update mocks base method.
+func (m *MockProductRepository) Update(product *data.Product, fromQuantity int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", product, fromQuantity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockProductRepositoryMockRecorder) Update(product, fromQuantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProductRepository)(nil).Update), product, fromQuantity)
+}
+
+// MockCategoryRepository is a mock of CategoryRepository interface.
+type MockCategoryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCategoryRepositoryMockRecorder
+}
+
+// MockCategoryRepositoryMockRecorder is the mock recorder for MockCategoryRepository.
+type MockCategoryRepositoryMockRecorder struct {
+	mock *MockCategoryRepository
+}
+
+// NewMockCategoryRepository creates a new mock instance.
+func NewMockCategoryRepository(ctrl *gomock.Controller) *MockCategoryRepository {
+	mock := &MockCategoryRepository{ctrl: ctrl}
+	mock.recorder = &MockCategoryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCategoryRepository) EXPECT() *MockCategoryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockCategoryRepository) Delete(id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCategoryRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCategoryRepository)(nil).Delete), id)
+}
+
+// Get mocks base method.
+func (m *MockCategoryRepository) Get(id int64) (*data.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", id)
+	ret0, _ := ret[0].(*data.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockCategoryRepositoryMockRecorder) Get(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCategoryRepository)(nil).Get), id)
+}
+
+// GetAll mocks base method.
+func (m *MockCategoryRepository) GetAll() ([]*data.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll")
+	ret0, _ := ret[0].([]*data.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockCategoryRepositoryMockRecorder) GetAll() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockCategoryRepository)(nil).GetAll))
+}
+
+// Insert mocks base method.
+func (m *MockCategoryRepository) Insert(category *data.Category) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockCategoryRepositoryMockRecorder) Insert(category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockCategoryRepository)(nil).Insert), category)
+}
+
+// IsDescendant mocks base method.
+func (m *MockCategoryRepository) IsDescendant(id, ancestorID int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsDescendant", id, ancestorID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsDescendant indicates an expected call of IsDescendant.
+func (mr *MockCategoryRepositoryMockRecorder) IsDescendant(id, ancestorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDescendant", reflect.TypeOf((*MockCategoryRepository)(nil).IsDescendant), id, ancestorID)
+}
+
+// Update mocks base method.
+func (m *MockCategoryRepository) Update(category *data.Category) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockCategoryRepositoryMockRecorder) Update(category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCategoryRepository)(nil).Update), category)
+}
+
+// MockPermissionRepository is a mock of PermissionRepository interface.
+type MockPermissionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPermissionRepositoryMockRecorder
+}
+
+// MockPermissionRepositoryMockRecorder is the mock recorder for MockPermissionRepository.
+type MockPermissionRepositoryMockRecorder struct {
+	mock *MockPermissionRepository
+}
+
+// NewMockPermissionRepository creates a new mock instance.
+func NewMockPermissionRepository(ctrl *gomock.Controller) *MockPermissionRepository {
+	mock := &MockPermissionRepository{ctrl: ctrl}
+	mock.recorder = &MockPermissionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPermissionRepository) EXPECT() *MockPermissionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddForUser mocks base method.
+func (m *MockPermissionRepository) AddForUser(userID int64, codes ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{userID}
+	for _, a := range codes {
+		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "AddForUser", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// AddForUser indicates an expected call of AddForUser.
-func (mr *MockPermissionRepositoryMockRecorder) AddForUser(userID interface{}, codes ...interface{}) *gomock.Call {
+// AddForUser indicates an expected call of AddForUser.
+func (mr *MockPermissionRepositoryMockRecorder) AddForUser(userID interface{}, codes ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{userID}, codes...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddForUser", reflect.TypeOf((*MockPermissionRepository)(nil).AddForUser), varargs...)
+}
+
+// Delete mocks base method.
+func (m *MockPermissionRepository) Delete(id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPermissionRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPermissionRepository)(nil).Delete), id)
+}
+
+// GetAll mocks base method.
+func (m *MockPermissionRepository) GetAll() ([]*data.Permission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll")
+	ret0, _ := ret[0].([]*data.Permission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockPermissionRepositoryMockRecorder) GetAll() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockPermissionRepository)(nil).GetAll))
+}
+
+// GetAllForUser mocks base method.
+func (m *MockPermissionRepository) GetAllForUser(userID int64) (data.Permissions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllForUser", userID)
+	ret0, _ := ret[0].(data.Permissions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllForUser indicates an expected call of GetAllForUser.
+func (mr *MockPermissionRepositoryMockRecorder) GetAllForUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllForUser", reflect.TypeOf((*MockPermissionRepository)(nil).GetAllForUser), userID)
+}
+
+// GetByCode mocks base method.
+func (m *MockPermissionRepository) GetByCode(code string) (*data.Permission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCode", code)
+	ret0, _ := ret[0].(*data.Permission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCode indicates an expected call of GetByCode.
+func (mr *MockPermissionRepositoryMockRecorder) GetByCode(code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCode", reflect.TypeOf((*MockPermissionRepository)(nil).GetByCode), code)
+}
+
+// GetGrantedForUser mocks base method.
+func (m *MockPermissionRepository) GetGrantedForUser(userID int64) (data.Permissions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrantedForUser", userID)
+	ret0, _ := ret[0].(data.Permissions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGrantedForUser indicates an expected call of GetGrantedForUser.
+func (mr *MockPermissionRepositoryMockRecorder) GetGrantedForUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrantedForUser", reflect.TypeOf((*MockPermissionRepository)(nil).GetGrantedForUser), userID)
+}
+
+// Insert mocks base method.
+func (m *MockPermissionRepository) Insert(permission *data.Permission) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", permission)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockPermissionRepositoryMockRecorder) Insert(permission interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockPermissionRepository)(nil).Insert), permission)
+}
+
+// RemoveForUser mocks base method.
+func (m *MockPermissionRepository) RemoveForUser(userID int64, codes ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{userID}
+	for _, a := range codes {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RemoveForUser", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveForUser indicates an expected call of RemoveForUser.
+func (mr *MockPermissionRepositoryMockRecorder) RemoveForUser(userID interface{}, codes ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{userID}, codes...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveForUser", reflect.TypeOf((*MockPermissionRepository)(nil).RemoveForUser), varargs...)
+}
+
+// MockRoleRepository is a mock of RoleRepository interface.
+type MockRoleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleRepositoryMockRecorder
+}
+
+// MockRoleRepositoryMockRecorder is the mock recorder for MockRoleRepository.
+type MockRoleRepositoryMockRecorder struct {
+	mock *MockRoleRepository
+}
+
+// NewMockRoleRepository creates a new mock instance.
+func NewMockRoleRepository(ctrl *gomock.Controller) *MockRoleRepository {
+	mock := &MockRoleRepository{ctrl: ctrl}
+	mock.recorder = &MockRoleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleRepository) EXPECT() *MockRoleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockRoleRepository) Delete(id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRoleRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRoleRepository)(nil).Delete), id)
+}
+
+// GetAll mocks base method.
+func (m *MockRoleRepository) GetAll() ([]*data.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll")
+	ret0, _ := ret[0].([]*data.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockRoleRepositoryMockRecorder) GetAll() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockRoleRepository)(nil).GetAll))
+}
+
+// GetByName mocks base method.
+func (m *MockRoleRepository) GetByName(name string) (*data.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByName", name)
+	ret0, _ := ret[0].(*data.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByName indicates an expected call of GetByName.
+func (mr *MockRoleRepositoryMockRecorder) GetByName(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByName", reflect.TypeOf((*MockRoleRepository)(nil).GetByName), name)
+}
+
+// Insert mocks base method.
+func (m *MockRoleRepository) Insert(role *data.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockRoleRepositoryMockRecorder) Insert(role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockRoleRepository)(nil).Insert), role)
+}
+
+// SetPermissions mocks base method.
+func (m *MockRoleRepository) SetPermissions(role *data.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPermissions", role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPermissions indicates an expected call of SetPermissions.
+func (mr *MockRoleRepositoryMockRecorder) SetPermissions(role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPermissions", reflect.TypeOf((*MockRoleRepository)(nil).SetPermissions), role)
+}
+
+// MockAuditRepository is a mock of AuditRepository interface.
+type MockAuditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryMockRecorder
+}
+
+// MockAuditRepositoryMockRecorder is the mock recorder for MockAuditRepository.
+type MockAuditRepositoryMockRecorder struct {
+	mock *MockAuditRepository
+}
+
+// NewMockAuditRepository creates a new mock instance.
+func NewMockAuditRepository(ctrl *gomock.Controller) *MockAuditRepository {
+	mock := &MockAuditRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepository) EXPECT() *MockAuditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetAll mocks base method.
+func (m *MockAuditRepository) GetAll(filter data.AuditFilter, filters data.Filters) ([]*data.AuditEntry, data.Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", filter, filters)
+	ret0, _ := ret[0].([]*data.AuditEntry)
+	ret1, _ := ret[1].(data.Metadata)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockAuditRepositoryMockRecorder) GetAll(filter, filters interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockAuditRepository)(nil).GetAll), filter, filters)
+}
+
+// Insert mocks base method.
+func (m *MockAuditRepository) Insert(entry *data.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockAuditRepositoryMockRecorder) Insert(entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockAuditRepository)(nil).Insert), entry)
+}
+
+// MockLedgerRepository is a mock of LedgerRepository interface.
+type MockLedgerRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLedgerRepositoryMockRecorder
+}
+
+// MockLedgerRepositoryMockRecorder is the mock recorder for MockLedgerRepository.
+type MockLedgerRepositoryMockRecorder struct {
+	mock *MockLedgerRepository
+}
+
+// NewMockLedgerRepository creates a new mock instance.
+func NewMockLedgerRepository(ctrl *gomock.Controller) *MockLedgerRepository {
+	mock := &MockLedgerRepository{ctrl: ctrl}
+	mock.recorder = &MockLedgerRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLedgerRepository) EXPECT() *MockLedgerRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockLedgerRepository) Append(entry *data.LedgerEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockLedgerRepositoryMockRecorder) Append(entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockLedgerRepository)(nil).Append), entry)
+}
+
+// GetAllForUser mocks base method.
+func (m *MockLedgerRepository) GetAllForUser(userID int64, filters data.Filters) ([]*data.LedgerEntry, data.Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllForUser", userID, filters)
+	ret0, _ := ret[0].([]*data.LedgerEntry)
+	ret1, _ := ret[1].(data.Metadata)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAllForUser indicates an expected call of GetAllForUser.
+func (mr *MockLedgerRepositoryMockRecorder) GetAllForUser(userID, filters interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllForUser", reflect.TypeOf((*MockLedgerRepository)(nil).GetAllForUser), userID, filters)
+}
+
+// MockPurchaseRepository is a mock of PurchaseRepository interface.
+type MockPurchaseRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPurchaseRepositoryMockRecorder
+}
+
+// MockPurchaseRepositoryMockRecorder is the mock recorder for MockPurchaseRepository.
+type MockPurchaseRepositoryMockRecorder struct {
+	mock *MockPurchaseRepository
+}
+
+// NewMockPurchaseRepository creates a new mock instance.
+func NewMockPurchaseRepository(ctrl *gomock.Controller) *MockPurchaseRepository {
+	mock := &MockPurchaseRepository{ctrl: ctrl}
+	mock.recorder = &MockPurchaseRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPurchaseRepository) EXPECT() *MockPurchaseRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockPurchaseRepository) Get(id int64) (*data.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", id)
+	ret0, _ := ret[0].(*data.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockPurchaseRepositoryMockRecorder) Get(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPurchaseRepository)(nil).Get), id)
+}
+
+// GetAllForBuyer mocks base method.
+func (m *MockPurchaseRepository) GetAllForBuyer(buyerID int64, filters data.Filters) ([]*data.Purchase, data.Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllForBuyer", buyerID, filters)
+	ret0, _ := ret[0].([]*data.Purchase)
+	ret1, _ := ret[1].(data.Metadata)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAllForBuyer indicates an expected call of GetAllForBuyer.
+func (mr *MockPurchaseRepositoryMockRecorder) GetAllForBuyer(buyerID, filters interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllForBuyer", reflect.TypeOf((*MockPurchaseRepository)(nil).GetAllForBuyer), buyerID, filters)
+}
+
+// Insert mocks base method.
+func (m *MockPurchaseRepository) Insert(purchase *data.Purchase) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", purchase)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockPurchaseRepositoryMockRecorder) Insert(purchase interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockPurchaseRepository)(nil).Insert), purchase)
+}
+
+// MockRefundRepository is a mock of RefundRepository interface.
+type MockRefundRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefundRepositoryMockRecorder
+}
+
+// MockRefundRepositoryMockRecorder is the mock recorder for MockRefundRepository.
+type MockRefundRepositoryMockRecorder struct {
+	mock *MockRefundRepository
+}
+
+// NewMockRefundRepository creates a new mock instance.
+func NewMockRefundRepository(ctrl *gomock.Controller) *MockRefundRepository {
+	mock := &MockRefundRepository{ctrl: ctrl}
+	mock.recorder = &MockRefundRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefundRepository) EXPECT() *MockRefundRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddEvent mocks base method.
+func (m *MockRefundRepository) AddEvent(event *data.RefundEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddEvent", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddEvent indicates an expected call of AddEvent.
+func (mr *MockRefundRepositoryMockRecorder) AddEvent(event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddEvent", reflect.TypeOf((*MockRefundRepository)(nil).AddEvent), event)
+}
+
+// Get mocks base method.
+func (m *MockRefundRepository) Get(id int64) (*data.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", id)
+	ret0, _ := ret[0].(*data.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockRefundRepositoryMockRecorder) Get(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRefundRepository)(nil).Get), id)
+}
+
+// GetAll mocks base method.
+func (m *MockRefundRepository) GetAll(filter data.RefundFilter, filters data.Filters) ([]*data.Refund, data.Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", filter, filters)
+	ret0, _ := ret[0].([]*data.Refund)
+	ret1, _ := ret[1].(data.Metadata)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockRefundRepositoryMockRecorder) GetAll(filter, filters interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockRefundRepository)(nil).GetAll), filter, filters)
+}
+
+// GetEvents mocks base method.
+func (m *MockRefundRepository) GetEvents(refundID int64) ([]*data.RefundEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvents", refundID)
+	ret0, _ := ret[0].([]*data.RefundEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvents indicates an expected call of GetEvents.
+func (mr *MockRefundRepositoryMockRecorder) GetEvents(refundID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvents", reflect.TypeOf((*MockRefundRepository)(nil).GetEvents), refundID)
+}
+
+// GetForUpdate mocks base method.
+func (m *MockRefundRepository) GetForUpdate(id int64) (*data.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForUpdate", id)
+	ret0, _ := ret[0].(*data.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForUpdate indicates an expected call of GetForUpdate.
+func (mr *MockRefundRepositoryMockRecorder) GetForUpdate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForUpdate", reflect.TypeOf((*MockRefundRepository)(nil).GetForUpdate), id)
+}
+
+// Insert mocks base method.
+func (m *MockRefundRepository) Insert(refund *data.Refund) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", refund)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockRefundRepositoryMockRecorder) Insert(refund interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockRefundRepository)(nil).Insert), refund)
+}
+
+// Update mocks base method.
+func (m *MockRefundRepository) Update(refund *data.Refund) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", refund)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockRefundRepositoryMockRecorder) Update(refund interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRefundRepository)(nil).Update), refund)
+}
+
+// MockSlotRepository is a mock of SlotRepository interface.
+type MockSlotRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSlotRepositoryMockRecorder
+}
+
+// MockSlotRepositoryMockRecorder is the mock recorder for MockSlotRepository.
+type MockSlotRepositoryMockRecorder struct {
+	mock *MockSlotRepository
+}
+
+// NewMockSlotRepository creates a new mock instance.
+func NewMockSlotRepository(ctrl *gomock.Controller) *MockSlotRepository {
+	mock := &MockSlotRepository{ctrl: ctrl}
+	mock.recorder = &MockSlotRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSlotRepository) EXPECT() *MockSlotRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetAll mocks base method.
+func (m *MockSlotRepository) GetAll(machineID int64) ([]*data.Slot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", machineID)
+	ret0, _ := ret[0].([]*data.Slot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockSlotRepositoryMockRecorder) GetAll(machineID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockSlotRepository)(nil).GetAll), machineID)
+}
+
+// GetAllForProductForUpdate mocks base method.
+func (m *MockSlotRepository) GetAllForProductForUpdate(machineID, productID int64) ([]*data.Slot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllForProductForUpdate", machineID, productID)
+	ret0, _ := ret[0].([]*data.Slot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllForProductForUpdate indicates an expected call of GetAllForProductForUpdate.
+func (mr *MockSlotRepositoryMockRecorder) GetAllForProductForUpdate(machineID, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllForProductForUpdate", reflect.TypeOf((*MockSlotRepository)(nil).GetAllForProductForUpdate), machineID, productID)
+}
+
+// GetByCode mocks base method.
+func (m *MockSlotRepository) GetByCode(machineID int64, code string) (*data.Slot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCode", machineID, code)
+	ret0, _ := ret[0].(*data.Slot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCode indicates an expected call of GetByCode.
+func (mr *MockSlotRepositoryMockRecorder) GetByCode(machineID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCode", reflect.TypeOf((*MockSlotRepository)(nil).GetByCode), machineID, code)
+}
+
+// GetByCodeForUpdate mocks base method.
+func (m *MockSlotRepository) GetByCodeForUpdate(machineID int64, code string) (*data.Slot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByCodeForUpdate", machineID, code)
+	ret0, _ := ret[0].(*data.Slot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByCodeForUpdate indicates an expected call of GetByCodeForUpdate.
+func (mr *MockSlotRepositoryMockRecorder) GetByCodeForUpdate(machineID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByCodeForUpdate", reflect.TypeOf((*MockSlotRepository)(nil).GetByCodeForUpdate), machineID, code)
+}
+
+// Update mocks base method.
+func (m *MockSlotRepository) Update(slot *data.Slot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", slot)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockSlotRepositoryMockRecorder) Update(slot interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockSlotRepository)(nil).Update), slot)
+}
+
+// MockMachineRepository is a mock of MachineRepository interface.
+type MockMachineRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMachineRepositoryMockRecorder
+}
+
+// MockMachineRepositoryMockRecorder is the mock recorder for MockMachineRepository.
+type MockMachineRepositoryMockRecorder struct {
+	mock *MockMachineRepository
+}
+
+// NewMockMachineRepository creates a new mock instance.
+func NewMockMachineRepository(ctrl *gomock.Controller) *MockMachineRepository {
+	mock := &MockMachineRepository{ctrl: ctrl}
+	mock.recorder = &MockMachineRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMachineRepository) EXPECT() *MockMachineRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AdjustStock mocks base method.
+func (m *MockMachineRepository) AdjustStock(machineID, productID int64, delta int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustStock", machineID, productID, delta)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AdjustStock indicates an expected call of AdjustStock.
+func (mr *MockMachineRepositoryMockRecorder) AdjustStock(machineID, productID, delta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustStock", reflect.TypeOf((*MockMachineRepository)(nil).AdjustStock), machineID, productID, delta)
+}
+
+// Get mocks base method.
+func (m *MockMachineRepository) Get(id int64) (*data.Machine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", id)
+	ret0, _ := ret[0].(*data.Machine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockMachineRepositoryMockRecorder) Get(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockMachineRepository)(nil).Get), id)
+}
+
+// GetAll mocks base method.
+func (m *MockMachineRepository) GetAll() ([]*data.Machine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll")
+	ret0, _ := ret[0].([]*data.Machine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockMachineRepositoryMockRecorder) GetAll() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockMachineRepository)(nil).GetAll))
+}
+
+// GetQuantityForUpdate mocks base method.
+func (m *MockMachineRepository) GetQuantityForUpdate(machineID, productID int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuantityForUpdate", machineID, productID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuantityForUpdate indicates an expected call of GetQuantityForUpdate.
+func (mr *MockMachineRepositoryMockRecorder) GetQuantityForUpdate(machineID, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuantityForUpdate", reflect.TypeOf((*MockMachineRepository)(nil).GetQuantityForUpdate), machineID, productID)
+}
+
+// GetStock mocks base method.
+func (m *MockMachineRepository) GetStock(machineID int64) ([]*data.MachineStock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStock", machineID)
+	ret0, _ := ret[0].([]*data.MachineStock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStock indicates an expected call of GetStock.
+func (mr *MockMachineRepositoryMockRecorder) GetStock(machineID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStock", reflect.TypeOf((*MockMachineRepository)(nil).GetStock), machineID)
+}
+
+// GetStockForProduct mocks base method.
+func (m *MockMachineRepository) GetStockForProduct(productID int64) ([]*data.MachineStock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStockForProduct", productID)
+	ret0, _ := ret[0].([]*data.MachineStock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStockForProduct indicates an expected call of GetStockForProduct.
+func (mr *MockMachineRepositoryMockRecorder) GetStockForProduct(productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockForProduct", reflect.TypeOf((*MockMachineRepository)(nil).GetStockForProduct), productID)
+}
+
+// Insert mocks base method.
+func (m *MockMachineRepository) Insert(machine *data.Machine) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", machine)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockMachineRepositoryMockRecorder) Insert(machine interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{userID}, codes...)
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddForUser", reflect.TypeOf((*MockPermissionRepository)(nil).AddForUser), varargs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockMachineRepository)(nil).Insert), machine)
 }
 
-// GetAllForUser mocks base method.
-func (m *MockPermissionRepository) GetAllForUser(userID int64) (data.Permissions, error) {
+// Update mocks base method.
+func (m *MockMachineRepository) Update(machine *data.Machine) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllForUser", userID)
-	ret0, _ := ret[0].(data.Permissions)
+	ret := m.ctrl.Call(m, "Update", machine)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockMachineRepositoryMockRecorder) Update(machine interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockMachineRepository)(nil).Update), machine)
+}
+
+// MockReportRepository is a mock of ReportRepository interface.
+type MockReportRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReportRepositoryMockRecorder
+}
+
+// MockReportRepositoryMockRecorder is the mock recorder for MockReportRepository.
+type MockReportRepositoryMockRecorder struct {
+	mock *MockReportRepository
+}
+
+// NewMockReportRepository creates a new mock instance.
+func NewMockReportRepository(ctrl *gomock.Controller) *MockReportRepository {
+	mock := &MockReportRepository{ctrl: ctrl}
+	mock.recorder = &MockReportRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReportRepository) EXPECT() *MockReportRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ProductSales mocks base method.
+func (m *MockReportRepository) ProductSales(sellerID int64) ([]*data.ProductSales, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProductSales", sellerID)
+	ret0, _ := ret[0].([]*data.ProductSales)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllForUser indicates an expected call of GetAllForUser.
-func (mr *MockPermissionRepositoryMockRecorder) GetAllForUser(userID interface{}) *gomock.Call {
+// ProductSales indicates an expected call of ProductSales.
+func (mr *MockReportRepositoryMockRecorder) ProductSales(sellerID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllForUser", reflect.TypeOf((*MockPermissionRepository)(nil).GetAllForUser), userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProductSales", reflect.TypeOf((*MockReportRepository)(nil).ProductSales), sellerID)
+}
+
+// RevenueByPeriod mocks base method.
+func (m *MockReportRepository) RevenueByPeriod(sellerID int64, period string, dateRange data.ReportRange) ([]*data.PeriodRevenue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevenueByPeriod", sellerID, period, dateRange)
+	ret0, _ := ret[0].([]*data.PeriodRevenue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevenueByPeriod indicates an expected call of RevenueByPeriod.
+func (mr *MockReportRepositoryMockRecorder) RevenueByPeriod(sellerID, period, dateRange interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevenueByPeriod", reflect.TypeOf((*MockReportRepository)(nil).RevenueByPeriod), sellerID, period, dateRange)
+}
+
+// MockCoinRepository is a mock of CoinRepository interface.
+type MockCoinRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCoinRepositoryMockRecorder
+}
+
+// MockCoinRepositoryMockRecorder is the mock recorder for MockCoinRepository.
+type MockCoinRepositoryMockRecorder struct {
+	mock *MockCoinRepository
+}
+
+// NewMockCoinRepository creates a new mock instance.
+func NewMockCoinRepository(ctrl *gomock.Controller) *MockCoinRepository {
+	mock := &MockCoinRepository{ctrl: ctrl}
+	mock.recorder = &MockCoinRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCoinRepository) EXPECT() *MockCoinRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Adjust mocks base method.
+func (m *MockCoinRepository) Adjust(machineID int64, coin, delta int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Adjust", machineID, coin, delta)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Adjust indicates an expected call of Adjust.
+func (mr *MockCoinRepositoryMockRecorder) Adjust(machineID, coin, delta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Adjust", reflect.TypeOf((*MockCoinRepository)(nil).Adjust), machineID, coin, delta)
+}
+
+// GetInventory mocks base method.
+func (m *MockCoinRepository) GetInventory(machineID int64) (data.CoinInventory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInventory", machineID)
+	ret0, _ := ret[0].(data.CoinInventory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInventory indicates an expected call of GetInventory.
+func (mr *MockCoinRepositoryMockRecorder) GetInventory(machineID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInventory", reflect.TypeOf((*MockCoinRepository)(nil).GetInventory), machineID)
+}
+
+// GetInventoryForUpdate mocks base method.
+func (m *MockCoinRepository) GetInventoryForUpdate(machineID int64) (data.CoinInventory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInventoryForUpdate", machineID)
+	ret0, _ := ret[0].(data.CoinInventory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInventoryForUpdate indicates an expected call of GetInventoryForUpdate.
+func (mr *MockCoinRepositoryMockRecorder) GetInventoryForUpdate(machineID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInventoryForUpdate", reflect.TypeOf((*MockCoinRepository)(nil).GetInventoryForUpdate), machineID)
+}
+
+// MockIdempotencyRepository is a mock of IdempotencyRepository interface.
+type MockIdempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepositoryMockRecorder
+}
+
+// MockIdempotencyRepositoryMockRecorder is the mock recorder for MockIdempotencyRepository.
+type MockIdempotencyRepositoryMockRecorder struct {
+	mock *MockIdempotencyRepository
+}
+
+// NewMockIdempotencyRepository creates a new mock instance.
+func NewMockIdempotencyRepository(ctrl *gomock.Controller) *MockIdempotencyRepository {
+	mock := &MockIdempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyRepository) EXPECT() *MockIdempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockIdempotencyRepository) Delete(userID int64, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", userID, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockIdempotencyRepositoryMockRecorder) Delete(userID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockIdempotencyRepository)(nil).Delete), userID, key)
+}
+
+// DeleteCreatedBefore mocks base method.
+func (m *MockIdempotencyRepository) DeleteCreatedBefore(before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCreatedBefore", before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteCreatedBefore indicates an expected call of DeleteCreatedBefore.
+func (mr *MockIdempotencyRepositoryMockRecorder) DeleteCreatedBefore(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCreatedBefore", reflect.TypeOf((*MockIdempotencyRepository)(nil).DeleteCreatedBefore), before)
+}
+
+// Get mocks base method.
+func (m *MockIdempotencyRepository) Get(userID int64, key string) (*data.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", userID, key)
+	ret0, _ := ret[0].(*data.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockIdempotencyRepositoryMockRecorder) Get(userID, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIdempotencyRepository)(nil).Get), userID, key)
+}
+
+// Insert mocks base method.
+func (m *MockIdempotencyRepository) Insert(key *data.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockIdempotencyRepositoryMockRecorder) Insert(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockIdempotencyRepository)(nil).Insert), key)
+}
+
+// Update mocks base method.
+func (m *MockIdempotencyRepository) Update(key *data.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockIdempotencyRepositoryMockRecorder) Update(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockIdempotencyRepository)(nil).Update), key)
 }
 
 // MockTokenRepository is a mock of TokenRepository interface.
@@ -347,3 +1887,185 @@ func (mr *MockTokenRepositoryMockRecorder) DeleteAllForUserByScope(scope, userID
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAllForUserByScope", reflect.TypeOf((*MockTokenRepository)(nil).DeleteAllForUserByScope), scope, userID)
 }
+
+// DeleteFamily mocks base method.
+func (m *MockTokenRepository) DeleteFamily(familyID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFamily", familyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFamily indicates an expected call of DeleteFamily.
+func (mr *MockTokenRepositoryMockRecorder) DeleteFamily(familyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFamily", reflect.TypeOf((*MockTokenRepository)(nil).DeleteFamily), familyID)
+}
+
+// DeleteFamilyByHash mocks base method.
+func (m *MockTokenRepository) DeleteFamilyByHash(hash []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFamilyByHash", hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFamilyByHash indicates an expected call of DeleteFamilyByHash.
+func (mr *MockTokenRepositoryMockRecorder) DeleteFamilyByHash(hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFamilyByHash", reflect.TypeOf((*MockTokenRepository)(nil).DeleteFamilyByHash), hash)
+}
+
+// DeleteOtherFamilies mocks base method.
+func (m *MockTokenRepository) DeleteOtherFamilies(userID int64, hash []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOtherFamilies", userID, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOtherFamilies indicates an expected call of DeleteOtherFamilies.
+func (mr *MockTokenRepositoryMockRecorder) DeleteOtherFamilies(userID, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOtherFamilies", reflect.TypeOf((*MockTokenRepository)(nil).DeleteOtherFamilies), userID, hash)
+}
+
+// GetAllForUser mocks base method.
+func (m *MockTokenRepository) GetAllForUser(userID int64, scope string) ([]*data.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllForUser", userID, scope)
+	ret0, _ := ret[0].([]*data.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllForUser indicates an expected call of GetAllForUser.
+func (mr *MockTokenRepositoryMockRecorder) GetAllForUser(userID, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllForUser", reflect.TypeOf((*MockTokenRepository)(nil).GetAllForUser), userID, scope)
+}
+
+// GetRotated mocks base method.
+func (m *MockTokenRepository) GetRotated(hash []byte) (*data.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRotated", hash)
+	ret0, _ := ret[0].(*data.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRotated indicates an expected call of GetRotated.
+func (mr *MockTokenRepositoryMockRecorder) GetRotated(hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRotated", reflect.TypeOf((*MockTokenRepository)(nil).GetRotated), hash)
+}
+
+// Rotate mocks base method.
+func (m *MockTokenRepository) Rotate(hash []byte) (*data.Token, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate", hash)
+	ret0, _ := ret[0].(*data.Token)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rotate indicates an expected call of Rotate.
+func (mr *MockTokenRepositoryMockRecorder) Rotate(hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockTokenRepository)(nil).Rotate), hash)
+}
+
+// Touch mocks base method.
+func (m *MockTokenRepository) Touch(hash []byte, ip string, staleBefore time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Touch", hash, ip, staleBefore)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Touch indicates an expected call of Touch.
+func (mr *MockTokenRepositoryMockRecorder) Touch(hash, ip, staleBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Touch", reflect.TypeOf((*MockTokenRepository)(nil).Touch), hash, ip, staleBefore)
+}
+
+// MockAPIKeyRepository is a mock of APIKeyRepository interface.
+type MockAPIKeyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyRepositoryMockRecorder
+}
+
+// MockAPIKeyRepositoryMockRecorder is the mock recorder for MockAPIKeyRepository.
+type MockAPIKeyRepositoryMockRecorder struct {
+	mock *MockAPIKeyRepository
+}
+
+// NewMockAPIKeyRepository creates a new mock instance.
+func NewMockAPIKeyRepository(ctrl *gomock.Controller) *MockAPIKeyRepository {
+	mock := &MockAPIKeyRepository{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyRepository) EXPECT() *MockAPIKeyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockAPIKeyRepository) Delete(machineID, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", machineID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAPIKeyRepositoryMockRecorder) Delete(machineID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAPIKeyRepository)(nil).Delete), machineID, id)
+}
+
+// GetAllForMachine mocks base method.
+func (m *MockAPIKeyRepository) GetAllForMachine(machineID int64) ([]*data.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllForMachine", machineID)
+	ret0, _ := ret[0].([]*data.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllForMachine indicates an expected call of GetAllForMachine.
+func (mr *MockAPIKeyRepositoryMockRecorder) GetAllForMachine(machineID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllForMachine", reflect.TypeOf((*MockAPIKeyRepository)(nil).GetAllForMachine), machineID)
+}
+
+// GetForKey mocks base method.
+func (m *MockAPIKeyRepository) GetForKey(keyPlainText string) (*data.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForKey", keyPlainText)
+	ret0, _ := ret[0].(*data.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForKey indicates an expected call of GetForKey.
+func (mr *MockAPIKeyRepositoryMockRecorder) GetForKey(keyPlainText interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForKey", reflect.TypeOf((*MockAPIKeyRepository)(nil).GetForKey), keyPlainText)
+}
+
+// Insert mocks base method.
+func (m *MockAPIKeyRepository) Insert(key *data.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockAPIKeyRepositoryMockRecorder) Insert(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockAPIKeyRepository)(nil).Insert), key)
+}
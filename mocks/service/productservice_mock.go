@@ -6,6 +6,7 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	data "github.com/terdia/mvp/internal/data"
@@ -65,6 +66,21 @@ func (mr *MockProductServiceMockRecorder) GetOne(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOne", reflect.TypeOf((*MockProductService)(nil).GetOne), arg0)
 }
 
+// GetOneIncludingArchived mocks base method.
+func (m *MockProductService) GetOneIncludingArchived(arg0 int64) (*data.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOneIncludingArchived", arg0)
+	ret0, _ := ret[0].(*data.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOneIncludingArchived indicates an expected call of GetOneIncludingArchived.
+func (mr *MockProductServiceMockRecorder) GetOneIncludingArchived(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOneIncludingArchived", reflect.TypeOf((*MockProductService)(nil).GetOneIncludingArchived), arg0)
+}
+
 // List mocks base method.
 func (m *MockProductService) List(arg0 dto.ListProductRequest) ([]*data.Product, data.Metadata, error) {
 	m.ctrl.T.Helper()
@@ -81,6 +97,54 @@ func (mr *MockProductServiceMockRecorder) List(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockProductService)(nil).List), arg0)
 }
 
+// ListByCursor mocks base method.
+func (m *MockProductService) ListByCursor(r dto.ListProductRequest, cursor string) ([]*data.Product, data.CursorMetadata, map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByCursor", r, cursor)
+	ret0, _ := ret[0].([]*data.Product)
+	ret1, _ := ret[1].(data.CursorMetadata)
+	ret2, _ := ret[2].(map[string]string)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// ListByCursor indicates an expected call of ListByCursor.
+func (mr *MockProductServiceMockRecorder) ListByCursor(r, cursor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByCursor", reflect.TypeOf((*MockProductService)(nil).ListByCursor), r, cursor)
+}
+
+// Patch mocks base method.
+func (m *MockProductService) Patch(id int64, seller data.User, version int32, input dto.UpdateProductRequest) (*data.Product, map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", id, seller, version, input)
+	ret0, _ := ret[0].(*data.Product)
+	ret1, _ := ret[1].(map[string]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockProductServiceMockRecorder) Patch(id, seller, version, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockProductService)(nil).Patch), id, seller, version, input)
+}
+
+// PurgeArchived mocks base method.
+func (m *MockProductService) PurgeArchived(retention time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeArchived", retention)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeArchived indicates an expected call of PurgeArchived.
+func (mr *MockProductServiceMockRecorder) PurgeArchived(retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeArchived", reflect.TypeOf((*MockProductService)(nil).PurgeArchived), retention)
+}
+
 // Remove mocks base method.
 func (m *MockProductService) Remove(arg0 data.Product) error {
 	m.ctrl.T.Helper()
@@ -95,6 +159,54 @@ func (mr *MockProductServiceMockRecorder) Remove(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockProductService)(nil).Remove), arg0)
 }
 
+// Restore mocks base method.
+func (m *MockProductService) Restore(arg0 data.Product) (*data.Product, map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0)
+	ret0, _ := ret[0].(*data.Product)
+	ret1, _ := ret[1].(map[string]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockProductServiceMockRecorder) Restore(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockProductService)(nil).Restore), arg0)
+}
+
+// SetCategories mocks base method.
+func (m *MockProductService) SetCategories(request data.Product, categoryIDs []int64) (*data.Product, map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCategories", request, categoryIDs)
+	ret0, _ := ret[0].(*data.Product)
+	ret1, _ := ret[1].(map[string]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SetCategories indicates an expected call of SetCategories.
+func (mr *MockProductServiceMockRecorder) SetCategories(request, categoryIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCategories", reflect.TypeOf((*MockProductService)(nil).SetCategories), request, categoryIDs)
+}
+
+// SetTags mocks base method.
+func (m *MockProductService) SetTags(request data.Product, tags []string) (*data.Product, map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTags", request, tags)
+	ret0, _ := ret[0].(*data.Product)
+	ret1, _ := ret[1].(map[string]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SetTags indicates an expected call of SetTags.
+func (mr *MockProductServiceMockRecorder) SetTags(request, tags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTags", reflect.TypeOf((*MockProductService)(nil).SetTags), request, tags)
+}
+
 // Update mocks base method.
 func (m *MockProductService) Update(arg0 data.Product) (*data.Product, map[string]string, error) {
 	m.ctrl.T.Helper()
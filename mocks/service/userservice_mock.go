@@ -35,6 +35,21 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockUserService) ChangePassword(user *data.User, request dto.ChangePasswordRequest, tokenPlainText string) (data.ValidationErrors, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", user, request, tokenPlainText)
+	ret0, _ := ret[0].(data.ValidationErrors)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserServiceMockRecorder) ChangePassword(user, request, tokenPlainText interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserService)(nil).ChangePassword), user, request, tokenPlainText)
+}
+
 // Create mocks base method.
 func (m *MockUserService) Create(request dto.CreateUserRequest) (*data.User, data.ValidationErrors, error) {
 	m.ctrl.T.Helper()
@@ -52,19 +67,19 @@ func (mr *MockUserServiceMockRecorder) Create(request interface{}) *gomock.Call
 }
 
 // CreateAuthenticationToken mocks base method.
-func (m *MockUserService) CreateAuthenticationToken(request dto.AuthTokenRequest, scope string) (*data.Token, data.ValidationErrors, error) {
+func (m *MockUserService) CreateAuthenticationToken(request dto.AuthTokenRequest) (*data.TokenPair, data.ValidationErrors, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAuthenticationToken", request, scope)
-	ret0, _ := ret[0].(*data.Token)
+	ret := m.ctrl.Call(m, "CreateAuthenticationToken", request)
+	ret0, _ := ret[0].(*data.TokenPair)
 	ret1, _ := ret[1].(data.ValidationErrors)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
 // CreateAuthenticationToken indicates an expected call of CreateAuthenticationToken.
-func (mr *MockUserServiceMockRecorder) CreateAuthenticationToken(request, scope interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) CreateAuthenticationToken(request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuthenticationToken", reflect.TypeOf((*MockUserService)(nil).CreateAuthenticationToken), request, scope)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuthenticationToken", reflect.TypeOf((*MockUserService)(nil).CreateAuthenticationToken), request)
 }
 
 // GetPermissions mocks base method.
@@ -82,19 +97,49 @@ func (mr *MockUserServiceMockRecorder) GetPermissions(userID interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPermissions", reflect.TypeOf((*MockUserService)(nil).GetPermissions), userID)
 }
 
+// GetUser mocks base method.
+func (m *MockUserService) GetUser(id int64) (*data.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", id)
+	ret0, _ := ret[0].(*data.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUserServiceMockRecorder) GetUser(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUserService)(nil).GetUser), id)
+}
+
 // GetUserByToken mocks base method.
-func (m *MockUserService) GetUserByToken(tokenPlainText, scope string) (*data.User, error) {
+func (m *MockUserService) GetUserByToken(tokenPlainText, scope, ip string) (*data.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByToken", tokenPlainText, scope)
+	ret := m.ctrl.Call(m, "GetUserByToken", tokenPlainText, scope, ip)
 	ret0, _ := ret[0].(*data.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserByToken indicates an expected call of GetUserByToken.
-func (mr *MockUserServiceMockRecorder) GetUserByToken(tokenPlainText, scope interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) GetUserByToken(tokenPlainText, scope, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByToken", reflect.TypeOf((*MockUserService)(nil).GetUserByToken), tokenPlainText, scope, ip)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockUserService) UpdateProfile(user *data.User, request dto.UpdateUserRequest) (data.ValidationErrors, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", user, request)
+	ret0, _ := ret[0].(data.ValidationErrors)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUserServiceMockRecorder) UpdateProfile(user, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByToken", reflect.TypeOf((*MockUserService)(nil).GetUserByToken), tokenPlainText, scope)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserService)(nil).UpdateProfile), user, request)
 }
 
 // UpdateUser mocks base method.
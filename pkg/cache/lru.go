@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a fixed size cache safe for concurrent use. Entries expire after the
+// TTL, or earlier when set with SetUntil; when the cache is full the least
+// recently used entry makes room. A cache of size 0 stores nothing.
+type LRU[K comparable, V any] struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[K]*list.Element
+	now   func() time.Time
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+func New[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[K]*list.Element),
+		now:   time.Now,
+	}
+}
+
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	e := el.Value.(*entry[K, V])
+	if !c.now().Before(e.expires) {
+		c.remove(el)
+		return zero, false
+	}
+
+	c.ll.MoveToFront(el)
+
+	return e.value, true
+}
+
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.SetUntil(key, value, time.Time{})
+}
+
+// SetUntil stores value until expires or the TTL, whichever comes first. A zero
+// expires only applies the TTL.
+func (c *LRU[K, V]) SetUntil(key K, value V, expires time.Time) {
+	if c.size <= 0 || c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deadline := c.now().Add(c.ttl)
+	if !expires.IsZero() && expires.Before(deadline) {
+		deadline = expires
+	}
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value, e.expires = value, deadline
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&entry[K, V]{key: key, value: value, expires: deadline})
+
+	if c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *LRU[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// DeleteFunc removes every entry fn returns true for.
+func (c *LRU[K, V]) DeleteFunc(fn func(key K, value V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for el := c.ll.Front(); el != nil; {
+		next := el.Next()
+
+		e := el.Value.(*entry[K, V])
+		if fn(e.key, e.value) {
+			c.remove(el)
+		}
+
+		el = next
+	}
+}
+
+// Purge removes every entry.
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[K]*list.Element)
+}
+
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *LRU[K, V]) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
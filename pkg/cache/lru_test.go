@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+
+	now := time.Now()
+
+	newCache := func(size int) *LRU[string, int] {
+		c := New[string, int](size, time.Minute)
+		c.now = func() time.Time { return now }
+		return c
+	}
+
+	testCases := map[string]interface{}{
+		"EvictsLeastRecentlyUsed": func() bool {
+			c := newCache(2)
+			c.Set("a", 1)
+			c.Set("b", 2)
+			c.Get("a")
+			c.Set("c", 3)
+
+			_, b := c.Get("b")
+			a, _ := c.Get("a")
+			cv, _ := c.Get("c")
+
+			return !b && a == 1 && cv == 3 && c.Len() == 2
+		},
+		"ExpiresAfterTTL": func() bool {
+			c := newCache(2)
+			c.Set("a", 1)
+
+			c.now = func() time.Time { return now.Add(time.Minute) }
+			_, ok := c.Get("a")
+
+			return !ok && c.Len() == 0
+		},
+		"ExpiresBeforeTTL": func() bool {
+			c := newCache(2)
+			c.SetUntil("a", 1, now.Add(time.Second))
+
+			_, before := c.Get("a")
+			c.now = func() time.Time { return now.Add(time.Second) }
+			_, after := c.Get("a")
+
+			return before && !after
+		},
+		"DeleteFunc": func() bool {
+			c := newCache(3)
+			c.Set("a", 1)
+			c.Set("b", 2)
+			c.Set("c", 1)
+			c.DeleteFunc(func(_ string, v int) bool { return v == 1 })
+
+			_, b := c.Get("b")
+
+			return b && c.Len() == 1
+		},
+		"ZeroSizeStoresNothing": func() bool {
+			c := newCache(0)
+			c.Set("a", 1)
+			_, ok := c.Get("a")
+
+			return !ok
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(tc, nil); err != nil {
+				t.Errorf("%v case failed with an error: %+v", name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/terdia/mvp/internal/data"
+)
+
+type (
+	RoleRequest struct {
+		Role   string `json:"role"`
+		Reason string `json:"reason"`
+	}
+
+	SuspendRequest struct {
+		Reason string `json:"reason"`
+	}
+
+	// DepositAdjustmentRequest credits a positive Amount to the deposit and
+	// debits a negative one.
+	DepositAdjustmentRequest struct {
+		Amount int    `json:"amount"`
+		Reason string `json:"reason"`
+	}
+
+	DepositAdjustmentResponse struct {
+		User  APIUser        `json:"user"`
+		Entry APILedgerEntry `json:"ledger_entry"`
+	}
+
+	ListUserResponse struct {
+		Users    []APIUser      `json:"users"`
+		Metadata *data.Metadata `json:"metadata,omitempty"`
+	}
+
+	APIAuditEntry struct {
+		ID           int64                  `json:"id"`
+		ActorID      int64                  `json:"actor_id"`
+		Action       string                 `json:"action"`
+		TargetUserID int64                  `json:"target_user_id,omitempty"`
+		Reason       string                 `json:"reason,omitempty"`
+		Details      map[string]interface{} `json:"details,omitempty"`
+		CreatedAt    time.Time              `json:"created_at"`
+	}
+
+	ListAuditResponse struct {
+		Entries  []APIAuditEntry `json:"entries"`
+		Metadata *data.Metadata  `json:"metadata,omitempty"`
+	}
+)
@@ -28,6 +28,27 @@ func (r StatusMessage) MarshalJSON() ([]byte, error) {
 
 }
 
+func (r *StatusMessage) UnmarshalJSON(b []byte) error {
+
+	status, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case "success":
+		*r = Success
+	case "fail":
+		*r = Fail
+	case "error":
+		*r = Error
+	default:
+		return errors.New("unsupported response status")
+	}
+
+	return nil
+}
+
 type ResponseObject struct {
 	StatusMsg StatusMessage `json:"status"` //(success|fail|error)
 	Message   string        `json:"message,omitempty"`
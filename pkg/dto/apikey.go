@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+)
+
+type (
+	APIKeyRequest struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	// APIKey carries the plaintext Key only in the response that created it.
+	APIKey struct {
+		ID         int64      `json:"id"`
+		MachineID  int64      `json:"machine_id"`
+		Name       string     `json:"name"`
+		Key        string     `json:"key,omitempty"`
+		Prefix     string     `json:"prefix"`
+		Scopes     []string   `json:"scopes"`
+		ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+		LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+
+	APIKeyResponse struct {
+		APIKey APIKey `json:"api_key"`
+	}
+
+	ListAPIKeyResponse struct {
+		APIKeys []APIKey `json:"api_keys"`
+	}
+)
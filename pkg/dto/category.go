@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"time"
+)
+
+type (
+	CategoryRequest struct {
+		Name     *string `json:"name"`
+		Slug     *string `json:"slug"`
+		ParentID *int64  `json:"parent_id"`
+	}
+
+	APICategory struct {
+		ID        int64     `json:"id"`
+		Name      string    `json:"name"`
+		Slug      string    `json:"slug"`
+		ParentID  int64     `json:"parent_id,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	CategoryResponse struct {
+		Category APICategory `json:"category"`
+	}
+
+	ListCategoryResponse struct {
+		Categories []APICategory `json:"categories"`
+	}
+)
@@ -0,0 +1,17 @@
+package dto
+
+type (
+	RefillCoinsRequest struct {
+		Coin     int `json:"coin"`     // 5|10|20|50|100
+		Quantity int `json:"quantity"` // number of coins loaded into the machine
+	}
+
+	APICoin struct {
+		Coin     int `json:"coin"`
+		Quantity int `json:"quantity"`
+	}
+
+	CoinInventoryResponse struct {
+		Coins []APICoin `json:"coins"`
+	}
+)
@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/terdia/mvp/internal/data"
+)
+
+type (
+	APILedgerEntry struct {
+		ID           int64     `json:"id"`
+		MachineID    int64     `json:"machine_id,omitempty"`
+		Kind         string    `json:"kind"`
+		Amount       int       `json:"amount"`
+		BalanceAfter int       `json:"balance_after"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+
+	ListLedgerResponse struct {
+		Metadata *data.Metadata   `json:"metadata,omitempty"`
+		Entries  []APILedgerEntry `json:"entries"`
+	}
+)
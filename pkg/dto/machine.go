@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"time"
+)
+
+type (
+	// MachineRequest leaves nil fields unchanged on update.
+	MachineRequest struct {
+		Name     *string `json:"name"`
+		Location *string `json:"location"`
+	}
+
+	RestockRequest struct {
+		Quantity int `json:"quantity"`
+	}
+
+	APIMachine struct {
+		ID        int64     `json:"id"`
+		Name      string    `json:"name"`
+		Location  string    `json:"location,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	APIMachineStock struct {
+		MachineID   int64  `json:"machine_id"`
+		MachineName string `json:"machine_name"`
+		ProductID   int64  `json:"product_id"`
+		ProductName string `json:"product_name"`
+		Cost        int    `json:"cost"`
+		Quantity    int    `json:"quantity"`
+	}
+
+	MachineResponse struct {
+		Machine APIMachine `json:"machine"`
+	}
+
+	ListMachineResponse struct {
+		Machines []APIMachine `json:"machines"`
+	}
+
+	MachineStockResponse struct {
+		Stock APIMachineStock `json:"stock"`
+	}
+
+	ListMachineStockResponse struct {
+		Stock []APIMachineStock `json:"stock"`
+	}
+)
@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"time"
+)
+
+type (
+	PermissionRequest struct {
+		Code        string `json:"code"`
+		Description string `json:"description"`
+	}
+
+	APIPermission struct {
+		ID          int64     `json:"id"`
+		Code        string    `json:"code"`
+		Description string    `json:"description,omitempty"`
+		Builtin     bool      `json:"builtin"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	PermissionResponse struct {
+		Permission APIPermission `json:"permission"`
+	}
+
+	ListPermissionResponse struct {
+		Permissions []APIPermission `json:"permissions"`
+	}
+
+	CreateRoleRequest struct {
+		Name        string   `json:"name"`
+		Permissions []string `json:"permissions"`
+	}
+
+	// RolePermissionsRequest replaces every permission of a role.
+	RolePermissionsRequest struct {
+		Permissions []string `json:"permissions"`
+	}
+
+	APIRole struct {
+		ID          int64     `json:"id"`
+		Name        string    `json:"name"`
+		Permissions []string  `json:"permissions"`
+		Builtin     bool      `json:"builtin"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	RoleResponse struct {
+		Role APIRole `json:"role"`
+	}
+
+	ListRoleResponse struct {
+		Roles []APIRole `json:"roles"`
+	}
+
+	// APIUserPermissions shows where a user's permissions come from;
+	// Permissions is everything the user may do.
+	APIUserPermissions struct {
+		UserID          int64    `json:"user_id"`
+		Role            string   `json:"role"`
+		RolePermissions []string `json:"role_permissions"`
+		Granted         []string `json:"granted"`
+		Permissions     []string `json:"permissions"`
+	}
+
+	UserPermissionsResponse struct {
+		Permissions APIUserPermissions `json:"user_permissions"`
+	}
+)
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/terdia/mvp/internal/data"
+	"github.com/terdia/mvp/pkg/validator"
 )
 
 type (
@@ -13,35 +14,124 @@ type (
 		Quantity int     `json:"amount_available"`
 	}
 
+	// UpdateProductRequest leaves nil fields unchanged.
+	UpdateProductRequest struct {
+		Name     *string `json:"name"`
+		Cost     *int    `json:"cost"`
+		Quantity *int    `json:"amount_available"`
+	}
+
 	ProductResponse struct {
 		Product APIProduct `json:"product"`
 	}
 
 	APIProduct struct {
-		ID              int64     `json:"id"`
-		Cost            int       `json:"cost"`
-		Name            string    `json:"name"`
-		CreatedAt       time.Time `json:"created_at"`
-		AmountAvailable int       `json:"amount_available"`
+		ID              int64         `json:"id"`
+		Cost            int           `json:"cost"`
+		Name            string        `json:"name"`
+		CreatedAt       time.Time     `json:"created_at"`
+		AmountAvailable int           `json:"amount_available"`
+		Version         int32         `json:"version"`
+		ArchivedAt      *time.Time    `json:"archived_at,omitempty"`
+		Categories      []APICategory `json:"categories"`
+		Tags            []string      `json:"tags"`
+	}
+
+	ProductCategoriesRequest struct {
+		CategoryIDs []int64 `json:"category_ids"`
+	}
+
+	ProductTagsRequest struct {
+		Tags []string `json:"tags"`
 	}
 
+	// ListProductRequest narrows the product listing. Zero values leave the
+	// matching filter off; CreatedBefore is exclusive.
 	ListProductRequest struct {
-		Name    string
-		Filters data.Filters
+		Name            string
+		IncludeArchived bool
+		MinCost         int
+		MaxCost         int
+		SellerID        int64
+		InStock         bool
+		CreatedAfter    time.Time
+		CreatedBefore   time.Time
+		Category        string
+		Tag             string
+		Filters         data.Filters
 	}
 
 	ListProductResponse struct {
-		Metadata *data.Metadata `json:"metadata,omitempty"`
-		Products []APIProduct   `json:"products"`
+		Metadata       *data.Metadata       `json:"metadata,omitempty"`
+		CursorMetadata *data.CursorMetadata `json:"cursor_metadata,omitempty"`
+		Products       []APIProduct         `json:"products"`
+	}
+
+	PurchaseRequest struct {
+		ProductID int64  `json:"product_id"`
+		Quantity  int    `json:"quantity"`
+		Slot      string `json:"slot,omitempty"`
+	}
+
+	CheckoutRequest struct {
+		Items []CartLine `json:"items"`
+	}
+
+	CartLine struct {
+		ProductID int64  `json:"product_id"`
+		Quantity  int    `json:"quantity"`
+		Slot      string `json:"slot,omitempty"`
+	}
+
+	CheckoutResponse struct {
+		Lines       []ReceiptLine `json:"lines"`
+		AmountSpent int           `json:"amount_spent"`
+		Change      []int         `json:"change"`
+	}
+
+	ReceiptLine struct {
+		PurchaseID int64  `json:"purchase_id"`
+		ProductID  int64  `json:"product_id"`
+		Name       string `json:"name"`
+		Cost       int    `json:"cost"`
+		Quantity   int    `json:"quantity_purchased"`
+		Total      int    `json:"line_total"`
+		Slot       string `json:"slot,omitempty"`
 	}
 
 	BuyProductResponse struct {
-		AmountSpent int `json:"amount_spent"`
+		PurchaseID  int64 `json:"purchase_id"`
+		AmountSpent int   `json:"amount_spent"`
 		Product     struct {
 			Name     string `json:"name"`
 			Cost     int    `json:"cost"`
 			Quantity int    `json:"quantity_purchased"`
 		} `json:"product_details"`
-		Change []int `json:"change"`
+		Change []int  `json:"change"`
+		Slot   string `json:"slot,omitempty"`
 	}
 )
+
+func (r ListProductRequest) Validate(v *validator.Validator) {
+	r.Filters.ValidateFilters(v)
+
+	v.Check(r.MinCost >= 0, "min_cost", "must not be negative")
+	v.Check(r.MaxCost >= 0, "max_cost", "must not be negative")
+	if r.MinCost > 0 && r.MaxCost > 0 {
+		v.Check(r.MinCost <= r.MaxCost, "min_cost", "must not be greater than max_cost")
+	}
+
+	v.Check(r.SellerID >= 0, "seller_id", "must be a positive integer")
+
+	if r.Category != "" {
+		v.Check(validator.Matches(r.Category, data.SlugRX), "category", "must be a category slug")
+	}
+
+	if r.Tag != "" {
+		v.Check(validator.Matches(r.Tag, data.SlugRX), "tag", "must only contain lowercase letters, digits and dashes")
+	}
+
+	if !r.CreatedAfter.IsZero() && !r.CreatedBefore.IsZero() {
+		v.Check(r.CreatedAfter.Before(r.CreatedBefore), "created_after", "must be before created_before")
+	}
+}
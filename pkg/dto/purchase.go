@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/terdia/mvp/internal/data"
+)
+
+type (
+	APIPurchase struct {
+		ID          int64     `json:"id"`
+		SellerID    int64     `json:"seller_id"`
+		MachineID   int64     `json:"machine_id,omitempty"`
+		ProductID   int64     `json:"product_id,omitempty"`
+		ProductName string    `json:"product_name"`
+		ProductCost int       `json:"product_cost"`
+		Quantity    int       `json:"quantity_purchased"`
+		AmountSpent int       `json:"amount_spent"`
+		Change      []int     `json:"change"`
+		Slot        string    `json:"slot,omitempty"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	PurchaseResponse struct {
+		Purchase APIPurchase `json:"purchase"`
+	}
+
+	ListPurchaseResponse struct {
+		Metadata  *data.Metadata `json:"metadata,omitempty"`
+		Purchases []APIPurchase  `json:"purchases"`
+	}
+)
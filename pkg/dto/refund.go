@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/terdia/mvp/internal/data"
+)
+
+type (
+	RefundRequest struct {
+		Reason string `json:"reason"`
+	}
+
+	ResolveRefundRequest struct {
+		Note string `json:"note"`
+	}
+
+	APIRefund struct {
+		ID         int64            `json:"id"`
+		PurchaseID int64            `json:"purchase_id"`
+		BuyerID    int64            `json:"buyer_id"`
+		SellerID   int64            `json:"seller_id"`
+		ProductID  int64            `json:"product_id,omitempty"`
+		Quantity   int              `json:"quantity"`
+		Amount     int              `json:"amount"`
+		Reason     string           `json:"reason"`
+		Status     string           `json:"status"`
+		Events     []APIRefundEvent `json:"events,omitempty"`
+		CreatedAt  time.Time        `json:"created_at"`
+		UpdatedAt  time.Time        `json:"updated_at"`
+	}
+
+	APIRefundEvent struct {
+		FromStatus string    `json:"from_status,omitempty"`
+		ToStatus   string    `json:"to_status"`
+		ActorID    int64     `json:"actor_id"`
+		Note       string    `json:"note,omitempty"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+
+	RefundResponse struct {
+		Refund APIRefund `json:"refund"`
+	}
+
+	ListRefundResponse struct {
+		Metadata *data.Metadata `json:"metadata,omitempty"`
+		Refunds  []APIRefund    `json:"refunds"`
+	}
+)
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"time"
+)
+
+type (
+	APIProductSales struct {
+		ProductID       int64  `json:"product_id"`
+		Name            string `json:"name"`
+		UnitsSold       int    `json:"units_sold"`
+		Revenue         int    `json:"revenue"`
+		AmountAvailable int    `json:"amount_available"`
+	}
+
+	ProductSalesReport struct {
+		Products []APIProductSales `json:"products"`
+	}
+
+	APIPeriodRevenue struct {
+		Period    time.Time `json:"period"`
+		UnitsSold int       `json:"units_sold"`
+		Revenue   int       `json:"revenue"`
+	}
+
+	RevenueReport struct {
+		Period  string             `json:"period"`
+		Revenue []APIPeriodRevenue `json:"revenue"`
+	}
+)
@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"time"
+)
+
+type (
+	SlotRequest struct {
+		ProductID int64 `json:"product_id"`
+		Quantity  int   `json:"quantity"`
+		Capacity  *int  `json:"capacity"`
+	}
+
+	APISlot struct {
+		Code      string    `json:"code"`
+		Capacity  int       `json:"capacity"`
+		ProductID int64     `json:"product_id,omitempty"`
+		Quantity  int       `json:"quantity"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	SlotResponse struct {
+		Slot APISlot `json:"slot"`
+	}
+
+	ListSlotResponse struct {
+		Slots []APISlot `json:"slots"`
+	}
+)
@@ -5,7 +5,8 @@ import (
 )
 
 type TokenResponse struct {
-	Token Token `json:"authentication_token"`
+	Token        Token `json:"authentication_token"`
+	RefreshToken Token `json:"refresh_token"`
 }
 
 type Token struct {
@@ -17,3 +18,23 @@ type AuthTokenRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Session is a login whose refresh token is still valid, identified by its
+// token family. Expiry is when the refresh token expires. Current marks the
+// one the request was made with.
+type Session struct {
+	ID         int64      `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Expiry     time.Time  `json:"expiry"`
+	LastSeenIP string     `json:"last_seen_ip,omitempty"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	Current    bool       `json:"current"`
+}
+
+type ListSessionResponse struct {
+	Sessions []Session `json:"sessions"`
+}
@@ -10,14 +10,49 @@ type CreateUserRequest struct {
 	Password string `json:"password"` // minimum 6 bytes maximum 72 bytes
 }
 
+type UpdateUserRequest struct {
+	Username *string `json:"username"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"` // minimum 6 bytes maximum 72 bytes
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// ExportToken describes a token without the token itself.
+type ExportToken struct {
+	ID         int64      `json:"id"`
+	Scope      string     `json:"scope"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Expiry     time.Time  `json:"expiry"`
+	LastSeenIP string     `json:"last_seen_ip,omitempty"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+}
+
 type UserResponse struct {
 	User APIUser `json:"user"`
 }
 
 type APIUser struct {
-	ID        int64     `json:"id"`
-	Username  string    `json:"username"`
-	Role      string    `json:"role"`
-	Deposit   int       `json:"deposit"`
-	CreatedAt time.Time `json:"created_at"`
+	ID               int64      `json:"id"`
+	Username         string     `json:"username"`
+	Role             string     `json:"role"`
+	Deposit          int        `json:"deposit"`
+	DepositMachineID int64      `json:"deposit_machine_id,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+}
+
+type DepositRequest struct {
+	Amount int `json:"amount"` // 5|10|20|50|100
+}
+
+type ReturnCoinsResponse struct {
+	Change []int   `json:"change"`
+	User   APIUser `json:"user"`
 }
@@ -1,5 +1,9 @@
 package validator
 
+import (
+	"regexp"
+)
+
 type Validator struct {
 	Errors map[string]string
 }
@@ -33,3 +37,17 @@ func In[T comparable](value T, elem []T) bool {
 
 	return false
 }
+
+func Matches(value string, rx *regexp.Regexp) bool {
+	return rx.MatchString(value)
+}
+
+func Unique[T comparable](values []T) bool {
+	uniqueValues := make(map[T]bool)
+
+	for _, value := range values {
+		uniqueValues[value] = true
+	}
+
+	return len(values) == len(uniqueValues)
+}